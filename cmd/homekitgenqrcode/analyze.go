@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"strings"
+
+	"github.com/lordbasex/HomeKitGenQRCode/internal/generator"
+
+	"github.com/spf13/cobra"
+)
+
+// Variables for analyze command flags
+var (
+	analyzeWidth float64   // Printed label width in mm (0 for the size at LabelDPI)
+	analyzeDPIs  []float64 // Printer resolutions to simulate
+)
+
+// analyzeCmd scores how well a rendered label survives printing
+var analyzeCmd = &cobra.Command{
+	Use:   "analyze <label.png>",
+	Short: "Score how well a label scans after printing",
+	Long: `Simulate printing a rendered label and read it back under degraded
+conditions: downsampling to the printer resolution, Gaussian blur and ink
+spread, then noise and contrast loss at the resolution of a camera reading
+the print. For each condition the QR code and barcodes
+are decoded, and the results are combined into a robustness score.
+
+The report includes the QR module size on paper, a recommended error
+correction level and, for each printer resolution, the minimum print width
+at which the QR code and the whole label still read.
+
+By default the label is analysed at its design size (300 DPI); use --width
+for the size it will actually be printed at.
+
+Examples:
+  homekitgenqrcode analyze example.png
+  homekitgenqrcode analyze example.png --width 50 --dpi 203
+  homekitgenqrcode analyze example.png --output-format json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runAnalyze,
+}
+
+func init() {
+	analyzeCmd.Flags().Float64Var(&analyzeWidth, "width", 0, "Printed label width in mm (default: the label size at 300 DPI)")
+	analyzeCmd.Flags().Float64SliceVar(&analyzeDPIs, "dpi", generator.DefaultPrintDPIs, "Printer resolutions to simulate, in dots per inch")
+
+	rootCmd.AddCommand(analyzeCmd)
+}
+
+// analyzeRecord is the machine-readable result of the analyze command.
+type analyzeRecord struct {
+	File             string            `json:"file" yaml:"file"`
+	WidthMM          float64           `json:"widthMm" yaml:"widthMm"`
+	HeightMM         float64           `json:"heightMm" yaml:"heightMm"`
+	Text             string            `json:"text" yaml:"text"`
+	Version          int               `json:"version" yaml:"version"`
+	Level            string            `json:"level" yaml:"level"`
+	ModuleMM         float64           `json:"moduleMm" yaml:"moduleMm"`
+	Barcodes         []string          `json:"barcodes" yaml:"barcodes"`
+	Score            int               `json:"score" yaml:"score"`
+	RecommendedLevel string            `json:"recommendedLevel" yaml:"recommendedLevel"`
+	Conditions       []conditionRecord `json:"conditions" yaml:"conditions"`
+	MinSizes         []minSizeRecord   `json:"minSizes" yaml:"minSizes"`
+}
+
+// conditionRecord is the result of one simulated print condition.
+type conditionRecord struct {
+	Name       string  `json:"name" yaml:"name"`
+	DPI        float64 `json:"dpi" yaml:"dpi"`
+	Blur       float64 `json:"blur" yaml:"blur"`
+	InkSpread  float64 `json:"inkSpread" yaml:"inkSpread"`
+	Noise      float64 `json:"noise" yaml:"noise"`
+	Contrast   float64 `json:"contrast" yaml:"contrast"`
+	ModuleDots float64 `json:"moduleDots" yaml:"moduleDots"`
+	QR         bool    `json:"qr" yaml:"qr"`
+	Corrected  int     `json:"corrected" yaml:"corrected"`
+	Barcodes   int     `json:"barcodes" yaml:"barcodes"`
+	Score      int     `json:"score" yaml:"score"`
+}
+
+// minSizeRecord is the minimum print width at one printer resolution.
+// Zero widths mean the label does not read at the analysed width.
+type minSizeRecord struct {
+	DPI          float64 `json:"dpi" yaml:"dpi"`
+	QRWidthMM    float64 `json:"qrWidthMm" yaml:"qrWidthMm"`
+	QRModuleMM   float64 `json:"qrModuleMm" yaml:"qrModuleMm"`
+	LabelWidthMM float64 `json:"labelWidthMm" yaml:"labelWidthMm"`
+}
+
+// runAnalyze executes the analyze command
+func runAnalyze(cmd *cobra.Command, args []string) error {
+	if analyzeWidth < 0 {
+		return fmt.Errorf("validation error: --width must be positive")
+	}
+	if len(analyzeDPIs) == 0 {
+		return fmt.Errorf("validation error: at least one --dpi is required")
+	}
+	for _, dpi := range analyzeDPIs {
+		if dpi < 50 || dpi > 2400 {
+			return fmt.Errorf("validation error: --dpi %g is out of range (50-2400)", dpi)
+		}
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return fmt.Errorf("error opening image: %w", err)
+	}
+	img, _, err := image.Decode(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("error decoding image: %w", err)
+	}
+
+	width := analyzeWidth
+	if width == 0 {
+		width = float64(img.Bounds().Dx()) * 25.4 / generator.LabelDPI
+	}
+	if !structuredOutput() {
+		fmt.Fprintf(diagOut(), "🔍 Simulating %d print conditions...\n\n", len(analyzeDPIs)*len(generator.PrintConditions([]float64{0})))
+	}
+	analysis, err := generator.AnalyzeLabel(img, width, generator.PrintConditions(analyzeDPIs))
+	if err != nil {
+		return err
+	}
+	record := newAnalyzeRecord(args[0], analysis)
+
+	if structuredOutput() {
+		return writeStructured(record)
+	}
+	printAnalysis(record)
+	return nil
+}
+
+// newAnalyzeRecord converts an analysis into its machine-readable form.
+func newAnalyzeRecord(file string, a generator.PrintAnalysis) analyzeRecord {
+	record := analyzeRecord{
+		File:             file,
+		WidthMM:          a.WidthMM,
+		HeightMM:         a.HeightMM,
+		Text:             a.QR.Text,
+		Version:          a.QR.Version,
+		Level:            string(a.QR.Level),
+		ModuleMM:         a.ModuleMM,
+		Barcodes:         a.Barcodes,
+		Score:            a.Score,
+		RecommendedLevel: string(a.RecommendedLevel),
+	}
+	if record.Barcodes == nil {
+		record.Barcodes = []string{}
+	}
+	for _, r := range a.Results {
+		c := r.Condition
+		record.Conditions = append(record.Conditions, conditionRecord{
+			Name: c.Name, DPI: c.DPI, Blur: c.Blur, InkSpread: c.InkSpread, Noise: c.Noise, Contrast: c.Contrast,
+			ModuleDots: r.ModuleDots, QR: r.QR, Corrected: r.Corrected, Barcodes: r.Barcodes,
+			Score: int(r.Score*100 + 0.5),
+		})
+	}
+	for _, m := range a.MinSizes {
+		record.MinSizes = append(record.MinSizes, minSizeRecord{
+			DPI:          m.DPI,
+			QRWidthMM:    m.QRWidthMM,
+			QRModuleMM:   m.QRWidthMM * a.ModuleMM / a.WidthMM,
+			LabelWidthMM: m.LabelWidthMM,
+		})
+	}
+	return record
+}
+
+// printAnalysis prints the analysis report in table mode.
+func printAnalysis(r analyzeRecord) {
+	out := diagOut()
+	fmt.Fprintf(out, "Print Analysis: %s\n", r.File)
+	fmt.Fprintln(out, strings.Repeat("=", 50))
+	fmt.Fprintf(out, "  Print Size:    %.1f x %.1f mm\n", r.WidthMM, r.HeightMM)
+	fmt.Fprintf(out, "  QR Code:       version %d, level %s\n", r.Version, r.Level)
+	fmt.Fprintf(out, "  Module Size:   %.2f mm\n", r.ModuleMM)
+	fmt.Fprintf(out, "  Barcodes:      %d\n", len(r.Barcodes))
+	fmt.Fprintln(out, strings.Repeat("=", 50))
+
+	fmt.Fprintf(out, "  %4s  %-13s %6s  %-3s %8s  %5s\n", "DPI", "Condition", "Dots", "QR", "Barcodes", "Score")
+	for _, c := range r.Conditions {
+		qr := "✅"
+		if !c.QR {
+			qr = "❌"
+		}
+		fmt.Fprintf(out, "  %4.0f  %-13s %6.1f  %s  %5d/%-2d  %5d\n", c.DPI, c.Name, c.ModuleDots, qr, c.Barcodes, len(r.Barcodes), c.Score)
+	}
+	fmt.Fprintln(out, strings.Repeat("=", 50))
+
+	fmt.Fprintf(out, "  Robustness Score:   %d/100\n", r.Score)
+	if r.RecommendedLevel == r.Level {
+		fmt.Fprintf(out, "  Error Correction:   level %s is sufficient\n", r.Level)
+	} else {
+		fmt.Fprintf(out, "  Error Correction:   use --qr-level %s (currently %s)\n", r.RecommendedLevel, r.Level)
+	}
+	fmt.Fprintln(out, "  Minimum Print Width:")
+	for _, m := range r.MinSizes {
+		qr, label := "not readable", "not readable"
+		if m.QRWidthMM > 0 {
+			qr = fmt.Sprintf("%.0f mm (module %.2f mm)", m.QRWidthMM, m.QRModuleMM)
+		}
+		if m.LabelWidthMM > 0 {
+			label = fmt.Sprintf("%.0f mm", m.LabelWidthMM)
+		}
+		fmt.Fprintf(out, "    %4.0f dpi: QR code %s, full label %s\n", m.DPI, qr, label)
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "Minimum widths are for worn prints; \"not readable\" means not readable at %.1f mm.\n", r.WidthMM)
+}
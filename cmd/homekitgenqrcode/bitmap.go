@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lordbasex/HomeKitGenQRCode/internal/bitmap"
+	"github.com/lordbasex/HomeKitGenQRCode/internal/generator"
+
+	"github.com/spf13/cobra"
+)
+
+// Display bitmap flags shared by generate and code
+var (
+	bitmapFormats   []string // Bitmap formats to write next to the label
+	bitmapScale     int      // Pixels per QR module
+	bitmapQuietZone int      // Quiet zone in modules
+	bitmapName      string   // C identifier of the bitmap
+)
+
+// bitmapSuffixes are the file name suffixes of the bitmap formats.
+var bitmapSuffixes = map[bitmap.Format]string{
+	bitmap.FormatC:    "-qr.h",
+	bitmap.FormatXBM:  ".xbm",
+	bitmap.FormatLVGL: "-lvgl.c",
+	bitmap.FormatGFX:  "-gfx.h",
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{generateCmd, codeCmd} {
+		cmd.Flags().StringSliceVar(&bitmapFormats, "bitmap", nil, "Also write the QR code as firmware bitmaps: c, xbm, lvgl, gfx (comma-separated)")
+		cmd.Flags().IntVar(&bitmapScale, "bitmap-scale", 1, "Pixels per QR module in the bitmaps (1-16)")
+		cmd.Flags().IntVar(&bitmapQuietZone, "bitmap-quiet-zone", generator.DefaultQuietZone, "Quiet zone around the QR code in the bitmaps, in modules (0-16)")
+		cmd.Flags().StringVar(&bitmapName, "bitmap-name", "homekit_qr", "C identifier of the bitmaps")
+	}
+}
+
+// parseBitmapFormats returns the formats selected with --bitmap, without duplicates.
+func parseBitmapFormats() ([]bitmap.Format, error) {
+	var formats []bitmap.Format
+	seen := map[bitmap.Format]bool{}
+	for _, name := range bitmapFormats {
+		f, err := bitmap.ParseFormat(name)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[f] {
+			seen[f] = true
+			formats = append(formats, f)
+		}
+	}
+	return formats, nil
+}
+
+// checkBitmapFlags validates the bitmap flags before anything is generated.
+func checkBitmapFlags(output string) error {
+	if len(bitmapFormats) == 0 {
+		return nil
+	}
+	if output == stdoutPath {
+		return fmt.Errorf("validation error: --bitmap writes files next to the label and cannot be used with -o -")
+	}
+	if _, err := parseBitmapFormats(); err != nil {
+		return fmt.Errorf("validation error: %w", err)
+	}
+	if bitmapScale < 1 || bitmapScale > bitmap.MaxScale {
+		return fmt.Errorf("validation error: --bitmap-scale must be between 1 and %d", bitmap.MaxScale)
+	}
+	if bitmapQuietZone < 0 || bitmapQuietZone > bitmap.MaxQuietZone {
+		return fmt.Errorf("validation error: --bitmap-quiet-zone must be between 0 and %d", bitmap.MaxQuietZone)
+	}
+	if err := bitmap.ValidateIdent(bitmapName); err != nil {
+		return fmt.Errorf("validation error: %w", err)
+	}
+	return nil
+}
+
+// writeBitmapFiles writes the label's QR code, encoded with the label's QR
+// options, in the formats selected with --bitmap next to the label output and
+// returns their paths: <name>-qr.h, <name>.xbm, <name>-lvgl.c and <name>-gfx.h.
+func writeBitmapFiles(label generator.Label, output string, opts generator.LabelOptions) ([]string, error) {
+	formats, err := parseBitmapFormats()
+	if err != nil || len(formats) == 0 {
+		return nil, err
+	}
+	modules, err := generator.EncodeQR(label.URI, opts.QR)
+	if err != nil {
+		return nil, err
+	}
+	bm, err := bitmap.New(label.URI, modules, bitmapScale, bitmapQuietZone)
+	if err != nil {
+		return nil, err
+	}
+
+	base := strings.TrimSuffix(output, filepath.Ext(output))
+	paths := make([]string, 0, len(formats))
+	for _, f := range formats {
+		source, err := bm.Source(f, bitmapName)
+		if err != nil {
+			return nil, err
+		}
+		path := base + bitmapSuffixes[f]
+		if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+			return nil, fmt.Errorf("error writing bitmap file: %w", err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// printBitmapFiles prints the written bitmap files in table mode.
+func printBitmapFiles(paths []string) {
+	for _, path := range paths {
+		fmt.Fprintf(diagOut(), "✅ Bitmap opgeslagen als: %s\n", path)
+	}
+}
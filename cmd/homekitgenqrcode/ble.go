@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/lordbasex/HomeKitGenQRCode/pkg/homekit"
+
+	"github.com/spf13/cobra"
+)
+
+// Variables for ble command flags
+var (
+	bleCategory     int    // HomeKit category ID
+	bleSetupID      string // Setup ID
+	bleMAC          string // MAC address (device ID)
+	bleLabel        string // Label image to read the values from
+	bleName         string // Local name sent in the scan response
+	bleStateNumber  int    // Global state number (GSN)
+	bleConfigNumber int    // Configuration number (CN)
+	blePaired       bool   // Advertise as paired
+	bleFormat       string // Print only one representation: hex, c or json
+)
+
+// bleCmd builds the HAP Bluetooth LE advertisement for an accessory
+var bleCmd = &cobra.Command{
+	Use:   "ble",
+	Short: "Generate the HAP Bluetooth LE advertisement payload",
+	Long: `Build the advertising data a HAP accessory sends over Bluetooth LE from the
+values on its label: the Apple manufacturer data (type 0x06) carries the status
+flags, the MAC address as device ID, the category (ACID), the global state
+number (GSN), the configuration number (CN), the compatible version and the
+setup hash, the same one advertised as sh over IP.
+
+The values can be given with -c, -s and -m, or read from a label image with
+--label. With --name, the complete local name is added as scan response data.
+
+The payload is printed as a field breakdown, hex and C arrays. Use --format to
+print only one of them.
+
+Examples:
+  homekitgenqrcode ble -c 5 -s ABCD -m 30:AE:A4:01:02:03
+  homekitgenqrcode ble --label example.png --name "Lamp" --format c > hap_adv.h
+  homekitgenqrcode ble --label example.png --format hex`,
+	RunE: runBLE,
+}
+
+func init() {
+	bleCmd.Flags().IntVarP(&bleCategory, "category", "c", 0, "HomeKit category ID")
+	bleCmd.Flags().StringVarP(&bleSetupID, "setup-id", "s", "", "Setup ID: 4 alphanumeric characters (0-9, A-Z)")
+	bleCmd.Flags().StringVarP(&bleMAC, "mac", "m", "", "MAC address, advertised as the device ID")
+	bleCmd.Flags().StringVar(&bleLabel, "label", "", "Read the category, setup ID and MAC from a label image")
+	bleCmd.Flags().StringVar(&bleName, "name", "", "Local name sent in the scan response (optional)")
+	bleCmd.Flags().IntVar(&bleStateNumber, "state-number", 1, "Global state number (GSN)")
+	bleCmd.Flags().IntVar(&bleConfigNumber, "config-number", 1, "Configuration number (CN)")
+	bleCmd.Flags().BoolVar(&blePaired, "paired", false, "Advertise the accessory as paired (SF=0)")
+	bleCmd.Flags().StringVar(&bleFormat, "format", "", "Print only one representation: hex, c or json")
+	bleCmd.MarkFlagsMutuallyExclusive("label", "category")
+	bleCmd.MarkFlagsMutuallyExclusive("label", "setup-id")
+	bleCmd.MarkFlagsMutuallyExclusive("label", "mac")
+
+	rootCmd.AddCommand(bleCmd)
+}
+
+// bleRecord is the machine-readable result of the ble command.
+type bleRecord struct {
+	Advertising  string             `json:"advertising" yaml:"advertising"`
+	ScanResponse string             `json:"scanResponse,omitempty" yaml:"scanResponse,omitempty"`
+	Fields       []homekit.BLEField `json:"fields" yaml:"fields"`
+	CArray       string             `json:"c" yaml:"c"`
+}
+
+// runBLE executes the ble command
+func runBLE(cmd *cobra.Command, args []string) error {
+	switch bleFormat {
+	case "", "hex", "c", "json":
+	default:
+		return fmt.Errorf("unknown format %q. Expected hex, c or json", bleFormat)
+	}
+
+	info, err := labelSetupInfo(bleCategory, bleSetupID, bleMAC, bleLabel)
+	if err != nil {
+		return err
+	}
+	adv, err := homekit.NewBLEAdvertisement(info, homekit.BLEOptions{
+		StateNumber:  bleStateNumber,
+		ConfigNumber: bleConfigNumber,
+		Paired:       blePaired,
+		Name:         bleName,
+	})
+	if err != nil {
+		return fmt.Errorf("validation error: %w", err)
+	}
+
+	result := bleRecord{
+		Advertising:  strings.ToUpper(hex.EncodeToString(adv.Bytes())),
+		ScanResponse: strings.ToUpper(hex.EncodeToString(adv.ScanResponse())),
+		Fields:       adv.Fields(),
+		CArray:       adv.CArray("hap"),
+	}
+
+	switch {
+	case bleFormat == "hex":
+		fmt.Println(result.Advertising)
+		if result.ScanResponse != "" {
+			fmt.Println(result.ScanResponse)
+		}
+		return nil
+	case bleFormat == "c":
+		fmt.Print(result.CArray)
+		return nil
+	case bleFormat == "json":
+		data, err := json.MarshalIndent(result.Fields, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error encoding JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	case structuredOutput():
+		return writeStructured(result)
+	}
+
+	fmt.Println("HAP BLE Advertisement:")
+	fmt.Println(strings.Repeat("=", 50))
+	for _, f := range result.Fields {
+		fmt.Printf("  %2d  %-12s  %-27s %s\n", f.Offset, f.Hex, f.Name+":", f.Value)
+	}
+	fmt.Println(strings.Repeat("=", 50))
+	fmt.Printf("  Advertising:   %s (%d bytes)\n", result.Advertising, len(adv.Bytes()))
+	if result.ScanResponse != "" {
+		fmt.Printf("  Scan Response: %s (%d bytes)\n", result.ScanResponse, len(adv.ScanResponse()))
+	}
+	fmt.Println()
+	fmt.Print(result.CArray)
+	return nil
+}
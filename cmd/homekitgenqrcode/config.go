@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lordbasex/HomeKitGenQRCode/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+// profileName is the value of the global --profile flag
+var profileName string
+
+// effectiveConfig is the configuration resolved before each command runs
+var effectiveConfig *config.Config
+
+// configCmd groups the configuration subcommands
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the configuration",
+	Long: fmt.Sprintf(`Settings can be stored in config files with named profiles and overridden
+with environment variables. Precedence: flags > env > profile > defaults.
+
+Config files (YAML):
+  %s
+  %s in the current directory or a parent (project-local, wins over the user file)
+
+Example:
+  profile: acme
+  category: 5
+  profiles:
+    acme:
+      brand: Designed by ACME
+      output-dir: labels/acme
+      output-format: json
+
+Environment variables: %s (select a profile), and
+%s, %s, %s, %s, %s, %s.`,
+		"~/.config/homekitgenqrcode/config.yaml ($XDG_CONFIG_HOME is honoured)",
+		config.ProjectFileName,
+		config.ProfileEnv,
+		config.EnvVar(config.KeyCategory), config.EnvVar(config.KeyBrand), config.EnvVar(config.KeyOutputDir),
+		config.EnvVar(config.KeyOutputFormat), config.EnvVar(config.KeyMACStyle), config.EnvVar(config.KeyMACBarcodeStyle)),
+}
+
+// configShowCmd prints the effective configuration
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the effective merged configuration and where each value comes from",
+	RunE:  runConfigShow,
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "Config profile to use (default: $"+config.ProfileEnv+" or the config file's 'profile')")
+
+	configCmd.AddCommand(configShowCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+// applyConfig loads the configuration and uses it for every flag of cmd that
+// has a setting key and was not given on the command line.
+func applyConfig(cmd *cobra.Command) error {
+	cfg, err := config.Load(profileName, os.Getenv)
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.ApplyFlags(cmd.Flags()); err != nil {
+		return err
+	}
+
+	effectiveConfig = cfg
+	return nil
+}
+
+// resolveOutputPath places relative output paths inside --output-dir.
+func resolveOutputPath(path string) string {
+	if outputDir == "" || path == "" || path == stdoutPath || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(outputDir, path)
+}
+
+// runConfigShow executes the config show command
+func runConfigShow(cmd *cobra.Command, args []string) error {
+	cfg := effectiveConfig
+
+	if structuredOutput() {
+		settings := map[string]string{}
+		for _, v := range cfg.Values() {
+			settings[v.Key] = v.Value
+		}
+		return writeStructured(struct {
+			Profile  string            `json:"profile,omitempty" yaml:"profile,omitempty"`
+			Files    []string          `json:"files" yaml:"files"`
+			Settings map[string]string `json:"settings" yaml:"settings"`
+			Sources  []config.Value    `json:"sources" yaml:"sources"`
+		}{cfg.Profile, append([]string{}, cfg.Files...), settings, cfg.Values()})
+	}
+
+	profile := cfg.Profile
+	if profile == "" {
+		profile = "(none)"
+	}
+	fmt.Println("Effective Configuration:")
+	fmt.Println(strings.Repeat("=", 50))
+	fmt.Printf("  Profile:           %s\n", profile)
+	for _, v := range cfg.Values() {
+		value := v.Value
+		if value == "" {
+			value = "(not set)"
+		}
+		fmt.Printf("  %-18s %-28s %s\n", v.Key+":", value, v.Source)
+	}
+	fmt.Println(strings.Repeat("=", 50))
+
+	if len(cfg.Files) == 0 {
+		fmt.Println("No config files found.")
+	} else {
+		fmt.Println("Config files:")
+		for _, path := range cfg.Files {
+			fmt.Printf("  %s\n", path)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/lordbasex/HomeKitGenQRCode/internal/espprov"
+	"github.com/lordbasex/HomeKitGenQRCode/internal/generator"
+
+	"github.com/spf13/cobra"
+)
+
+// ESP-IDF provisioning flags shared by generate and code
+var (
+	espProvOutput    bool   // Also generate the ESP-IDF provisioning QR payload
+	espProvTransport string // Provisioning transport: softap or ble
+	espProvName      string // Service name (SoftAP SSID or BLE device name)
+	espProvPoP       string // Fixed proof of possession
+	espProvKeyFile   string // Key file to derive the proof of possession from the MAC
+	espProvQR        bool   // Print the provisioning QR code on the label
+)
+
+func init() {
+	for _, cmd := range []*cobra.Command{generateCmd, codeCmd} {
+		cmd.Flags().BoolVar(&espProvOutput, "esp-prov", false, "Also generate the ESP-IDF Wi-Fi provisioning QR payload for Espressif's provisioning apps")
+		cmd.Flags().StringVar(&espProvTransport, "esp-prov-transport", string(espprov.TransportSoftAP), "Provisioning transport: softap or ble")
+		cmd.Flags().StringVar(&espProvName, "esp-prov-name", "", "Provisioning service name: SoftAP SSID or BLE device name (default: PROV_ and the last 3 MAC bytes)")
+		cmd.Flags().StringVar(&espProvPoP, "esp-prov-pop", "", "Fixed proof of possession (default: random per device)")
+		cmd.Flags().StringVar(&espProvKeyFile, "esp-prov-key-file", "", "Key file: derive the proof of possession from the key and the MAC address")
+		cmd.Flags().BoolVar(&espProvQR, "esp-prov-qr", true, "Print the provisioning QR code on the label (use --esp-prov-qr=false for the payload only)")
+		cmd.MarkFlagsMutuallyExclusive("esp-prov-pop", "esp-prov-key-file")
+	}
+}
+
+// checkESPProvFlags validates the provisioning flags before anything is generated.
+func checkESPProvFlags() error {
+	if !espProvOutput {
+		return nil
+	}
+	if _, err := espprov.ParseTransport(espProvTransport); err != nil {
+		return fmt.Errorf("validation error: %w", err)
+	}
+	return nil
+}
+
+// applyESPProv builds the provisioning payload for label when --esp-prov is
+// set and, unless --esp-prov-qr=false, adds it to the label as the second QR
+// code with the service name and proof of possession as caption.
+func applyESPProv(label *generator.Label) (*espprov.Payload, error) {
+	if !espProvOutput {
+		return nil, nil
+	}
+	transport, err := espprov.ParseTransport(espProvTransport)
+	if err != nil {
+		return nil, err
+	}
+	payload := espprov.Payload{
+		Version:   espprov.Version,
+		Name:      espProvName,
+		PoP:       espProvPoP,
+		Transport: transport,
+	}
+	if payload.Name == "" {
+		payload.Name = espprov.DefaultName(label.MAC)
+	}
+	switch {
+	case espProvKeyFile != "":
+		key, err := os.ReadFile(espProvKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading provisioning key file: %w", err)
+		}
+		if payload.PoP, err = espprov.DerivePoP(key, label.MAC); err != nil {
+			return nil, err
+		}
+	case payload.PoP == "":
+		payload.PoP = espprov.RandomPoP()
+	}
+
+	content, err := payload.QRCode()
+	if err != nil {
+		return nil, err
+	}
+	if espProvQR {
+		label.SecondQR = &generator.SecondQR{
+			Content: content,
+			Caption: []string{"Wi-Fi: " + payload.Name, "PoP: " + payload.PoP},
+		}
+	}
+	return &payload, nil
+}
+
+// printESPProv prints the provisioning payload in table mode.
+func printESPProv(payload *espprov.Payload) {
+	if payload == nil {
+		return
+	}
+	content, err := payload.QRCode()
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(diagOut(), "📶 ESP provisioning QR: %s\n", content)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lordbasex/HomeKitGenQRCode/internal/generator"
+	"github.com/lordbasex/HomeKitGenQRCode/internal/homespan"
+
+	"github.com/spf13/cobra"
+)
+
+// HomeSpan flags shared by generate and code
+var (
+	homespanOutput bool   // Also write HomeSpan provisioning files next to the label
+	homespanName   string // Accessory name passed to homeSpan.begin()
+)
+
+func init() {
+	for _, cmd := range []*cobra.Command{generateCmd, codeCmd} {
+		cmd.Flags().BoolVar(&homespanOutput, "homespan", false, "Also write HomeSpan files: serial CLI script (.homespan.txt), sketch snippet (.homespan.cpp) and #define header (.homespan.h)")
+		cmd.Flags().StringVar(&homespanName, "homespan-name", "", "Accessory name for homeSpan.begin() (default: the category name)")
+	}
+}
+
+// checkHomeSpanFlags validates the HomeSpan flags before anything is generated.
+func checkHomeSpanFlags(output string) error {
+	if homespanOutput && output == stdoutPath {
+		return fmt.Errorf("validation error: --homespan writes files next to the label and cannot be used with -o -")
+	}
+	return nil
+}
+
+// writeHomeSpanFiles writes the HomeSpan files for label next to the label
+// output and returns their paths: <name>.homespan.txt, <name>.homespan.cpp and
+// <name>.homespan.h.
+func writeHomeSpanFiles(label generator.Label, output string) ([]string, error) {
+	if !homespanOutput {
+		return nil, nil
+	}
+	name := strings.TrimSpace(homespanName)
+	if name == "" {
+		name = label.CategoryName()
+	}
+	device := homespan.Device{
+		Name:      name,
+		Category:  label.Category,
+		SetupCode: label.SetupCode,
+		SetupID:   label.SetupID,
+		URI:       label.URI,
+		Serial:    label.Serial,
+		MAC:       label.MAC,
+	}
+	script, err := device.Script()
+	if err != nil {
+		return nil, err
+	}
+	sketch, err := device.Sketch()
+	if err != nil {
+		return nil, err
+	}
+	header, err := device.Header()
+	if err != nil {
+		return nil, err
+	}
+
+	base := strings.TrimSuffix(output, filepath.Ext(output))
+	files := []struct {
+		path string
+		data string
+	}{
+		{base + ".homespan.txt", script},
+		{base + ".homespan.cpp", sketch},
+		{base + ".homespan.h", header},
+	}
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		if err := os.WriteFile(f.path, []byte(f.data), 0644); err != nil {
+			return nil, fmt.Errorf("error writing HomeSpan file: %w", err)
+		}
+		paths = append(paths, f.path)
+	}
+	return paths, nil
+}
+
+// printHomeSpanFiles prints the written HomeSpan files in table mode.
+func printHomeSpanFiles(paths []string) {
+	for _, path := range paths {
+		fmt.Fprintf(diagOut(), "✅ HomeSpan-bestand opgeslagen als: %s\n", path)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/lordbasex/HomeKitGenQRCode/internal/generator"
+	"github.com/lordbasex/HomeKitGenQRCode/internal/importer"
+	"github.com/lordbasex/HomeKitGenQRCode/pkg/homekit"
+
+	"github.com/spf13/cobra"
+)
+
+// importCmd groups the importers for the pairing data of other HomeKit software
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import pairing data from other HomeKit software and print labels",
+	Long: `Read the pairing data that other HomeKit bridge software keeps on disk and
+render a label for every bridge or accessory found, so existing installations
+can get printed labels with their real setup code, setup ID, category and MAC.
+
+Use import-image to read the data from a photo of a label instead.`,
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+}
+
+// bridgeRecord is the machine-readable result for one imported bridge.
+type bridgeRecord struct {
+	Name        string `json:"name" yaml:"name"`
+	Source      string `json:"source" yaml:"source"`
+	Port        int    `json:"port,omitempty" yaml:"port,omitempty"`
+	StateFile   string `json:"stateFile,omitempty" yaml:"stateFile,omitempty"`
+	labelRecord `yaml:",inline"`
+	Warnings    []string `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+	Error       string   `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// renderImportedBridges renders a label for every bridge that has complete
+// pairing data, saved as <prefix>-<MAC>.png, and reports the results.
+func renderImportedBridges(bridges []importer.Bridge, prefix string) error {
+	opts, err := labelOptions()
+	if err != nil {
+		return err
+	}
+
+	records := make([]bridgeRecord, 0, len(bridges))
+	failed := 0
+	for _, bridge := range bridges {
+		record := bridgeRecord{
+			Name:        bridge.Name,
+			Source:      bridge.Source,
+			Port:        bridge.Port,
+			StateFile:   bridge.StateFile,
+			labelRecord: labelRecord{SetupCode: bridge.SetupCode, SetupID: bridge.SetupID, MAC: bridge.MAC, Category: bridge.Category, CategoryName: homekit.CategoryName(bridge.Category)},
+			Warnings:    bridge.Warnings,
+		}
+		if err := renderImportedBridge(bridge, prefix, opts, &record); err != nil {
+			record.Error = err.Error()
+			failed++
+		}
+		records = append(records, record)
+		if !structuredOutput() {
+			printBridgeRecord(record)
+		}
+	}
+
+	if structuredOutput() {
+		if err := writeStructured(records); err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d bridges could not be imported", failed, len(bridges))
+	}
+	return nil
+}
+
+// renderImportedBridge renders the label of one bridge into record.
+func renderImportedBridge(bridge importer.Bridge, prefix string, opts generator.LabelOptions, record *bridgeRecord) error {
+	if err := bridge.Validate(); err != nil {
+		return fmt.Errorf("cannot render label: %w", err)
+	}
+	label := generator.NewLabel(bridge.SetupInfo)
+	output := resolveOutputPath(fmt.Sprintf("%s-%s.png", prefix, bridge.MAC))
+	if err := writeLabel(label, output, opts); err != nil {
+		return fmt.Errorf("error generating label: %w", err)
+	}
+	record.labelRecord = newLabelRecord(label, output)
+	return nil
+}
+
+// printBridgeRecord prints the result for one bridge in table mode.
+func printBridgeRecord(r bridgeRecord) {
+	out := diagOut()
+	title := r.Name
+	if r.Source != "" && !strings.EqualFold(r.Source, r.Name) {
+		title = fmt.Sprintf("%s (%s)", r.Name, r.Source)
+	}
+	fmt.Fprintf(out, "🌉 %s\n", title)
+	fmt.Fprintln(out, strings.Repeat("=", 50))
+	if r.URI != "" {
+		fmt.Fprintf(out, "  Setup URI:     %s\n", r.URI)
+	}
+	fmt.Fprintf(out, "  Setup Code:    %s\n", orNotFound(r.SetupCode))
+	fmt.Fprintf(out, "  Setup ID:      %s\n", orNotFound(r.SetupID))
+	fmt.Fprintf(out, "  Category:      %d (%s)\n", r.Category, r.CategoryName)
+	fmt.Fprintf(out, "  MAC Address:   %s\n", orNotFound(homekit.FormatMAC(r.MAC)))
+	if r.Port != 0 {
+		fmt.Fprintf(out, "  Port:          %d\n", r.Port)
+	}
+	fmt.Fprintln(out, strings.Repeat("=", 50))
+	for _, w := range r.Warnings {
+		fmt.Fprintf(os.Stderr, "⚠️  Warning: %s\n", w)
+	}
+	if r.Error != "" {
+		fmt.Fprintf(os.Stderr, "❌ Error: %s\n", r.Error)
+	} else if r.Output != "" {
+		fmt.Fprintf(out, "✅ QR-code opgeslagen als: %s\n", outputName(r.Output))
+	}
+}
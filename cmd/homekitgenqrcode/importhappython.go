@@ -0,0 +1,59 @@
+package main
+
+import (
+	"github.com/lordbasex/HomeKitGenQRCode/internal/importer"
+	"github.com/lordbasex/HomeKitGenQRCode/pkg/homekit"
+
+	"github.com/spf13/cobra"
+)
+
+// Variables for import hap-python command flags
+var (
+	hapPythonCategory int // Category for state files whose category cannot be inferred
+)
+
+// importHAPPythonCmd prints labels for HAP-python and Home Assistant bridges
+var importHAPPythonCmd = &cobra.Command{
+	Use:   "hap-python <path>...",
+	Short: "Print labels for HAP-python and Home Assistant HomeKit Bridge accessories",
+	Long: `Read HAP-python accessory state files and render one label per file. A path
+may be a state file, a Home Assistant configuration directory (its
+.storage/homekit.*.state files) or a directory of *.state files.
+
+The MAC address, setup code (pincode) and setup ID are read from the state file,
+whether stored as text, Python bytes literals, hex strings or byte arrays. For
+Home Assistant, the name, port and category are taken from the HomeKit Bridge
+config entry in .storage/core.config_entries: a bridge in bridge mode, or the
+category of the exposed entity in accessory mode. Otherwise the category is
+taken from --category.
+
+Labels are saved as hap-python-<mac>.png.
+
+Examples:
+  homekitgenqrcode import hap-python /config
+  homekitgenqrcode import hap-python accessory.state --category 2
+  homekitgenqrcode import hap-python /config --output-dir labels --output-format json`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runImportHAPPython,
+}
+
+func init() {
+	importHAPPythonCmd.Flags().IntVarP(&hapPythonCategory, "category", "c", 0, "HomeKit category ID for state files whose category cannot be inferred")
+	addLabelFlags(importHAPPythonCmd)
+
+	importCmd.AddCommand(importHAPPythonCmd)
+}
+
+// runImportHAPPython executes the import hap-python command
+func runImportHAPPython(cmd *cobra.Command, args []string) error {
+	if hapPythonCategory != 0 {
+		if err := homekit.ValidateCategory(hapPythonCategory); err != nil {
+			return err
+		}
+	}
+	bridges, err := importer.ReadHAPPython(args, hapPythonCategory)
+	if err != nil {
+		return err
+	}
+	return renderImportedBridges(bridges, "hap-python")
+}
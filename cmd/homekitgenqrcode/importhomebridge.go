@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/lordbasex/HomeKitGenQRCode/internal/importer"
+
+	"github.com/spf13/cobra"
+)
+
+// importHomebridgeCmd prints labels for a Homebridge installation
+var importHomebridgeCmd = &cobra.Command{
+	Use:   "homebridge [storage-dir]",
+	Short: "Print labels for a Homebridge bridge and its child bridges",
+	Long: `Read a Homebridge storage directory (default ~/.homebridge) and render one
+label per bridge: the main bridge and every child bridge (_bridge block of a
+platform or accessory).
+
+The MAC address (username) and setup code (pin) come from config.json; the
+setup ID and category from the persist/AccessoryInfo.<username>.json file
+HAP-NodeJS writes when the bridge first starts. Child bridges use the main
+bridge's PIN unless their _bridge block sets one.
+
+Labels are saved as homebridge-<username>.png.
+
+Examples:
+  homekitgenqrcode import homebridge
+  homekitgenqrcode import homebridge /var/lib/homebridge --output-dir labels`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runImportHomebridge,
+}
+
+func init() {
+	addLabelFlags(importHomebridgeCmd)
+
+	importCmd.AddCommand(importHomebridgeCmd)
+}
+
+// runImportHomebridge executes the import homebridge command
+func runImportHomebridge(cmd *cobra.Command, args []string) error {
+	dir := ""
+	if len(args) > 0 {
+		dir = args[0]
+	} else {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("error locating home directory: %w", err)
+		}
+		dir = filepath.Join(home, ".homebridge")
+	}
+
+	bridges, err := importer.ReadHomebridge(dir)
+	if err != nil {
+		return err
+	}
+	return renderImportedBridges(bridges, "homebridge")
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg" // Register JPEG decoding for photos
+	_ "image/png"  // Register PNG decoding
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lordbasex/HomeKitGenQRCode/internal/generator"
+	"github.com/lordbasex/HomeKitGenQRCode/pkg/homekit"
+
+	"github.com/spf13/cobra"
+	_ "golang.org/x/image/webp" // Register WebP decoding
+)
+
+// Variables for import-image command flags
+var (
+	importRender bool   // Re-render a fresh label for each image
+	importOutput string // Output label path (single image only, implies --render)
+	importMAC    string // MAC address for re-rendered labels without a readable MAC barcode
+)
+
+// importImageCmd recovers pairing data from photos of existing labels
+var importImageCmd = &cobra.Command{
+	Use:   "import-image <image>...",
+	Short: "Read the setup code from a photo of a HomeKit label",
+	Long: `Locate and decode the HomeKit setup QR code in a photo or scan (PNG, JPEG or
+WebP) and print the pairing data it carries: setup code, setup ID, category
+and transport flags. Rotated, oblique and poorly lit photos are supported.
+
+The device code, serial number, CSN and MAC barcodes around the QR code are
+read too when the photo is sharp enough. With --render, a fresh label is drawn
+with the current template from the recovered data; values that could not be
+read are generated, and a missing MAC can be given with --mac.
+
+Examples:
+  homekitgenqrcode import-image photo.jpg
+  homekitgenqrcode import-image photos/*.jpg --output-format json
+  homekitgenqrcode import-image photo.jpg -o relabel.png --mac AABBCCDDEEFF`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runImportImage,
+}
+
+func init() {
+	importImageCmd.Flags().BoolVar(&importRender, "render", false, "Re-render a label for each image as <name>-label.png")
+	importImageCmd.Flags().StringVarP(&importOutput, "output", "o", "", "Output label path for a single image, or - for stdout (implies --render)")
+	importImageCmd.Flags().StringVarP(&importMAC, "mac", "m", "", "MAC address for the re-rendered label when no MAC barcode is read")
+	addLabelFlags(importImageCmd)
+
+	rootCmd.AddCommand(importImageCmd)
+}
+
+// importRecord is the machine-readable result for one imported image.
+type importRecord struct {
+	File         string   `json:"file" yaml:"file"`
+	URI          string   `json:"uri,omitempty" yaml:"uri,omitempty"`
+	SetupCode    string   `json:"setupCode,omitempty" yaml:"setupCode,omitempty"`
+	SetupID      string   `json:"setupId,omitempty" yaml:"setupId,omitempty"`
+	Category     int      `json:"category,omitempty" yaml:"category,omitempty"`
+	CategoryName string   `json:"categoryName,omitempty" yaml:"categoryName,omitempty"`
+	Flags        int      `json:"flags,omitempty" yaml:"flags,omitempty"`
+	FlagNames    []string `json:"flagNames,omitempty" yaml:"flagNames,omitempty"`
+	Version      int      `json:"version,omitempty" yaml:"version,omitempty"`
+	MAC          string   `json:"mac,omitempty" yaml:"mac,omitempty"`
+	DeviceCode   string   `json:"deviceCode,omitempty" yaml:"deviceCode,omitempty"`
+	Serial       string   `json:"serial,omitempty" yaml:"serial,omitempty"`
+	CSN          string   `json:"csn,omitempty" yaml:"csn,omitempty"`
+	Output       string   `json:"output,omitempty" yaml:"output,omitempty"`
+	Error        string   `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// runImportImage executes the import-image command
+func runImportImage(cmd *cobra.Command, args []string) error {
+	importOutput = strings.TrimSpace(importOutput)
+	if importOutput != "" && len(args) > 1 {
+		return fmt.Errorf("--output can only be used with a single image; use --render for several")
+	}
+	render := importRender || importOutput != ""
+
+	var opts generator.LabelOptions
+	if render {
+		var err error
+		if opts, err = labelOptions(); err != nil {
+			return err
+		}
+		if importMAC != "" {
+			normalized, err := homekit.NormalizeMAC(importMAC)
+			if err != nil {
+				return fmt.Errorf("validation error: %w", err)
+			}
+			importMAC = normalized
+		}
+		if importOutput != "" {
+			importOutput = resolveOutputPath(importOutput)
+			if err := validateOutputPath(importOutput); err != nil {
+				return fmt.Errorf("validation error: %w", err)
+			}
+			if err := checkStdoutOutput(importOutput); err != nil {
+				return err
+			}
+		}
+	}
+
+	records := make([]importRecord, 0, len(args))
+	failed := 0
+	for _, path := range args {
+		record, err := importImage(path, render, opts)
+		if err != nil {
+			record.Error = err.Error()
+			failed++
+		}
+		records = append(records, record)
+		if !structuredOutput() {
+			printImportRecord(record)
+		}
+	}
+
+	if structuredOutput() {
+		if err := writeStructured(records); err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d images could not be imported", failed, len(args))
+	}
+	return nil
+}
+
+// importImage reads the label in one image and, if render is set, draws a
+// fresh label from it. The record holds whatever was read before an error.
+func importImage(path string, render bool, opts generator.LabelOptions) (importRecord, error) {
+	record := importRecord{File: path}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return record, fmt.Errorf("error opening image: %w", err)
+	}
+	img, _, err := image.Decode(f)
+	f.Close()
+	if err != nil {
+		return record, fmt.Errorf("error decoding image: %w", err)
+	}
+
+	scanned, err := generator.ReadLabel(img)
+	if err != nil {
+		return record, err
+	}
+	payload := scanned.Payload
+	record.URI = scanned.URI
+	record.SetupCode = payload.SetupCode
+	record.SetupID = payload.SetupID
+	record.Category = payload.Category
+	record.CategoryName = homekit.CategoryName(payload.Category)
+	record.Flags = payload.Flags
+	record.FlagNames = payload.FlagNames()
+	record.Version = payload.Version
+	record.MAC = scanned.MAC
+	record.DeviceCode = scanned.DeviceCode
+	record.Serial = scanned.Serial
+	record.CSN = scanned.CSN
+
+	if !render {
+		return record, nil
+	}
+	label, err := relabel(scanned, path)
+	if err != nil {
+		return record, err
+	}
+	output := importOutput
+	if output == "" {
+		output = resolveOutputPath(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)) + "-label.png")
+	}
+	if err := writeLabel(label, output, opts); err != nil {
+		return record, fmt.Errorf("error generating label: %w", err)
+	}
+	record.MAC, record.DeviceCode, record.Serial, record.CSN = label.MAC, label.DeviceCode, label.Serial, label.CSN
+	record.Output = output
+	return record, nil
+}
+
+// relabel builds a label from scanned data. The setup URI is kept as read,
+// so its flags are preserved; values whose barcode could not be read are
+// taken from --mac or generated.
+func relabel(scanned generator.ScannedLabel, path string) (generator.Label, error) {
+	payload := scanned.Payload
+	if payload.SetupID == "" {
+		return generator.Label{}, errors.New("the setup URI has no setup ID, so the label cannot be re-rendered")
+	}
+	if err := homekit.ValidateCategory(payload.Category); err != nil {
+		return generator.Label{}, fmt.Errorf("cannot re-render label: %w", err)
+	}
+
+	mac := scanned.MAC
+	if mac == "" {
+		mac = importMAC
+	}
+	if mac == "" {
+		mac = homekit.GenerateMAC()
+		fmt.Fprintf(os.Stderr, "⚠️  Warning: %s: no MAC barcode read, using random MAC %s (set one with --mac)\n", path, homekit.FormatMAC(mac))
+		warnMAC(mac, false)
+	}
+
+	label := generator.Label{
+		SetupInfo:  homekit.SetupInfo{Category: payload.Category, SetupCode: payload.SetupCode, SetupID: payload.SetupID, MAC: mac},
+		URI:        scanned.URI,
+		DeviceCode: scanned.DeviceCode,
+		Serial:     scanned.Serial,
+		CSN:        scanned.CSN,
+	}
+	if label.DeviceCode == "" {
+		label.DeviceCode = generator.GenerateDeviceCode(payload.Category)
+	}
+	if label.Serial == "" {
+		label.Serial = generator.GenerateSerial()
+	}
+	if label.CSN == "" {
+		label.CSN = generator.GenerateCSN()
+	}
+	return label, nil
+}
+
+// printImportRecord prints the result for one image in table mode.
+func printImportRecord(r importRecord) {
+	out := diagOut()
+	fmt.Fprintf(out, "📷 %s\n", r.File)
+	fmt.Fprintln(out, strings.Repeat("=", 50))
+	if r.URI != "" {
+		flags := "none"
+		if len(r.FlagNames) > 0 {
+			flags = strings.Join(r.FlagNames, ", ")
+		}
+		fmt.Fprintf(out, "  Setup URI:     %s\n", r.URI)
+		fmt.Fprintf(out, "  Setup Code:    %s\n", r.SetupCode)
+		fmt.Fprintf(out, "  Setup ID:      %s\n", orNotFound(r.SetupID))
+		fmt.Fprintf(out, "  Category:      %d (%s)\n", r.Category, r.CategoryName)
+		fmt.Fprintf(out, "  Flags:         %s (0x%X)\n", flags, r.Flags)
+		fmt.Fprintf(out, "  MAC Address:   %s\n", orNotFound(homekit.FormatMAC(r.MAC)))
+		fmt.Fprintf(out, "  Device Code:   %s\n", orNotFound(r.DeviceCode))
+		fmt.Fprintf(out, "  Serial:        %s\n", orNotFound(r.Serial))
+		fmt.Fprintf(out, "  CSN:           %s\n", orNotFound(r.CSN))
+	}
+	fmt.Fprintln(out, strings.Repeat("=", 50))
+	if r.Error != "" {
+		fmt.Fprintf(os.Stderr, "❌ Error: %s\n", r.Error)
+	} else if r.Output != "" {
+		fmt.Fprintf(out, "✅ QR-code opgeslagen als: %s\n", outputName(r.Output))
+	}
+	fmt.Fprintln(out)
+}
+
+// orNotFound returns s, or a placeholder for values that could not be read.
+func orNotFound(s string) string {
+	if s == "" {
+		return "(not found)"
+	}
+	return s
+}
@@ -2,14 +2,14 @@ package main
 
 import (
 	"fmt"
-	"math/rand"
 	"os"
 	"path/filepath"
-	"sort"
-	"strconv"
 	"strings"
 
+	"github.com/lordbasex/HomeKitGenQRCode/internal/api"
 	"github.com/lordbasex/HomeKitGenQRCode/internal/generator"
+	"github.com/lordbasex/HomeKitGenQRCode/internal/scan"
+	"github.com/lordbasex/HomeKitGenQRCode/pkg/homekit"
 
 	"github.com/spf13/cobra"
 )
@@ -19,16 +19,39 @@ var (
 	password string // Setup password in format XXX-XX-XXX
 	setupID  string // Setup ID (4 alphanumeric characters)
 	mac      string // MAC address (12 hexadecimal characters)
-	output   string // Output image file path
+	output   string // Output image file path ("-" for stdout)
 	category int    // HomeKit device category ID
+
+	generateStdin bool // Read JSON label specs from stdin, write NDJSON results
 )
 
 // Variables for code command flags
 var (
 	codeCategory int    // HomeKit device category ID
-	codeOutput   string // Output image file path
+	codeOutput   string // Output image file path ("-" for stdout)
 	codeSetupID  string // Setup ID (optional, auto-generated if not provided)
 	codeMAC      string // MAC address (optional, auto-generated if not provided)
+	codeKeyFile  string // Factory master key file (optional, derives setup code from the serial or MAC)
+	codeSerial   string // Serial number printed on the label (optional, random if not provided)
+
+	codeMACBlock string // Owned MAC block to allocate from, e.g. 70:B3:D5:12:30:00/36
+	codeMACRange string // Offset range inside the block (START-END)
+	codeMACAlloc string // Allocation mode inside the block: sequential or random
+	codeMACState string // JSON file tracking allocated addresses
+)
+
+// Label rendering flags shared by the generate and code commands
+var (
+	macStyle        string // Human-readable MAC text style (colon, hyphen, dot, bare)
+	macBarcodeStyle string // MAC barcode content style (hyphen, dot, bare)
+	brand           string // Brand line printed on the label
+	outputDir       string // Directory for relative output paths
+	noVerify        bool   // Skip reading back the rendered label
+	qrLevel         string // QR error correction level (L, M, Q, H)
+	qrVersion       int    // Forced QR version (0 for the smallest that fits)
+	qrMinVersion    int    // Smallest QR version to use
+	qrQuietZone     int    // QR quiet zone width in modules
+	qrBackground    string // QR background: transparent or opaque
 )
 
 // version is set at build time via ldflags
@@ -60,6 +83,12 @@ Examples:
   # List all available categories
   homekitgenqrcode list-categories
 
+  # Machine-readable output (diagnostics go to stderr)
+  homekitgenqrcode code -c 5 -o example.png --output-format json
+
+  # Use a named profile from the config file
+  homekitgenqrcode code -o example.png --profile acme
+
 For more documentation, visit: https://github.com/lordbasex/HomeKitGenQRCode`,
 }
 
@@ -74,7 +103,21 @@ All parameters are required:
   - password: Setup password in format XXX-XX-XXX (e.g., 613-80-755)
   - setup-id: Setup ID with 4 alphanumeric characters (0-9, A-Z) (e.g., ABCD)
   - mac: MAC address with 12 hexadecimal characters (e.g., AABBCCDDEEFF)
-  - output: Output image file path (PNG format, directory will be created if needed)`,
+  - output: Output image file path (PNG format, directory will be created if needed, - for stdout)
+
+With --stdin, one JSON label spec is read per line instead and one JSON result
+is written per line (NDJSON). Missing password, setup-id and mac are generated;
+without "output" the PNG is returned inline as a base64 data URL:
+  {"category":5,"password":"613-80-755","setupId":"ABCD","mac":"AABBCCDDEEFF","output":"a.png"}
+--nfc, --homespan, --bitmap, --esp-prov and --preview apply to every spec;
+the file outputs need the spec's "output".
+
+Examples:
+  # Stream the label to the printer
+  homekitgenqrcode generate -c 5 -p 613-80-755 -s ABCD -m AABBCCDDEEFF -o - | lp
+
+  # Batch from a JSON lines file
+  homekitgenqrcode generate --stdin < labels.jsonl > results.jsonl`,
 	RunE: runGenerate,
 }
 
@@ -83,7 +126,7 @@ var listCategoriesCmd = &cobra.Command{
 	Use:   "list-categories",
 	Short: "List all available HomeKit categories",
 	Long:  "Display all available HomeKit device categories with their IDs.",
-	Run:   runListCategories,
+	RunE:  runListCategories,
 }
 
 // codeCmd is the command for generating QR codes with auto-generated setup code
@@ -119,6 +162,27 @@ Examples:
   
   # Generate in a specific directory (will be created automatically)
   homekitgenqrcode code -c 5 -o output/example.png
+  
+  # Derive setup code and setup ID from a factory master key and the MAC
+  homekitgenqrcode code -c 5 -o example.png -m AABBCCDDEEFF --master-key-file factory.key
+  
+  # Derive them from the device serial number instead (printed on the label)
+  homekitgenqrcode code -c 5 -o example.png --serial SN0001234 --master-key-file factory.key
+  
+  # Allocate the MAC from an owned MA-S block (tracked in mac-allocations.json)
+  homekitgenqrcode code -c 5 -o example.png --mac-block 70:B3:D5:12:30:00/36
+  
+  # Also write NFC sticker files (example.ndef, example-ntag213.bin, example.nfc)
+  homekitgenqrcode code -c 5 -o example.png --nfc
+  
+  # Also write HomeSpan files (example.homespan.txt, .homespan.cpp, .homespan.h)
+  homekitgenqrcode code -c 5 -o example.png --homespan
+  
+  # Add an ESP-IDF Wi-Fi provisioning QR code to the label
+  homekitgenqrcode code -c 5 -o example.png --esp-prov --esp-prov-transport ble
+  
+  # Also write the QR code as firmware bitmaps for an on-device display
+  homekitgenqrcode code -c 5 -o example.png --bitmap c,xbm,lvgl,gfx --bitmap-scale 3
 
 For more documentation, visit: https://github.com/lordbasex/HomeKitGenQRCode`,
 	RunE: runCode,
@@ -130,25 +194,42 @@ func init() {
 	generateCmd.Flags().IntVarP(&category, "category", "c", 0, "HomeKit category ID (required)")
 	generateCmd.Flags().StringVarP(&password, "password", "p", "", "Setup password in format XXX-XX-XXX (required)")
 	generateCmd.Flags().StringVarP(&setupID, "setup-id", "s", "", "Setup ID: 4 alphanumeric characters (0-9, A-Z) (required)")
-	generateCmd.Flags().StringVarP(&mac, "mac", "m", "", "MAC address: 12 hexadecimal characters (required)")
-	generateCmd.Flags().StringVarP(&output, "output", "o", "", "Output image file path (PNG format) (required)")
+	generateCmd.Flags().StringVarP(&mac, "mac", "m", "", "MAC address: EUI-48 or EUI-64 in bare, colon, hyphen or Cisco dotted notation (required)")
+	generateCmd.Flags().StringVarP(&output, "output", "o", "", "Output image file path (PNG format), or - for stdout (required)")
+	generateCmd.Flags().BoolVar(&generateStdin, "stdin", false, "Read one JSON label spec per line from stdin and write NDJSON results to stdout")
 
-	// Mark flags as required
-	generateCmd.MarkFlagRequired("category")
-	generateCmd.MarkFlagRequired("password")
-	generateCmd.MarkFlagRequired("setup-id")
-	generateCmd.MarkFlagRequired("mac")
-	generateCmd.MarkFlagRequired("output")
+	// The parameter flags are required unless --stdin is used (checked in runGenerate).
+	// With --stdin, --category is the default category of specs without one.
+	generateCmd.MarkFlagsMutuallyExclusive("stdin", "password")
+	generateCmd.MarkFlagsMutuallyExclusive("stdin", "setup-id")
+	generateCmd.MarkFlagsMutuallyExclusive("stdin", "mac")
+	generateCmd.MarkFlagsMutuallyExclusive("stdin", "output")
 
 	// Code command flags
 	codeCmd.Flags().IntVarP(&codeCategory, "category", "c", 0, "HomeKit category ID (required)")
-	codeCmd.Flags().StringVarP(&codeOutput, "output", "o", "", "Output image file path (PNG format) (required)")
+	codeCmd.Flags().StringVarP(&codeOutput, "output", "o", "", "Output image file path (PNG format), or - for stdout (required)")
 	codeCmd.Flags().StringVarP(&codeSetupID, "setup-id", "s", "", "Setup ID: 4 alphanumeric characters (0-9, A-Z) (optional, auto-generated if not provided)")
-	codeCmd.Flags().StringVarP(&codeMAC, "mac", "m", "", "MAC address: 12 hexadecimal characters (optional, auto-generated if not provided)")
+	codeCmd.Flags().StringVarP(&codeMAC, "mac", "m", "", "MAC address: EUI-48 or EUI-64 in bare, colon, hyphen or Cisco dotted notation (optional, auto-generated if not provided)")
+
+	codeCmd.Flags().StringVar(&codeKeyFile, "master-key-file", "", "Factory master key file (hexadecimal): derive setup code and setup ID from the key and the serial number (--serial) or MAC address (--mac or --mac-block) (optional)")
+	codeCmd.Flags().StringVar(&codeSerial, "serial", "", "Serial number printed on the label and used for --master-key-file derivation (optional, the label shows a random serial if not provided)")
+
+	codeCmd.Flags().StringVar(&codeMACBlock, "mac-block", "", "Allocate the MAC from an owned block: <address>/<prefix bits> (e.g., 70:B3:D5:12:30:00/36) or an OUI")
+	codeCmd.Flags().StringVar(&codeMACRange, "mac-range", "", "Offset range inside --mac-block: START-END (e.g., 0x100-0x1FF) (default: whole block)")
+	codeCmd.Flags().StringVar(&codeMACAlloc, "mac-alloc", "sequential", "Allocation mode inside --mac-block: sequential or random")
+	codeCmd.Flags().StringVar(&codeMACState, "mac-state", "mac-allocations.json", "State file tracking MAC addresses allocated from --mac-block")
+	codeCmd.MarkFlagsMutuallyExclusive("mac", "mac-block")
 
 	codeCmd.MarkFlagRequired("category")
 	codeCmd.MarkFlagRequired("output")
 
+	// Label rendering flags
+	addLabelFlags(generateCmd)
+	addLabelFlags(codeCmd)
+
+	// Global flags
+	addOutputFormatFlag(rootCmd)
+
 	// Add commands to root
 	rootCmd.AddCommand(generateCmd)
 	rootCmd.AddCommand(listCategoriesCmd)
@@ -158,256 +239,409 @@ func init() {
 // runGenerate executes the generate command
 // It validates all inputs and generates the HomeKit QR code label
 func runGenerate(cmd *cobra.Command, args []string) error {
-	// Trim whitespace from inputs
-	password = strings.TrimSpace(password)
-	setupID = strings.TrimSpace(strings.ToUpper(setupID))
-	mac = strings.TrimSpace(strings.ToUpper(mac))
-	output = strings.TrimSpace(output)
-
-	// Validate all inputs
-	if err := validateInputs(); err != nil {
+	if generateStdin {
+		opts, err := labelOptions()
+		if err != nil {
+			return err
+		}
+		// Specs without an output get their image inline, so the file
+		// outputs are checked per spec (see processLabelSpec)
+		if err := checkNFCFlags(""); err != nil {
+			return err
+		}
+		if err := checkBitmapFlags(""); err != nil {
+			return err
+		}
+		if err := checkESPProvFlags(); err != nil {
+			return err
+		}
+		return runStdin(os.Stdin, os.Stdout, category, opts)
+	}
+	if err := requireFlags(cmd, "category", "password", "setup-id", "mac", "output"); err != nil {
+		return err
+	}
+
+	// Normalize and validate setup parameters with the shared HAP rules
+	info, err := homekit.ParseSetupInfo(category, password, setupID, mac)
+	if err != nil {
 		return fmt.Errorf("validation error: %w", err)
 	}
 
-	// Ensure output directory exists
-	if err := ensureOutputDirectory(output); err != nil {
-		return fmt.Errorf("error creating output directory: %w", err)
+	output = resolveOutputPath(strings.TrimSpace(output))
+	if err := validateOutputPath(output); err != nil {
+		return fmt.Errorf("validation error: %w", err)
+	}
+	if err := checkStdoutOutput(output); err != nil {
+		return err
+	}
+	if err := checkNFCFlags(output); err != nil {
+		return err
+	}
+	if err := checkHomeSpanFlags(output); err != nil {
+		return err
+	}
+	if err := checkBitmapFlags(output); err != nil {
+		return err
+	}
+	if err := checkESPProvFlags(); err != nil {
+		return err
 	}
 
+	opts, err := labelOptions()
+	if err != nil {
+		return err
+	}
+	warnMAC(info.MAC, true)
+	warnSetupCode(info.SetupCode)
+
 	// Generate the HomeKit label
-	err := generator.GenerateHomeKitLabel(category, password, setupID, mac, output)
+	label := generator.NewLabel(info)
+	applyNFCFlag(&label)
+	espProv, err := applyESPProv(&label)
 	if err != nil {
+		return err
+	}
+	if err := writeLabel(label, output, opts); err != nil {
 		return fmt.Errorf("error generating label: %w", err)
 	}
+	nfcFiles, err := writeNFCFiles(label, output)
+	if err != nil {
+		return err
+	}
+	homespanFiles, err := writeHomeSpanFiles(label, output)
+	if err != nil {
+		return err
+	}
+	bitmapFiles, err := writeBitmapFiles(label, output, opts)
+	if err != nil {
+		return err
+	}
+	if previewQR {
+		if err := printPreview(diagOut(), label); err != nil {
+			return err
+		}
+	}
 
-	fmt.Printf("\n✅ QR-code opgeslagen als: %s\n", output)
+	if structuredOutput() {
+		record := newLabelRecord(label, output)
+		record.NFC = nfcFiles
+		record.HomeSpan = homespanFiles
+		record.Bitmaps = bitmapFiles
+		record.ESPProv = espProv
+		return writeStructured(record)
+	}
+	fmt.Fprintf(diagOut(), "\n✅ QR-code opgeslagen als: %s\n", outputName(output))
+	printNFCFiles(nfcFiles)
+	printHomeSpanFiles(homespanFiles)
+	printBitmapFiles(bitmapFiles)
+	printESPProv(espProv)
 	return nil
 }
 
 // runListCategories executes the list-categories command
 // It displays all available HomeKit device categories sorted by ID
-func runListCategories(cmd *cobra.Command, args []string) {
+func runListCategories(cmd *cobra.Command, args []string) error {
+	if structuredOutput() {
+		categories := []api.Category{}
+		for _, id := range homekit.CategoryIDs() {
+			categories = append(categories, api.Category{ID: id, Name: homekit.CategoryReference[id]})
+		}
+		return writeStructured(categories)
+	}
+
 	fmt.Println("Available HomeKit Categories:")
 	fmt.Println(strings.Repeat("=", 50))
 
-	// Get all categories and sort by ID
-	ids := make([]int, 0, len(generator.CategoryReference))
-	for id := range generator.CategoryReference {
-		ids = append(ids, id)
-	}
-	sort.Ints(ids)
-
-	for _, id := range ids {
-		fmt.Printf("  %2d: %s\n", id, generator.CategoryReference[id])
+	for _, id := range homekit.CategoryIDs() {
+		fmt.Printf("  %2d: %s\n", id, homekit.CategoryReference[id])
 	}
 	fmt.Println()
+	return nil
 }
 
 // runCode executes the code command
 // It generates a setup code automatically and optionally generates setup ID and MAC address
 func runCode(cmd *cobra.Command, args []string) error {
 	// Validate category
-	if codeCategory < 1 {
-		return fmt.Errorf("category must be a positive number")
+	if err := homekit.ValidateCategory(codeCategory); err != nil {
+		return err
 	}
-	if _, exists := generator.CategoryReference[codeCategory]; !exists {
-		return fmt.Errorf("invalid category ID: %d. Use 'list-categories' to see available categories", codeCategory)
+	serial := ""
+	if codeSerial != "" {
+		normalized, err := generator.NormalizeSerial(codeSerial)
+		if err != nil {
+			return fmt.Errorf("invalid serial number: %w", err)
+		}
+		serial = normalized
+	}
+
+	// A derived code must be reproducible, so it needs an identifier that is
+	// recorded: the serial number, an explicit MAC or one allocated from a block
+	var masterKey []byte
+	if codeKeyFile != "" {
+		if serial == "" && codeMAC == "" && codeMACBlock == "" {
+			return fmt.Errorf("validation error: --master-key-file needs --serial, --mac or --mac-block; a generated MAC would derive a different code on every run")
+		}
+		data, err := os.ReadFile(codeKeyFile)
+		if err != nil {
+			return fmt.Errorf("error reading master key file: %w", err)
+		}
+		if masterKey, err = homekit.ParseMasterKey(data); err != nil {
+			return fmt.Errorf("validation error: %s: %w", codeKeyFile, err)
+		}
 	}
 
 	// Validate output path
-	codeOutput = strings.TrimSpace(codeOutput)
-	if codeOutput == "" {
-		return fmt.Errorf("output path cannot be empty")
+	codeOutput = resolveOutputPath(strings.TrimSpace(codeOutput))
+	if err := validateOutputPath(codeOutput); err != nil {
+		return err
 	}
-	if !strings.HasSuffix(strings.ToLower(codeOutput), ".png") {
-		return fmt.Errorf("output file must have .png extension")
+	if err := checkStdoutOutput(codeOutput); err != nil {
+		return err
+	}
+	if err := checkNFCFlags(codeOutput); err != nil {
+		return err
+	}
+	if err := checkHomeSpanFlags(codeOutput); err != nil {
+		return err
+	}
+	if err := checkBitmapFlags(codeOutput); err != nil {
+		return err
+	}
+	if err := checkESPProvFlags(); err != nil {
+		return err
 	}
 
-	// Generate setup code automatically
-	setupCode := generator.GenerateHomeKitSetupCode()
+	opts, err := labelOptions()
+	if err != nil {
+		return err
+	}
 
-	// Generate setup ID if not provided
-	if codeSetupID == "" {
-		codeSetupID = generateRandomSetupID()
-	} else {
-		codeSetupID = strings.TrimSpace(strings.ToUpper(codeSetupID))
-		if err := validateSetupID(codeSetupID); err != nil {
-			return fmt.Errorf("invalid setup ID: %w", err)
+	info := homekit.SetupInfo{Category: codeCategory}
+
+	// Generate MAC address if not provided
+	switch {
+	case codeMAC != "":
+		normalized, err := homekit.NormalizeMAC(codeMAC)
+		if err != nil {
+			return fmt.Errorf("invalid MAC address: %w", err)
+		}
+		info.MAC = normalized
+	case codeMACBlock != "":
+		addr, err := allocateMAC()
+		if err != nil {
+			return err
+		}
+		info.MAC = addr.String()
+	default:
+		info.MAC = homekit.GenerateMAC()
+	}
+	warnMAC(info.MAC, codeMAC != "")
+
+	// Generate setup code automatically, or derive it from the master key and
+	// the serial number, or the MAC if no serial number is given
+	derivedSetupID := ""
+	if masterKey != nil {
+		identifier := info.MAC
+		if serial != "" {
+			identifier = serial
 		}
+		info.SetupCode, derivedSetupID, err = homekit.DeriveSetupCode(masterKey, identifier)
+		if err != nil {
+			return fmt.Errorf("error deriving setup code: %w", err)
+		}
+	} else {
+		info.SetupCode = homekit.GenerateHomeKitSetupCode()
 	}
 
-	// Generate MAC address if not provided
-	if codeMAC == "" {
-		codeMAC = generateRandomMAC()
+	// Generate setup ID if not provided (an explicit setup ID wins over a derived one)
+	if codeSetupID == "" {
+		if derivedSetupID != "" {
+			info.SetupID = derivedSetupID
+		} else {
+			info.SetupID = homekit.GenerateSetupID()
+		}
 	} else {
-		codeMAC = strings.TrimSpace(strings.ToUpper(codeMAC))
-		if err := validateMAC(codeMAC); err != nil {
-			return fmt.Errorf("invalid MAC address: %w", err)
+		normalized, err := homekit.NormalizeSetupID(codeSetupID)
+		if err != nil {
+			return fmt.Errorf("invalid setup ID: %w", err)
 		}
+		info.SetupID = normalized
 	}
 
 	// Display generated values
-	fmt.Println("Generated HomeKit Setup Information:")
-	fmt.Println(strings.Repeat("=", 50))
-	fmt.Printf("  Setup Code:    %s\n", setupCode)
-	fmt.Printf("  Setup ID:      %s\n", codeSetupID)
-	fmt.Printf("  MAC Address:   %s\n", formatMACDisplay(codeMAC))
-	fmt.Printf("  Category:      %d (%s)\n", codeCategory, generator.CategoryReference[codeCategory])
-	fmt.Println(strings.Repeat("=", 50))
-	fmt.Println()
-
-	// Ensure output directory exists
-	if err := ensureOutputDirectory(codeOutput); err != nil {
-		return fmt.Errorf("error creating output directory: %w", err)
+	if !structuredOutput() {
+		out := diagOut()
+		fmt.Fprintln(out, "Generated HomeKit Setup Information:")
+		fmt.Fprintln(out, strings.Repeat("=", 50))
+		fmt.Fprintf(out, "  Setup Code:    %s\n", info.SetupCode)
+		fmt.Fprintf(out, "  Setup ID:      %s\n", info.SetupID)
+		fmt.Fprintf(out, "  MAC Address:   %s\n", homekit.FormatMAC(info.MAC))
+		fmt.Fprintf(out, "  Category:      %d (%s)\n", info.Category, info.CategoryName())
+		if serial != "" {
+			fmt.Fprintf(out, "  Serial:        %s\n", serial)
+		}
+		fmt.Fprintln(out, strings.Repeat("=", 50))
+		fmt.Fprintln(out)
 	}
 
 	// Generate the HomeKit label
-	err := generator.GenerateHomeKitLabel(codeCategory, setupCode, codeSetupID, codeMAC, codeOutput)
+	label := generator.NewLabel(info)
+	if serial != "" {
+		label.Serial = serial
+	}
+	applyNFCFlag(&label)
+	espProv, err := applyESPProv(&label)
 	if err != nil {
+		return err
+	}
+	if err := writeLabel(label, codeOutput, opts); err != nil {
 		return fmt.Errorf("error generating label: %w", err)
 	}
-
-	fmt.Printf("✅ QR-code opgeslagen als: %s\n", codeOutput)
-	return nil
-}
-
-// generateRandomSetupID generates a random 4-character setup ID.
-// Characters are selected from 0-9 and A-Z (36 possible characters).
-func generateRandomSetupID() string {
-	const chars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
-	result := make([]byte, 4)
-	for i := range result {
-		result[i] = chars[rand.Intn(len(chars))]
+	nfcFiles, err := writeNFCFiles(label, codeOutput)
+	if err != nil {
+		return err
 	}
-	return string(result)
-}
-
-// generateRandomMAC generates a random 12-character hexadecimal MAC address.
-// Characters are selected from 0-9 and A-F (16 possible characters).
-func generateRandomMAC() string {
-	const hexChars = "0123456789ABCDEF"
-	result := make([]byte, 12)
-	for i := range result {
-		result[i] = hexChars[rand.Intn(len(hexChars))]
+	homespanFiles, err := writeHomeSpanFiles(label, codeOutput)
+	if err != nil {
+		return err
 	}
-	return string(result)
-}
-
-// formatMACDisplay formats a MAC address for display by adding colons every 2 characters.
-// Example: "AABBCCDDEEFF" -> "AA:BB:CC:DD:EE:FF"
-// If the MAC address is not 12 characters, returns it unchanged.
-func formatMACDisplay(mac string) string {
-	if len(mac) != 12 {
-		return mac
+	bitmapFiles, err := writeBitmapFiles(label, codeOutput, opts)
+	if err != nil {
+		return err
 	}
-	var parts []string
-	for i := 0; i < 12; i += 2 {
-		parts = append(parts, mac[i:i+2])
+	if previewQR {
+		if err := printPreview(diagOut(), label); err != nil {
+			return err
+		}
 	}
-	return strings.Join(parts, ":")
-}
 
-// validateInputs validates all input parameters for the generate command.
-// Returns an error if any validation fails.
-func validateInputs() error {
-	// Validate category
-	if category < 1 {
-		return fmt.Errorf("category must be a positive number")
-	}
-	if _, exists := generator.CategoryReference[category]; !exists {
-		return fmt.Errorf("invalid category ID: %d. Use 'list-categories' to see available categories", category)
+	if structuredOutput() {
+		record := newLabelRecord(label, codeOutput)
+		record.NFC = nfcFiles
+		record.HomeSpan = homespanFiles
+		record.Bitmaps = bitmapFiles
+		record.ESPProv = espProv
+		return writeStructured(record)
 	}
+	fmt.Fprintf(diagOut(), "✅ QR-code opgeslagen als: %s\n", outputName(codeOutput))
+	printNFCFiles(nfcFiles)
+	printHomeSpanFiles(homespanFiles)
+	printBitmapFiles(bitmapFiles)
+	printESPProv(espProv)
+	return nil
+}
 
-	// Validate password format: XXX-XX-XXX
-	if err := validatePassword(password); err != nil {
-		return err
+// allocateMAC allocates the next MAC address from the block given by the --mac-block flags.
+func allocateMAC() (homekit.MAC, error) {
+	block, err := homekit.ParseMACBlock(codeMACBlock)
+	if err != nil {
+		return nil, err
 	}
-
-	// Validate setup ID format: 4 alphanumeric characters (0-9, A-Z)
-	if err := validateSetupID(setupID); err != nil {
-		return err
+	mode, err := homekit.ParseMACAllocMode(codeMACAlloc)
+	if err != nil {
+		return nil, err
 	}
 
-	// Validate MAC address format: 12 hexadecimal characters
-	if err := validateMAC(mac); err != nil {
-		return err
+	allocator := homekit.NewMACAllocator(block, mode, codeMACState)
+	if codeMACRange != "" {
+		if err := allocator.SetRange(codeMACRange); err != nil {
+			return nil, err
+		}
 	}
 
-	// Validate output path
-	if output == "" {
-		return fmt.Errorf("output path cannot be empty")
-	}
-	if !strings.HasSuffix(strings.ToLower(output), ".png") {
-		return fmt.Errorf("output file must have .png extension")
+	addr, err := allocator.Allocate()
+	if err != nil {
+		return nil, fmt.Errorf("error allocating MAC address: %w", err)
 	}
+	return addr, nil
+}
 
-	return nil
+// addLabelFlags registers the label rendering flags on a command.
+func addLabelFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&macStyle, "mac-style", "colon", "MAC text style on the label: colon, hyphen, dot or bare")
+	cmd.Flags().StringVar(&macBarcodeStyle, "mac-barcode-style", "bare", "MAC barcode content style: hyphen, dot or bare")
+	cmd.Flags().StringVar(&brand, "brand", generator.DefaultBrand, "Brand line printed on the label")
+	cmd.Flags().StringVar(&outputDir, "output-dir", "", "Directory for relative output paths")
+	cmd.Flags().BoolVar(&noVerify, "no-verify", false, "Skip reading back the QR code and barcodes after rendering")
+	cmd.Flags().StringVar(&qrLevel, "qr-level", string(generator.DefaultQRLevel), "QR error correction level: L, M, Q or H")
+	cmd.Flags().IntVar(&qrVersion, "qr-version", 0, "Force the QR version (1-40, default: the smallest that fits)")
+	cmd.Flags().IntVar(&qrMinVersion, "qr-min-version", 0, "Smallest QR version to use (1-40)")
+	cmd.Flags().IntVar(&qrQuietZone, "qr-quiet-zone", generator.DefaultQuietZone, "QR quiet zone width in modules (1-16)")
+	cmd.Flags().StringVar(&qrBackground, "qr-background", string(generator.QRBackgroundTransparent), "QR background: transparent or opaque (white)")
 }
 
-// validatePassword validates password format XXX-XX-XXX.
-// The password must be exactly 10 characters: 3 digits, dash, 2 digits, dash, 3 digits.
-// All parts must be numeric.
-func validatePassword(pwd string) error {
-	// Trim whitespace
-	pwd = strings.TrimSpace(pwd)
-	// Format XXX-XX-XXX has 10 characters: 3 + 1 + 2 + 1 + 3 = 10
-	if len(pwd) != 10 {
-		return fmt.Errorf("invalid password length (%d). Expected format: XXX-XX-XXX (e.g., 613-80-755)", len(pwd))
+// labelOptions builds the generator options from the label rendering flags.
+func labelOptions() (generator.LabelOptions, error) {
+	textStyle, err := homekit.ParseMACStyle(macStyle)
+	if err != nil {
+		return generator.LabelOptions{}, err
 	}
-
-	parts := strings.Split(pwd, "-")
-	if len(parts) != 3 {
-		return fmt.Errorf("invalid password format. Expected format: XXX-XX-XXX (e.g., 613-80-755)")
+	barcodeStyle, err := homekit.ParseMACStyle(macBarcodeStyle)
+	if err != nil {
+		return generator.LabelOptions{}, err
 	}
 
-	if len(parts[0]) != 3 || len(parts[1]) != 2 || len(parts[2]) != 3 {
-		return fmt.Errorf("invalid password format. Expected format: XXX-XX-XXX (e.g., 613-80-755)")
+	level, err := scan.ParseLevel(qrLevel)
+	if err != nil {
+		return generator.LabelOptions{}, err
 	}
-
-	for i, part := range parts {
-		if _, err := strconv.Atoi(part); err != nil {
-			return fmt.Errorf("password part %d contains non-numeric characters. Expected format: XXX-XX-XXX", i+1)
-		}
+	background, err := generator.ParseQRBackground(qrBackground)
+	if err != nil {
+		return generator.LabelOptions{}, err
+	}
+	if qrQuietZone < 1 {
+		return generator.LabelOptions{}, fmt.Errorf("--qr-quiet-zone must be at least 1 module")
 	}
 
-	return nil
+	opts := generator.LabelOptions{
+		MACStyle:        textStyle,
+		MACBarcodeStyle: barcodeStyle,
+		Brand:           strings.TrimSpace(brand),
+		NoVerify:        noVerify,
+		QR:              generator.QRSymbolOptions{Level: level, Version: qrVersion, MinVersion: qrMinVersion},
+		QRQuietZone:     qrQuietZone,
+		QRBackground:    background,
+	}
+	if err := opts.Validate(); err != nil {
+		return generator.LabelOptions{}, err
+	}
+	return opts, nil
 }
 
-// validateSetupID validates setup ID format (4 alphanumeric: 0-9, A-Z).
-// The setup ID must be exactly 4 characters and contain only alphanumeric characters.
-// The input is automatically converted to uppercase.
-func validateSetupID(id string) error {
-	// Trim whitespace and convert to uppercase
-	id = strings.TrimSpace(strings.ToUpper(id))
-	if len(id) != 4 {
-		return fmt.Errorf("invalid setup ID length. Expected 4 alphanumeric characters (0-9, A-Z)")
+// warnMAC prints warnings about unusual bits in a MAC address to stderr.
+// Locally administered addresses are only reported when the user supplied the
+// address; generated addresses are locally administered on purpose.
+func warnMAC(normalized string, userSupplied bool) {
+	addr, err := homekit.ParseMAC(normalized)
+	if err != nil {
+		return
 	}
-
-	for i, r := range id {
-		if !((r >= '0' && r <= '9') || (r >= 'A' && r <= 'Z')) {
-			return fmt.Errorf("invalid character '%c' at position %d. Setup ID must contain only 0-9 and A-Z", r, i+1)
-		}
+	for _, warning := range addr.Warnings(userSupplied) {
+		fmt.Fprintf(os.Stderr, "⚠️  Warning: %s\n", warning)
 	}
-
-	return nil
 }
 
-// validateMAC validates MAC address format (12 hexadecimal characters).
-// The MAC address must be exactly 12 characters and contain only hexadecimal characters (0-9, A-F).
-// The input is automatically converted to uppercase.
-func validateMAC(macAddr string) error {
-	// Trim whitespace and convert to uppercase
-	macAddr = strings.TrimSpace(strings.ToUpper(macAddr))
-	if len(macAddr) != 12 {
-		return fmt.Errorf("invalid MAC address length. Expected 12 hexadecimal characters (e.g., AABBCCDDEEFF)")
+// warnSetupCode prints a warning to stderr when a user-supplied setup code is
+// allowed by the HAP specification but easy to guess.
+func warnSetupCode(code string) {
+	if warning := homekit.SetupCodeWarning(code); warning != "" {
+		fmt.Fprintf(os.Stderr, "⚠️  Warning: %s\n", warning)
 	}
+}
 
-	for i, r := range macAddr {
-		if !((r >= '0' && r <= '9') || (r >= 'a' && r <= 'f') || (r >= 'A' && r <= 'F')) {
-			return fmt.Errorf("invalid character '%c' at position %d. MAC address must contain only hexadecimal characters (0-9, A-F)", r, i+1)
-		}
+// validateOutputPath validates the output image path.
+// The path must not be empty and must have a .png extension, or be "-" for stdout.
+func validateOutputPath(path string) error {
+	if path == "" {
+		return fmt.Errorf("output path cannot be empty")
+	}
+	if path == stdoutPath {
+		return nil
+	}
+	if !strings.HasSuffix(strings.ToLower(path), ".png") {
+		return fmt.Errorf("output file must have .png extension")
 	}
-
 	return nil
 }
 
@@ -428,7 +662,7 @@ func ensureOutputDirectory(outputPath string) error {
 		if err := os.MkdirAll(outputDir, 0755); err != nil {
 			return fmt.Errorf("failed to create directory '%s': %w", outputDir, err)
 		}
-		fmt.Printf("📁 Created output directory: %s\n", outputDir)
+		fmt.Fprintf(diagOut(), "📁 Created output directory: %s\n", outputDir)
 	}
 
 	return nil
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lordbasex/HomeKitGenQRCode/internal/generator"
+	"github.com/lordbasex/HomeKitGenQRCode/pkg/homekit"
+
+	"github.com/spf13/cobra"
+)
+
+// Variables for matter command flags
+var (
+	matterOutput        string   // Output image file path ("-" for stdout)
+	matterCategory      int      // HomeKit category ID used for the device code
+	matterVendorID      string   // Vendor ID (decimal or 0x hex)
+	matterProductID     string   // Product ID (decimal or 0x hex)
+	matterPasscode      int      // Setup passcode (0 for random)
+	matterDiscriminator int      // Discriminator (-1 for random)
+	matterFlow          string   // Commissioning flow: standard, user-intent or custom
+	matterDiscovery     []string // Discovery capabilities: softap, ble, on-network
+	matterMAC           string   // MAC address (optional, auto-generated if not provided)
+)
+
+// matterCmd generates labels for Matter devices
+var matterCmd = &cobra.Command{
+	Use:   "matter",
+	Short: "Generate a Matter onboarding label",
+	Long: `Generate a label for a Matter device with the MT: onboarding QR code and the
+manual pairing code (11 digits, or 21 digits with the vendor and product ID
+for the user-intent and custom commissioning flows, ending in a Verhoeff check
+digit).
+
+The passcode and discriminator are generated unless given. Passcodes are
+checked against the trivial values the Matter specification forbids
+(00000000, 11111111, ..., 12345678, 87654321).
+
+The label uses the Matter layout: the manual pairing code replaces the
+HomeKit icon and setup code digits.
+
+Examples:
+  homekitgenqrcode matter --vid 0xFFF1 --pid 0x8000 -o matter.png
+  homekitgenqrcode matter --vid 0xFFF1 --pid 0x8000 --passcode 20202021 --discriminator 3840 -o matter.png
+  homekitgenqrcode matter --vid 0xFFF1 --pid 0x8000 --flow custom --discovery ble,on-network -o matter.png`,
+	RunE: runMatter,
+}
+
+func init() {
+	matterCmd.Flags().StringVarP(&matterOutput, "output", "o", "", "Output image file path (PNG format), or - for stdout (required)")
+	matterCmd.Flags().StringVar(&matterVendorID, "vid", "", "Vendor ID, decimal or 0x hex (required)")
+	matterCmd.Flags().StringVar(&matterProductID, "pid", "", "Product ID, decimal or 0x hex (required)")
+	matterCmd.Flags().IntVar(&matterPasscode, "passcode", 0, "Setup passcode, 1-99999998 (default: random)")
+	matterCmd.Flags().IntVar(&matterDiscriminator, "discriminator", -1, "Discriminator, 0-4095 (default: random)")
+	matterCmd.Flags().StringVar(&matterFlow, "flow", "standard", "Commissioning flow: standard, user-intent or custom")
+	matterCmd.Flags().StringSliceVar(&matterDiscovery, "discovery", []string{"ble"}, "Discovery capabilities: softap, ble, on-network")
+	matterCmd.Flags().IntVarP(&matterCategory, "category", "c", 0, "HomeKit category ID used in the device code (optional)")
+	matterCmd.Flags().StringVarP(&matterMAC, "mac", "m", "", "MAC address (optional, auto-generated if not provided)")
+	matterCmd.MarkFlagRequired("output")
+	matterCmd.MarkFlagRequired("vid")
+	matterCmd.MarkFlagRequired("pid")
+	addLabelFlags(matterCmd)
+
+	rootCmd.AddCommand(matterCmd)
+}
+
+// matterRecord is the machine-readable result of the matter command.
+type matterRecord struct {
+	QRPayload     string   `json:"qrPayload" yaml:"qrPayload"`
+	ManualCode    string   `json:"manualCode" yaml:"manualCode"`
+	VendorID      uint16   `json:"vendorId" yaml:"vendorId"`
+	ProductID     uint16   `json:"productId" yaml:"productId"`
+	Passcode      int      `json:"passcode" yaml:"passcode"`
+	Discriminator int      `json:"discriminator" yaml:"discriminator"`
+	Flow          string   `json:"flow" yaml:"flow"`
+	Discovery     []string `json:"discovery" yaml:"discovery"`
+	MAC           string   `json:"mac" yaml:"mac"`
+	DeviceCode    string   `json:"deviceCode" yaml:"deviceCode"`
+	Serial        string   `json:"serial" yaml:"serial"`
+	CSN           string   `json:"csn" yaml:"csn"`
+	Output        string   `json:"output" yaml:"output"`
+}
+
+// runMatter executes the matter command
+func runMatter(cmd *cobra.Command, args []string) error {
+	payload, err := matterPayload()
+	if err != nil {
+		return fmt.Errorf("validation error: %w", err)
+	}
+	if matterCategory != 0 {
+		if err := homekit.ValidateCategory(matterCategory); err != nil {
+			return err
+		}
+	}
+
+	matterOutput = resolveOutputPath(strings.TrimSpace(matterOutput))
+	if err := validateOutputPath(matterOutput); err != nil {
+		return fmt.Errorf("validation error: %w", err)
+	}
+	if err := checkStdoutOutput(matterOutput); err != nil {
+		return err
+	}
+
+	opts, err := labelOptions()
+	if err != nil {
+		return err
+	}
+
+	mac := homekit.GenerateMAC()
+	if matterMAC != "" {
+		if mac, err = homekit.NormalizeMAC(matterMAC); err != nil {
+			return fmt.Errorf("validation error: %w", err)
+		}
+	}
+	warnMAC(mac, matterMAC != "")
+
+	label, err := generator.NewMatterLabel(payload, matterCategory, mac)
+	if err != nil {
+		return fmt.Errorf("validation error: %w", err)
+	}
+	if err := writeLabel(label, matterOutput, opts); err != nil {
+		return fmt.Errorf("error generating label: %w", err)
+	}
+
+	record := matterRecord{
+		QRPayload:     label.URI,
+		ManualCode:    label.SetupCode,
+		VendorID:      payload.VendorID,
+		ProductID:     payload.ProductID,
+		Passcode:      payload.Passcode,
+		Discriminator: payload.Discriminator,
+		Flow:          payload.Flow.String(),
+		Discovery:     payload.DiscoveryNames(),
+		MAC:           label.MAC,
+		DeviceCode:    label.DeviceCode,
+		Serial:        label.Serial,
+		CSN:           label.CSN,
+		Output:        matterOutput,
+	}
+	if record.Discovery == nil {
+		record.Discovery = []string{}
+	}
+	if structuredOutput() {
+		return writeStructured(record)
+	}
+
+	out := diagOut()
+	fmt.Fprintln(out, "Generated Matter Setup Information:")
+	fmt.Fprintln(out, strings.Repeat("=", 50))
+	fmt.Fprintf(out, "  QR Payload:    %s\n", record.QRPayload)
+	fmt.Fprintf(out, "  Manual Code:   %s\n", record.ManualCode)
+	fmt.Fprintf(out, "  Vendor ID:     0x%04X\n", record.VendorID)
+	fmt.Fprintf(out, "  Product ID:    0x%04X\n", record.ProductID)
+	fmt.Fprintf(out, "  Passcode:      %08d\n", record.Passcode)
+	fmt.Fprintf(out, "  Discriminator: %d (0x%03X)\n", record.Discriminator, record.Discriminator)
+	fmt.Fprintf(out, "  Flow:          %s\n", record.Flow)
+	fmt.Fprintf(out, "  Discovery:     %s\n", strings.Join(record.Discovery, ", "))
+	fmt.Fprintf(out, "  MAC Address:   %s\n", homekit.FormatMAC(record.MAC))
+	fmt.Fprintln(out, strings.Repeat("=", 50))
+	fmt.Fprintf(out, "✅ QR-code opgeslagen als: %s\n", outputName(matterOutput))
+	return nil
+}
+
+// matterPayload builds the onboarding payload from the matter command flags,
+// generating the passcode and discriminator when they are not given.
+func matterPayload() (homekit.MatterPayload, error) {
+	vendorID, err := strconv.ParseUint(strings.TrimSpace(matterVendorID), 0, 16)
+	if err != nil {
+		return homekit.MatterPayload{}, fmt.Errorf("invalid vendor ID %q: must be 0-0xFFFF", matterVendorID)
+	}
+	productID, err := strconv.ParseUint(strings.TrimSpace(matterProductID), 0, 16)
+	if err != nil {
+		return homekit.MatterPayload{}, fmt.Errorf("invalid product ID %q: must be 0-0xFFFF", matterProductID)
+	}
+	flow, err := homekit.ParseMatterFlow(matterFlow)
+	if err != nil {
+		return homekit.MatterPayload{}, err
+	}
+	discovery, err := homekit.ParseMatterDiscovery(matterDiscovery)
+	if err != nil {
+		return homekit.MatterPayload{}, err
+	}
+
+	payload := homekit.MatterPayload{
+		VendorID:      uint16(vendorID),
+		ProductID:     uint16(productID),
+		Flow:          flow,
+		Discovery:     discovery,
+		Discriminator: matterDiscriminator,
+		Passcode:      matterPasscode,
+	}
+	if payload.Passcode == 0 {
+		payload.Passcode = homekit.GenerateMatterPasscode()
+	}
+	if payload.Discriminator < 0 {
+		payload.Discriminator = homekit.GenerateMatterDiscriminator()
+	}
+	return payload, payload.Validate()
+}
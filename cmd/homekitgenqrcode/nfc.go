@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lordbasex/HomeKitGenQRCode/internal/generator"
+	"github.com/lordbasex/HomeKitGenQRCode/internal/nfc"
+	"github.com/lordbasex/HomeKitGenQRCode/pkg/homekit"
+
+	"github.com/spf13/cobra"
+)
+
+// NFC tag flags shared by generate and code
+var (
+	nfcOutput bool   // Also write NFC tag files next to the label
+	nfcTag    string // NTAG type for the memory dump and Flipper file
+	nfcLock   bool   // Make the tag read-only
+)
+
+func init() {
+	for _, cmd := range []*cobra.Command{generateCmd, codeCmd} {
+		cmd.Flags().BoolVar(&nfcOutput, "nfc", false, "Also write NFC tag files: raw NDEF (.ndef), NTAG memory dump (.bin) and Flipper Zero (.nfc)")
+		cmd.Flags().StringVar(&nfcTag, "nfc-tag", string(nfc.NTAG213), "NFC tag type for the memory dump: ntag213, ntag215 or ntag216")
+		cmd.Flags().BoolVar(&nfcLock, "nfc-lock", false, "Make the NFC tag read-only (static lock bytes and capability container)")
+	}
+}
+
+// checkNFCFlags validates the NFC flags before anything is generated.
+func checkNFCFlags(output string) error {
+	if !nfcOutput {
+		return nil
+	}
+	if output == stdoutPath {
+		return fmt.Errorf("validation error: --nfc writes files next to the label and cannot be used with -o -")
+	}
+	if _, err := nfc.ParseTagType(nfcTag); err != nil {
+		return fmt.Errorf("validation error: %w", err)
+	}
+	return nil
+}
+
+// applyNFCFlag sets the NFC flag in the label's setup URI when NFC files are
+// requested, so that the accessory advertises NFC pairing.
+func applyNFCFlag(label *generator.Label) {
+	if !nfcOutput {
+		return
+	}
+	label.URI = homekit.GenHomeKitSetupURIWithFlags(label.Category, homekit.FlagIP|homekit.FlagNFC, label.SetupCode, label.SetupID)
+}
+
+// writeNFCFiles writes the NFC tag files for label next to the label output
+// and returns their paths: <name>.ndef, <name>-<tag>.bin and <name>.nfc.
+func writeNFCFiles(label generator.Label, output string) ([]string, error) {
+	if !nfcOutput {
+		return nil, nil
+	}
+	tagType, err := nfc.ParseTagType(nfcTag)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := nfc.URIRecord(label.URI)
+	if err != nil {
+		return nil, err
+	}
+	tag, err := nfc.NewTag(tagType, nfc.RandomUID(), msg, nfcLock)
+	if err != nil {
+		return nil, err
+	}
+
+	base := strings.TrimSuffix(output, filepath.Ext(output))
+	files := []struct {
+		path string
+		data []byte
+	}{
+		{base + ".ndef", msg},
+		{base + "-" + string(tagType) + ".bin", tag.Memory},
+		{base + ".nfc", []byte(tag.FlipperFile())},
+	}
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		if err := os.WriteFile(f.path, f.data, 0644); err != nil {
+			return nil, fmt.Errorf("error writing NFC file: %w", err)
+		}
+		paths = append(paths, f.path)
+	}
+	return paths, nil
+}
+
+// printNFCFiles prints the written NFC files in table mode.
+func printNFCFiles(paths []string) {
+	for _, path := range paths {
+		fmt.Fprintf(diagOut(), "✅ NFC-bestand opgeslagen als: %s\n", path)
+	}
+}
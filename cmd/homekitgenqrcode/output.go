@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/lordbasex/HomeKitGenQRCode/internal/espprov"
+	"github.com/lordbasex/HomeKitGenQRCode/internal/generator"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Supported values of the --output-format flag
+const (
+	outputTable = "table" // Human-readable output (default)
+	outputJSON  = "json"  // Indented JSON on stdout
+	outputYAML  = "yaml"  // YAML on stdout
+)
+
+// outputFormat is the value of the global --output-format flag
+var outputFormat string
+
+// labelRecord is the machine-readable result of a label command.
+type labelRecord struct {
+	SetupCode    string           `json:"setupCode" yaml:"setupCode"`
+	SetupID      string           `json:"setupId" yaml:"setupId"`
+	MAC          string           `json:"mac" yaml:"mac"`
+	URI          string           `json:"uri" yaml:"uri"`
+	DeviceCode   string           `json:"deviceCode" yaml:"deviceCode"`
+	Serial       string           `json:"serial" yaml:"serial"`
+	CSN          string           `json:"csn" yaml:"csn"`
+	Category     int              `json:"category" yaml:"category"`
+	CategoryName string           `json:"categoryName" yaml:"categoryName"`
+	Output       string           `json:"output" yaml:"output"`
+	NFC          []string         `json:"nfc,omitempty" yaml:"nfc,omitempty"`
+	HomeSpan     []string         `json:"homespan,omitempty" yaml:"homespan,omitempty"`
+	Bitmaps      []string         `json:"bitmaps,omitempty" yaml:"bitmaps,omitempty"`
+	ESPProv      *espprov.Payload `json:"espProv,omitempty" yaml:"espProv,omitempty"`
+}
+
+// newLabelRecord builds the record for a generated label.
+func newLabelRecord(label generator.Label, output string) labelRecord {
+	return labelRecord{
+		SetupCode:    label.SetupCode,
+		SetupID:      label.SetupID,
+		MAC:          label.MAC,
+		URI:          label.URI,
+		DeviceCode:   label.DeviceCode,
+		Serial:       label.Serial,
+		CSN:          label.CSN,
+		Category:     label.Category,
+		CategoryName: label.CategoryName(),
+		Output:       output,
+	}
+}
+
+// addOutputFormatFlag registers the global --output-format flag.
+func addOutputFormatFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&outputFormat, "output-format", outputTable, "Output format: table, json or yaml (json and yaml write diagnostics to stderr)")
+	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := applyConfig(cmd); err != nil {
+			return err
+		}
+		switch outputFormat {
+		case outputTable, outputJSON, outputYAML:
+			return nil
+		}
+		return fmt.Errorf("unknown output format %q. Expected table, json or yaml", outputFormat)
+	}
+}
+
+// structuredOutput reports whether stdout is reserved for JSON or YAML.
+func structuredOutput() bool {
+	return outputFormat == outputJSON || outputFormat == outputYAML
+}
+
+// diagOut returns the writer for human-readable progress messages: stdout in
+// table mode, stderr when stdout carries JSON, YAML, NDJSON or image data.
+func diagOut() io.Writer {
+	if structuredOutput() || stdoutReserved {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// writeStructured writes v to stdout as JSON or YAML, depending on --output-format.
+func writeStructured(v interface{}) error {
+	if outputFormat == outputYAML {
+		enc := yaml.NewEncoder(os.Stdout)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return fmt.Errorf("error encoding YAML: %w", err)
+		}
+		return enc.Close()
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("error encoding JSON: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lordbasex/HomeKitGenQRCode/internal/hap"
+	"github.com/lordbasex/HomeKitGenQRCode/pkg/homekit"
+
+	"github.com/spf13/cobra"
+)
+
+// Variables for pairtest command flags
+var (
+	pairSetupCode string        // Setup code to pair with
+	pairLabel     string        // Label image to read the setup code from
+	pairSalt      string        // Provisioned SRP salt (hex)
+	pairVerifier  string        // Provisioned SRP verifier (hex)
+	pairAccessory string        // host:port of an accessory to pair with
+	pairFull      bool          // Complete M5-M6 with a remote accessory
+	pairTimeout   time.Duration // Network timeout per message
+)
+
+// pairtestCmd runs HAP pair-setup to check a setup code end to end
+var pairtestCmd = &cobra.Command{
+	Use:   "pairtest",
+	Short: "Run a HAP pair-setup to check that a setup code pairs",
+	Long: `Run the HAP pair-setup exchange (SRP-6a with the 3072-bit group and SHA-512,
+messages M1-M6 in TLV8) to prove that a setup code actually pairs before the
+labels are printed.
+
+By default both sides run in-process: a simulated accessory is provisioned
+with the salt and SRP verifier of the setup code, and a simulated controller
+pairs with it using the code. The accessory is then checked to reject a wrong
+code. With --salt and --verifier, the accessory uses the values provisioned in
+the firmware instead, proving that they match the code on the label.
+
+With --accessory, the controller pairs with an unpaired accessory on the local
+network. The exchange stops after M4, once the accessory has proved it knows
+the setup code, so it is left unpaired; --full also exchanges the long-term
+keys (M5-M6), which leaves the accessory paired with this throwaway controller.
+Failed attempts count towards the accessory's limit of 100.
+
+Examples:
+  homekitgenqrcode pairtest -p 613-80-755
+  homekitgenqrcode pairtest --label example.png
+  homekitgenqrcode pairtest -p 613-80-755 --salt <hex> --verifier <hex>
+  homekitgenqrcode pairtest --label example.png --accessory 192.168.1.50:51826`,
+	RunE: runPairTest,
+}
+
+func init() {
+	pairtestCmd.Flags().StringVarP(&pairSetupCode, "setup-code", "p", "", "Setup code, XXX-XX-XXX or 8 digits (default: random)")
+	pairtestCmd.Flags().StringVar(&pairLabel, "label", "", "Read the setup code from a label image")
+	pairtestCmd.Flags().StringVar(&pairSalt, "salt", "", "SRP salt provisioned in the accessory, 16 bytes hex (requires --verifier)")
+	pairtestCmd.Flags().StringVar(&pairVerifier, "verifier", "", "SRP verifier provisioned in the accessory, hex (requires --salt)")
+	pairtestCmd.Flags().StringVar(&pairAccessory, "accessory", "", "Pair with the accessory at host:port instead of a simulated one")
+	pairtestCmd.Flags().BoolVar(&pairFull, "full", false, "With --accessory, also exchange long-term keys (M5-M6), leaving the accessory paired")
+	pairtestCmd.Flags().DurationVar(&pairTimeout, "timeout", 10*time.Second, "Network timeout per message with --accessory")
+	pairtestCmd.MarkFlagsMutuallyExclusive("setup-code", "label")
+	pairtestCmd.MarkFlagsRequiredTogether("salt", "verifier")
+	pairtestCmd.MarkFlagsMutuallyExclusive("accessory", "salt")
+
+	rootCmd.AddCommand(pairtestCmd)
+}
+
+// pairTestRecord is the machine-readable result of the pairtest command.
+type pairTestRecord struct {
+	SetupCode         string   `json:"setupCode" yaml:"setupCode"`
+	Accessory         string   `json:"accessory" yaml:"accessory"`
+	Salt              string   `json:"salt" yaml:"salt"`
+	Verifier          string   `json:"verifier,omitempty" yaml:"verifier,omitempty"`
+	Paired            bool     `json:"paired" yaml:"paired"`
+	AccessoryID       string   `json:"accessoryId,omitempty" yaml:"accessoryId,omitempty"`
+	WrongCodeRejected *bool    `json:"wrongCodeRejected,omitempty" yaml:"wrongCodeRejected,omitempty"`
+	Steps             []string `json:"steps" yaml:"steps"`
+}
+
+// runPairTest executes the pairtest command
+func runPairTest(cmd *cobra.Command, args []string) error {
+	code, err := pairTestSetupCode()
+	if err != nil {
+		return err
+	}
+	if !homekit.IsValidSetupCode(code) {
+		fmt.Fprintf(os.Stderr, "⚠️  Warning: setup code %s is too simple and will be rejected by iOS\n", code)
+	}
+
+	record := pairTestRecord{SetupCode: code, Accessory: "simulated", Steps: []string{}}
+	out := diagOut()
+	opts := hap.PairSetupOptions{
+		StopAfterM4: pairAccessory != "" && !pairFull,
+		Trace: func(state int, desc string) {
+			arrow := "→"
+			if state%2 == 0 {
+				arrow = "←"
+			}
+			step := fmt.Sprintf("M%d %s %s", state, arrow, desc)
+			record.Steps = append(record.Steps, step)
+			if !structuredOutput() {
+				fmt.Fprintf(out, "  %s\n", step)
+			}
+		},
+	}
+	controller := hap.NewController(newPairingID())
+
+	var result *hap.PairSetupResult
+	if pairAccessory != "" {
+		record.Accessory = pairAccessory
+		if !structuredOutput() {
+			fmt.Fprintf(out, "Pair-setup with %s using %s:\n", pairAccessory, code)
+		}
+		conn, err := hap.Dial(pairAccessory, pairTimeout)
+		if err != nil {
+			return fmt.Errorf("error connecting to accessory: %w", err)
+		}
+		defer conn.Close()
+		result, err = controller.PairSetup(code, conn.PairSetup, opts)
+		if err != nil {
+			return fmt.Errorf("pair-setup failed: %w", err)
+		}
+	} else {
+		salt, verifier, err := pairTestVerifier(code)
+		if err != nil {
+			return fmt.Errorf("validation error: %w", err)
+		}
+		record.Verifier = hex.EncodeToString(verifier)
+		if !structuredOutput() {
+			fmt.Fprintf(out, "Pair-setup with a simulated accessory using %s:\n", code)
+		}
+		accessory := hap.NewAccessory(homekit.FormatMAC(homekit.GenerateMAC()), salt, verifier)
+		result, err = controller.PairSetup(code, accessory.Transport(), opts)
+		if err != nil {
+			if pairSalt != "" {
+				return fmt.Errorf("pair-setup failed: the salt and verifier do not match setup code %s: %w", code, err)
+			}
+			return fmt.Errorf("pair-setup failed: %w", err)
+		}
+
+		// A second accessory with the same verifier must refuse a wrong code.
+		rejected := false
+		_, err = hap.NewController(newPairingID()).PairSetup(wrongSetupCode(code), hap.NewAccessory(accessory.PairingID, salt, verifier).Transport(), hap.PairSetupOptions{StopAfterM4: true})
+		var pairErr *hap.PairingError
+		if errors.As(err, &pairErr) && pairErr.Code == hap.ErrorAuthentication {
+			rejected = true
+		}
+		record.WrongCodeRejected = &rejected
+		if !rejected {
+			return fmt.Errorf("pair-setup check failed: the accessory accepted a wrong setup code")
+		}
+	}
+	record.Salt = hex.EncodeToString(result.Salt)
+	record.Paired = result.Paired
+	record.AccessoryID = result.AccessoryID
+
+	if structuredOutput() {
+		return writeStructured(record)
+	}
+	fmt.Fprintln(out, strings.Repeat("=", 50))
+	fmt.Fprintf(out, "  Setup Code:    %s\n", record.SetupCode)
+	fmt.Fprintf(out, "  Accessory:     %s\n", record.Accessory)
+	fmt.Fprintf(out, "  Salt:          %s\n", record.Salt)
+	if record.Verifier != "" {
+		fmt.Fprintf(out, "  Verifier:      %s... (%d bytes)\n", record.Verifier[:32], len(record.Verifier)/2)
+	}
+	if record.AccessoryID != "" {
+		fmt.Fprintf(out, "  Accessory ID:  %s\n", record.AccessoryID)
+	}
+	if record.WrongCodeRejected != nil {
+		fmt.Fprintf(out, "  Wrong Code:    rejected\n")
+	}
+	fmt.Fprintln(out, strings.Repeat("=", 50))
+	if result.Paired {
+		fmt.Fprintf(out, "✅ Pair-setup completed (M1-M6) with setup code %s\n", code)
+	} else {
+		fmt.Fprintf(out, "✅ Setup code %s verified by the accessory (M1-M4); the accessory was left unpaired\n", code)
+	}
+	return nil
+}
+
+// pairTestSetupCode returns the setup code from --setup-code or --label, or a
+// random one.
+func pairTestSetupCode() (string, error) {
+	switch {
+	case pairLabel != "":
+		scanned, err := readLabelImage(pairLabel)
+		if err != nil {
+			return "", err
+		}
+		return scanned.Payload.SetupCode, nil
+	case pairSetupCode != "":
+		code, err := homekit.NormalizeSetupCode(pairSetupCode)
+		if err != nil {
+			return "", fmt.Errorf("validation error: %w", err)
+		}
+		return code, nil
+	}
+	return homekit.GenerateHomeKitSetupCode(), nil
+}
+
+// pairTestVerifier returns the salt and verifier for the simulated accessory:
+// the provisioned values from --salt and --verifier, or new ones computed from
+// the setup code.
+func pairTestVerifier(code string) (salt, verifier []byte, err error) {
+	if pairSalt == "" {
+		salt = hap.NewSalt()
+		return salt, hap.SRPVerifier(code, salt), nil
+	}
+	if salt, err = hex.DecodeString(strings.TrimSpace(pairSalt)); err != nil || len(salt) != hap.SaltSize {
+		return nil, nil, fmt.Errorf("--salt must be %d bytes of hexadecimal", hap.SaltSize)
+	}
+	if verifier, err = hex.DecodeString(strings.TrimSpace(pairVerifier)); err != nil || len(verifier) == 0 {
+		return nil, nil, errors.New("--verifier must be hexadecimal")
+	}
+	return salt, verifier, nil
+}
+
+// wrongSetupCode returns a setup code that differs from code in its last digit.
+func wrongSetupCode(code string) string {
+	last := code[len(code)-1]
+	return code[:len(code)-1] + string('0'+(last-'0'+1)%10)
+}
+
+// newPairingID returns a random controller pairing identifier in UUID form.
+func newPairingID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = b[6]&0x0F | 0x40
+	b[8] = b[8]&0x3F | 0x80
+	return strings.ToUpper(fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]))
+}
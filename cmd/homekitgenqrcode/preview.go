@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/lordbasex/HomeKitGenQRCode/internal/generator"
+	"github.com/lordbasex/HomeKitGenQRCode/pkg/homekit"
+
+	"github.com/spf13/cobra"
+)
+
+// Terminal preview flags shared by generate, code and show
+var (
+	previewQR        bool // Print the setup QR in the terminal (generate, code)
+	previewInvert    bool // Invert colors for light-background terminals
+	previewQuietZone int  // Quiet zone width in modules
+)
+
+// Variables for show command flags
+var (
+	showCategory int    // HomeKit device category ID
+	showPassword string // Setup code
+	showSetupID  string // Setup ID
+	showURI      string // Existing X-HM:// setup URI
+)
+
+// showCmd renders the setup QR code in the terminal
+var showCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the setup QR code in the terminal",
+	Long: `Render the HomeKit setup QR code in the terminal with Unicode half-block
+characters, followed by the formatted setup code. Useful over SSH, where a
+PNG cannot be opened: scan the terminal with the Home app to pair.
+
+By default light modules are drawn as blocks, for dark-background terminals.
+Use --invert on terminals with a light background.
+
+Examples:
+  homekitgenqrcode show -c 5 -p 613-80-755 -s ABCD
+  homekitgenqrcode show --uri X-HM://0053158R7ABCD --invert`,
+	RunE: runShow,
+}
+
+func init() {
+	showCmd.Flags().IntVarP(&showCategory, "category", "c", 0, "HomeKit category ID")
+	showCmd.Flags().StringVarP(&showPassword, "password", "p", "", "Setup password in format XXX-XX-XXX")
+	showCmd.Flags().StringVarP(&showSetupID, "setup-id", "s", "", "Setup ID: 4 alphanumeric characters (0-9, A-Z)")
+	showCmd.Flags().StringVar(&showURI, "uri", "", "Existing X-HM:// setup URI (instead of category, password and setup ID)")
+	showCmd.MarkFlagsMutuallyExclusive("uri", "password")
+	showCmd.MarkFlagsMutuallyExclusive("uri", "setup-id")
+	addPreviewStyleFlags(showCmd)
+
+	generateCmd.Flags().BoolVar(&previewQR, "preview", false, "Also print the setup QR code in the terminal")
+	codeCmd.Flags().BoolVar(&previewQR, "preview", false, "Also print the setup QR code in the terminal")
+	addPreviewStyleFlags(generateCmd)
+	addPreviewStyleFlags(codeCmd)
+
+	rootCmd.AddCommand(showCmd)
+}
+
+// addPreviewStyleFlags registers the terminal rendering flags on a command.
+func addPreviewStyleFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&previewInvert, "invert", false, "Invert terminal QR colors (for light-background terminals)")
+	cmd.Flags().IntVar(&previewQuietZone, "quiet-zone", generator.DefaultQuietZone, "Terminal QR quiet zone in modules")
+}
+
+// renderPreview renders the terminal QR with the setup code beneath it.
+func renderPreview(uri, setupCode string) (string, error) {
+	qr, err := generator.RenderTerminalQR(uri, generator.TerminalOptions{
+		QuietZone: previewQuietZone,
+		Invert:    previewInvert,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	width := len([]rune(strings.SplitN(qr, "\n", 2)[0]))
+	var sb strings.Builder
+	sb.WriteString(qr)
+	sb.WriteString(centerText("Setup Code: "+setupCode, width) + "\n")
+	sb.WriteString(centerText(uri, width) + "\n")
+	return sb.String(), nil
+}
+
+// printPreview writes the terminal QR preview of a label to w.
+func printPreview(w io.Writer, label generator.Label) error {
+	preview, err := renderPreview(label.URI, label.SetupCode)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w)
+	fmt.Fprint(w, preview)
+	return nil
+}
+
+// centerText pads text with leading spaces to center it in width columns.
+func centerText(text string, width int) string {
+	if pad := (width - len([]rune(text))) / 2; pad > 0 {
+		return strings.Repeat(" ", pad) + text
+	}
+	return text
+}
+
+// runShow executes the show command
+func runShow(cmd *cobra.Command, args []string) error {
+	var uri, setupCode string
+	var payload homekit.SetupPayload
+
+	if showURI != "" {
+		decoded, err := homekit.DecodeSetupURI(showURI)
+		if err != nil {
+			return err
+		}
+		payload = decoded
+		uri = strings.ToUpper(strings.TrimSpace(showURI))
+		setupCode = decoded.SetupCode
+	} else {
+		if err := requireFlags(cmd, "category", "password", "setup-id"); err != nil {
+			return err
+		}
+		if err := homekit.ValidateCategory(showCategory); err != nil {
+			return fmt.Errorf("validation error: %w", err)
+		}
+		code, err := homekit.NormalizeSetupCode(showPassword)
+		if err != nil {
+			return fmt.Errorf("validation error: %w", err)
+		}
+		id, err := homekit.NormalizeSetupID(showSetupID)
+		if err != nil {
+			return fmt.Errorf("validation error: %w", err)
+		}
+		uri = homekit.GenHomeKitSetupURI(showCategory, code, id)
+		setupCode = code
+		payload = homekit.SetupPayload{Category: showCategory, Flags: homekit.FlagIP, SetupCode: code, SetupID: id}
+	}
+
+	preview, err := renderPreview(uri, setupCode)
+	if err != nil {
+		return err
+	}
+
+	if structuredOutput() {
+		return writeStructured(struct {
+			URI          string `json:"uri" yaml:"uri"`
+			SetupCode    string `json:"setupCode" yaml:"setupCode"`
+			SetupID      string `json:"setupId,omitempty" yaml:"setupId,omitempty"`
+			Category     int    `json:"category" yaml:"category"`
+			CategoryName string `json:"categoryName" yaml:"categoryName"`
+			QR           string `json:"qr" yaml:"qr"`
+		}{uri, setupCode, payload.SetupID, payload.Category, homekit.CategoryName(payload.Category), preview})
+	}
+
+	fmt.Print(preview)
+	return nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/lordbasex/HomeKitGenQRCode/internal/server"
+
+	"github.com/spf13/cobra"
+)
+
+// Variables for serve command flags
+var (
+	serveAddr            string        // Listen address
+	serveToken           string        // Bearer token (optional)
+	serveTLSCert         string        // TLS certificate file
+	serveTLSKey          string        // TLS private key file
+	serveMaxBody         int64         // Request body size limit in bytes
+	serveShutdownTimeout time.Duration // Grace period for in-flight requests
+)
+
+// serveCmd runs the HTTP API server
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run the HTTP API server",
+	Long: `Run an HTTP API exposing label generation, random codes, validation,
+category listing and setup URI decoding as JSON endpoints.
+
+Endpoints:
+  POST /api/v1/label        Generate a label (PNG, SVG, PDF or JSON)
+  GET  /api/v1/random       Random setup code, setup ID and MAC (?category=5)
+  POST /api/v1/validate     Validate setup parameters
+  GET  /api/v1/categories   List categories
+  POST /api/v1/decode       Decode an X-HM:// setup URI
+  GET  /api/v1/openapi.json OpenAPI document
+
+The bearer token can also be set with the HOMEKITGENQRCODE_TOKEN environment
+variable. The server shuts down gracefully on SIGINT/SIGTERM.
+
+Examples:
+  # Listen on localhost:8080
+  homekitgenqrcode serve
+
+  # Listen on all interfaces with TLS and a bearer token
+  homekitgenqrcode serve --addr :8443 --tls-cert cert.pem --tls-key key.pem --token s3cret
+
+  # Request a PDF label
+  curl -X POST localhost:8080/api/v1/label -d '{"category":5,"password":"613-80-755","setupId":"ABCD","mac":"AABBCCDDEEFF","format":"pdf"}' -o label.pdf`,
+	RunE: runServe,
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveAddr, "addr", "127.0.0.1:8080", "Listen address")
+	serveCmd.Flags().StringVar(&serveToken, "token", "", "Require this bearer token on API requests (default: $HOMEKITGENQRCODE_TOKEN)")
+	serveCmd.Flags().StringVar(&serveTLSCert, "tls-cert", "", "TLS certificate file (enables HTTPS together with --tls-key)")
+	serveCmd.Flags().StringVar(&serveTLSKey, "tls-key", "", "TLS private key file")
+	serveCmd.Flags().Int64Var(&serveMaxBody, "max-body", server.DefaultMaxBodyBytes, "Maximum request body size in bytes")
+	serveCmd.Flags().DurationVar(&serveShutdownTimeout, "shutdown-timeout", 10*time.Second, "Time allowed for in-flight requests on shutdown")
+	serveCmd.MarkFlagsRequiredTogether("tls-cert", "tls-key")
+
+	rootCmd.AddCommand(serveCmd)
+}
+
+// serverRecord is the machine-readable startup information of serve and web.
+type serverRecord struct {
+	URL     string `json:"url" yaml:"url"`
+	OpenAPI string `json:"openapi" yaml:"openapi"`
+	TLS     bool   `json:"tls" yaml:"tls"`
+	Auth    bool   `json:"auth" yaml:"auth"`
+	WASM    *bool  `json:"wasm,omitempty" yaml:"wasm,omitempty"`
+}
+
+// runServe executes the serve command
+func runServe(cmd *cobra.Command, args []string) error {
+	token := serveToken
+	if token == "" {
+		token = os.Getenv("HOMEKITGENQRCODE_TOKEN")
+	}
+	if serveMaxBody <= 0 {
+		return fmt.Errorf("--max-body must be greater than 0")
+	}
+
+	srv := server.New(server.Config{
+		Addr:            serveAddr,
+		Token:           token,
+		MaxBodyBytes:    serveMaxBody,
+		TLSCertFile:     serveTLSCert,
+		TLSKeyFile:      serveTLSKey,
+		ShutdownTimeout: serveShutdownTimeout,
+		Version:         version,
+		Logger:          log.New(os.Stderr, "", log.LstdFlags),
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	scheme := "http"
+	if serveTLSCert != "" {
+		scheme = "https"
+	}
+	if structuredOutput() {
+		if err := writeStructured(serverRecord{
+			URL:     fmt.Sprintf("%s://%s", scheme, serveAddr),
+			OpenAPI: fmt.Sprintf("%s://%s/api/v1/openapi.json", scheme, serveAddr),
+			TLS:     scheme == "https",
+			Auth:    token != "",
+		}); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(diagOut(), "🌐 Serving HomeKit API on %s://%s\n", scheme, serveAddr)
+	if token != "" {
+		fmt.Fprintln(diagOut(), "🔒 Bearer token authentication enabled")
+	}
+
+	if err := srv.ListenAndServe(ctx); err != nil {
+		return err
+	}
+	fmt.Fprintln(diagOut(), "👋 Server stopped")
+	return nil
+}
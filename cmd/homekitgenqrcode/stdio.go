@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/lordbasex/HomeKitGenQRCode/internal/generator"
+	"github.com/lordbasex/HomeKitGenQRCode/pkg/homekit"
+
+	"github.com/spf13/cobra"
+)
+
+// stdoutPath is the output path that streams the encoded image to stdout
+const stdoutPath = "-"
+
+// maxStdinLine is the longest accepted JSON label spec in --stdin mode
+const maxStdinLine = 1 << 20
+
+// stdoutReserved is set when stdout carries image data or NDJSON, so that
+// progress messages are sent to stderr (see diagOut)
+var stdoutReserved bool
+
+// labelSpec is one JSON line read in --stdin mode.
+// Missing setup code, setup ID and MAC address are generated; a missing
+// category defaults to --category. Relative output paths use --output-dir.
+type labelSpec struct {
+	Category int    `json:"category"`
+	Password string `json:"password"`
+	SetupID  string `json:"setupId"`
+	MAC      string `json:"mac"`
+	Output   string `json:"output"`
+}
+
+// stdinResult is one NDJSON line written in --stdin mode. When the spec has no
+// output file, the PNG is returned inline as a base64 data URL.
+type stdinResult struct {
+	Line int `json:"line"`
+	*labelRecord
+	ImageBase64 string `json:"imageBase64,omitempty"`
+	Error       string `json:"error,omitempty"`
+	Field       string `json:"field,omitempty"`
+	Rule        string `json:"rule,omitempty"`
+}
+
+// requireFlags returns cobra's "required flag(s)" error for flags that were not set.
+// It is used instead of MarkFlagRequired by commands where --stdin replaces the flags.
+func requireFlags(cmd *cobra.Command, names ...string) error {
+	var missing []string
+	for _, name := range names {
+		if !cmd.Flags().Changed(name) {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf(`required flag(s) "%s" not set`, strings.Join(missing, `", "`))
+	}
+	return nil
+}
+
+// checkStdoutOutput rejects combining -o - with structured output, which would
+// both write to stdout, and reserves stdout for the image otherwise.
+func checkStdoutOutput(output string) error {
+	if output != stdoutPath {
+		return nil
+	}
+	if structuredOutput() {
+		return fmt.Errorf("-o - writes the image to stdout and cannot be combined with --output-format %s", outputFormat)
+	}
+	stdoutReserved = true
+	return nil
+}
+
+// writeLabel saves the label to output, or streams the PNG to stdout if output is "-".
+func writeLabel(label generator.Label, output string, opts generator.LabelOptions) error {
+	if output != stdoutPath {
+		if err := ensureOutputDirectory(output); err != nil {
+			return fmt.Errorf("error creating output directory: %w", err)
+		}
+		return generator.SaveLabel(label, output, opts)
+	}
+
+	img, err := generator.RenderLabel(label, opts)
+	if err != nil {
+		return err
+	}
+	// Encode fully before writing so a failure never leaves a truncated image on stdout
+	var buf bytes.Buffer
+	if err := generator.EncodeImage(&buf, img, generator.FormatPNG); err != nil {
+		return fmt.Errorf("error encoding PNG: %w", err)
+	}
+	_, err = os.Stdout.Write(buf.Bytes())
+	return err
+}
+
+// outputName returns a human-readable name for an output path.
+func outputName(output string) string {
+	if output == stdoutPath {
+		return "<stdout>"
+	}
+	return output
+}
+
+// runStdin reads one JSON label spec per line from r and writes one NDJSON
+// result per spec to w. Blank lines are skipped. Every line is processed;
+// an error is returned at the end if any of them failed.
+func runStdin(r io.Reader, w io.Writer, defaultCategory int, opts generator.LabelOptions) error {
+	stdoutReserved = true
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStdinLine)
+	enc := json.NewEncoder(w)
+
+	lineNo, failed := 0, 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		result := processLabelSpec(line, defaultCategory, opts)
+		result.Line = lineNo
+		if result.Error != "" {
+			failed++
+			fmt.Fprintf(os.Stderr, "❌ Line %d: %s\n", lineNo, result.Error)
+		}
+		if err := enc.Encode(result); err != nil {
+			return fmt.Errorf("error writing result: %w", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading stdin: %w", err)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of the label specs failed", failed)
+	}
+	return nil
+}
+
+// fileOutputFlags returns the set flags that write files next to the label,
+// which needs an output file.
+func fileOutputFlags() []string {
+	var flags []string
+	if nfcOutput {
+		flags = append(flags, "--nfc")
+	}
+	if homespanOutput {
+		flags = append(flags, "--homespan")
+	}
+	if len(bitmapFormats) > 0 {
+		flags = append(flags, "--bitmap")
+	}
+	return flags
+}
+
+// processLabelSpec parses, validates and renders one label spec.
+func processLabelSpec(line string, defaultCategory int, opts generator.LabelOptions) stdinResult {
+	fail := func(err error) stdinResult {
+		result := stdinResult{Error: err.Error()}
+		var verr *homekit.ValidationError
+		if errors.As(err, &verr) {
+			result.Field = verr.Field
+			result.Rule = verr.Rule
+		}
+		return result
+	}
+
+	var spec labelSpec
+	dec := json.NewDecoder(strings.NewReader(line))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&spec); err != nil {
+		return fail(fmt.Errorf("invalid JSON: %w", err))
+	}
+
+	// Fill in missing values like the code command does
+	userMAC := spec.MAC != ""
+	userCode := spec.Password != ""
+	if spec.Category == 0 {
+		spec.Category = defaultCategory
+	}
+	if spec.Password == "" {
+		spec.Password = homekit.GenerateHomeKitSetupCode()
+	}
+	if spec.SetupID == "" {
+		spec.SetupID = homekit.GenerateSetupID()
+	}
+	if spec.MAC == "" {
+		spec.MAC = homekit.GenerateMAC()
+	}
+
+	info, err := homekit.ParseSetupInfo(spec.Category, spec.Password, spec.SetupID, spec.MAC)
+	if err != nil {
+		return fail(err)
+	}
+
+	output := resolveOutputPath(strings.TrimSpace(spec.Output))
+	if output == stdoutPath {
+		return fail(errors.New("output \"-\" is not available in --stdin mode; omit output to get the image inline"))
+	}
+	if output != "" {
+		if err := validateOutputPath(output); err != nil {
+			return fail(err)
+		}
+	} else if flags := fileOutputFlags(); len(flags) > 0 {
+		return fail(fmt.Errorf("%s write files next to the label; give the spec an output", strings.Join(flags, ", ")))
+	}
+	warnMAC(info.MAC, userMAC)
+	if userCode {
+		warnSetupCode(info.SetupCode)
+	}
+
+	label := generator.NewLabel(info)
+	applyNFCFlag(&label)
+	espProv, err := applyESPProv(&label)
+	if err != nil {
+		return fail(err)
+	}
+	record := newLabelRecord(label, output)
+	record.ESPProv = espProv
+	result := stdinResult{labelRecord: &record}
+	if previewQR {
+		if err := printPreview(diagOut(), label); err != nil {
+			return fail(err)
+		}
+	}
+
+	if output != "" {
+		if err := writeLabel(label, output, opts); err != nil {
+			return fail(fmt.Errorf("error generating label: %w", err))
+		}
+		if record.NFC, err = writeNFCFiles(label, output); err != nil {
+			return fail(err)
+		}
+		if record.HomeSpan, err = writeHomeSpanFiles(label, output); err != nil {
+			return fail(err)
+		}
+		if record.Bitmaps, err = writeBitmapFiles(label, output, opts); err != nil {
+			return fail(err)
+		}
+		return result
+	}
+
+	img, err := generator.RenderLabel(label, opts)
+	if err != nil {
+		return fail(fmt.Errorf("error generating label: %w", err))
+	}
+	var buf bytes.Buffer
+	if err := generator.EncodeImage(&buf, img, generator.FormatPNG); err != nil {
+		return fail(fmt.Errorf("error encoding PNG: %w", err))
+	}
+	result.ImageBase64 = "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+	return result
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"os"
+	"strings"
+
+	"github.com/lordbasex/HomeKitGenQRCode/internal/generator"
+	"github.com/lordbasex/HomeKitGenQRCode/pkg/homekit"
+
+	"github.com/spf13/cobra"
+)
+
+// Variables for txt command flags
+var (
+	txtCategory     int    // HomeKit category ID
+	txtSetupID      string // Setup ID
+	txtMAC          string // MAC address (device ID)
+	txtLabel        string // Label image to read the values from
+	txtModel        string // Model name (md)
+	txtName         string // Service instance name
+	txtPort         int    // HAP TCP port
+	txtConfigNumber int    // Configuration number (c#)
+	txtFeatureFlags int    // Feature flags (ff)
+	txtPaired       bool   // Advertise as paired
+	txtFormat       string // Print only one representation: json, dns-sd or c
+)
+
+// txtCmd builds the _hap._tcp Bonjour TXT record for an accessory
+var txtCmd = &cobra.Command{
+	Use:   "txt",
+	Short: "Generate the _hap._tcp Bonjour TXT record",
+	Long: `Build the Bonjour TXT record a HAP accessory advertises for its _hap._tcp
+service from the values on its label: the category becomes ci, the MAC address
+the device ID (id), and the setup ID with the device ID the setup hash (sh)
+that lets the Home app find the accessory whose code was scanned.
+
+The values can be given with -c, -s and -m, or read from a label image with
+--label, so the advertised record provably matches the printed label.
+
+The record is printed as key/value pairs, a dns-sd -R command line and a C
+initializer. Use --format to print only one of them.
+
+Examples:
+  homekitgenqrcode txt -c 5 -s ABCD -m 30:AE:A4:01:02:03 --model "Bridge1,1"
+  homekitgenqrcode txt --label example.png --format dns-sd
+  homekitgenqrcode txt --label example.png --format c > hap_txt.h`,
+	RunE: runTXT,
+}
+
+func init() {
+	txtCmd.Flags().IntVarP(&txtCategory, "category", "c", 0, "HomeKit category ID")
+	txtCmd.Flags().StringVarP(&txtSetupID, "setup-id", "s", "", "Setup ID: 4 alphanumeric characters (0-9, A-Z)")
+	txtCmd.Flags().StringVarP(&txtMAC, "mac", "m", "", "MAC address, advertised as the device ID")
+	txtCmd.Flags().StringVar(&txtLabel, "label", "", "Read the category, setup ID and MAC from a label image")
+	txtCmd.Flags().StringVar(&txtModel, "model", "", "Model name (md) (default: the category name)")
+	txtCmd.Flags().StringVar(&txtName, "name", "", "Service instance name for dns-sd (default: the model name)")
+	txtCmd.Flags().IntVar(&txtPort, "port", 51826, "HAP TCP port for dns-sd")
+	txtCmd.Flags().IntVar(&txtConfigNumber, "config-number", 1, "Configuration number (c#)")
+	txtCmd.Flags().IntVar(&txtFeatureFlags, "feature-flags", 0, "Feature flags (ff): 1 for MFi hardware authentication, 2 for software authentication")
+	txtCmd.Flags().BoolVar(&txtPaired, "paired", false, "Advertise the accessory as paired (sf=0)")
+	txtCmd.Flags().StringVar(&txtFormat, "format", "", "Print only one representation: json, dns-sd or c")
+	txtCmd.MarkFlagsMutuallyExclusive("label", "category")
+	txtCmd.MarkFlagsMutuallyExclusive("label", "setup-id")
+	txtCmd.MarkFlagsMutuallyExclusive("label", "mac")
+
+	rootCmd.AddCommand(txtCmd)
+}
+
+// txtRecord is the machine-readable result of the txt command.
+type txtRecord struct {
+	Name   string            `json:"name" yaml:"name"`
+	Port   int               `json:"port" yaml:"port"`
+	TXT    map[string]string `json:"txt" yaml:"txt"`
+	DNSSD  string            `json:"dnsSd" yaml:"dnsSd"`
+	CArray string            `json:"c" yaml:"c"`
+}
+
+// runTXT executes the txt command
+func runTXT(cmd *cobra.Command, args []string) error {
+	switch txtFormat {
+	case "", "json", "dns-sd", "c":
+	default:
+		return fmt.Errorf("unknown format %q. Expected json, dns-sd or c", txtFormat)
+	}
+	if txtPort < 1 || txtPort > 65535 {
+		return fmt.Errorf("validation error: --port %d is out of range (1-65535)", txtPort)
+	}
+
+	info, err := labelSetupInfo(txtCategory, txtSetupID, txtMAC, txtLabel)
+	if err != nil {
+		return err
+	}
+	record, err := homekit.NewTXTRecord(info, homekit.TXTOptions{
+		Model:        txtModel,
+		ConfigNumber: txtConfigNumber,
+		FeatureFlags: txtFeatureFlags,
+		Paired:       txtPaired,
+	})
+	if err != nil {
+		return fmt.Errorf("validation error: %w", err)
+	}
+
+	name := strings.TrimSpace(txtName)
+	if name == "" {
+		name = record.Model
+	}
+	result := txtRecord{
+		Name:   name,
+		Port:   txtPort,
+		TXT:    map[string]string{},
+		DNSSD:  record.DNSSDCommand(name, txtPort),
+		CArray: record.CInitializer("hap_txt"),
+	}
+	for _, p := range record.Pairs() {
+		result.TXT[p.Key] = p.Value
+	}
+
+	switch {
+	case txtFormat == "json":
+		data, err := json.MarshalIndent(result.TXT, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error encoding JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	case txtFormat == "dns-sd":
+		fmt.Println(result.DNSSD)
+		return nil
+	case txtFormat == "c":
+		fmt.Print(result.CArray)
+		return nil
+	case structuredOutput():
+		return writeStructured(result)
+	}
+
+	fmt.Printf("_hap._tcp TXT Record: %s\n", name)
+	fmt.Println(strings.Repeat("=", 50))
+	for _, p := range record.Pairs() {
+		fmt.Printf("  %-3s %s\n", p.Key+":", p.Value)
+	}
+	fmt.Println(strings.Repeat("=", 50))
+	fmt.Println()
+	fmt.Println(result.DNSSD)
+	fmt.Println()
+	fmt.Print(result.CArray)
+	return nil
+}
+
+// labelSetupInfo returns the category, setup ID and MAC given with -c, -s and
+// -m, or read from the label image given with --label.
+func labelSetupInfo(category int, setupID, mac, labelPath string) (homekit.SetupInfo, error) {
+	if labelPath == "" {
+		if category == 0 || setupID == "" || mac == "" {
+			return homekit.SetupInfo{}, errors.New("validation error: --category, --setup-id and --mac are required without --label")
+		}
+		return homekit.SetupInfo{Category: category, SetupID: setupID, MAC: mac}, nil
+	}
+
+	scanned, err := readLabelImage(labelPath)
+	if err != nil {
+		return homekit.SetupInfo{}, err
+	}
+	if scanned.MAC == "" {
+		return homekit.SetupInfo{}, fmt.Errorf("no MAC barcode could be read from %s", labelPath)
+	}
+	return homekit.SetupInfo{Category: scanned.Payload.Category, SetupID: scanned.Payload.SetupID, MAC: scanned.MAC}, nil
+}
+
+// readLabelImage decodes the label image at path and reads its setup code.
+func readLabelImage(path string) (generator.ScannedLabel, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return generator.ScannedLabel{}, fmt.Errorf("error opening image: %w", err)
+	}
+	img, _, err := image.Decode(f)
+	f.Close()
+	if err != nil {
+		return generator.ScannedLabel{}, fmt.Errorf("error decoding image: %w", err)
+	}
+	return generator.ReadLabel(img)
+}
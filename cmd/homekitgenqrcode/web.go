@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"syscall"
+
+	"github.com/lordbasex/HomeKitGenQRCode/internal/server"
+	"github.com/lordbasex/HomeKitGenQRCode/internal/webui"
+
+	"github.com/spf13/cobra"
+)
+
+// Variables for web command flags
+var (
+	webAddr   string // Listen address
+	webOpen   bool   // Open the browser after starting
+	webNoWASM bool   // Do not serve the WASM module (force server-side rendering)
+)
+
+// webCmd serves the embedded web interface
+var webCmd = &cobra.Command{
+	Use:   "web",
+	Short: "Open the web interface on localhost",
+	Long: `Serve the web interface (index.html, wasm_exec.js and the WASM module)
+embedded in this binary. No Node.js or separate web server is needed.
+
+Labels are generated in the browser with WebAssembly. When the WASM module is
+unavailable (not embedded, or blocked by the browser) the page falls back to
+the server-side renderer, which is the same HTTP API as 'homekitgenqrcode serve'.
+
+Examples:
+  # Serve on http://127.0.0.1:8090 and open the browser
+  homekitgenqrcode web --open
+
+  # Always render labels on the server
+  homekitgenqrcode web --no-wasm`,
+	RunE: runWeb,
+}
+
+func init() {
+	webCmd.Flags().StringVar(&webAddr, "addr", "127.0.0.1:8090", "Listen address")
+	webCmd.Flags().BoolVar(&webOpen, "open", false, "Open the web interface in the default browser")
+	webCmd.Flags().BoolVar(&webNoWASM, "no-wasm", false, "Do not serve the WASM module; render labels on the server")
+
+	rootCmd.AddCommand(webCmd)
+}
+
+// runWeb executes the web command
+func runWeb(cmd *cobra.Command, args []string) error {
+	hasWASM := webui.HasWASM() && !webNoWASM
+
+	srv := server.New(server.Config{
+		Addr:    webAddr,
+		Version: version,
+		Logger:  log.New(os.Stderr, "", log.LstdFlags),
+	})
+
+	var static fs.FS = webui.FS()
+	if !hasWASM {
+		static = withoutFiles{static, webui.WASMFile}
+	}
+	srv.Handle("GET /", http.FileServerFS(static))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	url := "http://" + webAddr + "/"
+	if structuredOutput() {
+		if err := writeStructured(serverRecord{
+			URL:     url,
+			OpenAPI: url + "api/v1/openapi.json",
+			WASM:    &hasWASM,
+		}); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(diagOut(), "🌐 Web interface: %s\n", url)
+	if hasWASM {
+		fmt.Fprintln(diagOut(), "🧩 Rendering labels in the browser with WebAssembly")
+	} else {
+		fmt.Fprintln(diagOut(), "🖥️  Rendering labels on the server (WASM module not available)")
+	}
+
+	if webOpen {
+		if err := openBrowser(url); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Warning: could not open browser: %v\n", err)
+		}
+	}
+
+	if err := srv.ListenAndServe(ctx); err != nil {
+		return err
+	}
+	fmt.Fprintln(diagOut(), "👋 Server stopped")
+	return nil
+}
+
+// withoutFiles hides the named files of an fs.FS.
+type withoutFiles struct {
+	fs.FS
+	hidden string
+}
+
+func (f withoutFiles) Open(name string) (fs.File, error) {
+	if name == f.hidden {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return f.FS.Open(name)
+}
+
+// openBrowser opens url in the default browser.
+func openBrowser(url string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", url).Start()
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
+	default:
+		return exec.Command("xdg-open", url).Start()
+	}
+}
@@ -0,0 +1,220 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Endpoint describes one HTTP API operation. The OpenAPI document is built
+// from this table and the Go types referenced by it, so the documented schemas
+// always match what the server and the WASM build actually exchange.
+type Endpoint struct {
+	Method      string
+	Path        string
+	OperationID string
+	Summary     string
+	Request     interface{} // JSON request body type (nil for none)
+	Response    interface{} // JSON response body type
+	Binary      []string    // Additional non-JSON response content types
+	Query       []QueryParam
+}
+
+// QueryParam describes a query string parameter.
+type QueryParam struct {
+	Name        string
+	Type        string
+	Description string
+	Required    bool
+}
+
+// Endpoints lists every operation of the HTTP API.
+var Endpoints = []Endpoint{
+	{
+		Method: http.MethodPost, Path: "/api/v1/label", OperationID: "generateLabel",
+		Summary:  "Generate a label image (PNG, SVG, PDF or JSON with a base64 data URL)",
+		Request:  GenerateLabelRequest{},
+		Response: GenerateLabelResponse{},
+		Binary:   []string{"image/png", "image/svg+xml", "application/pdf"},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/random", OperationID: "generateRandomCode",
+		Summary:  "Generate a random setup code, setup ID and MAC address",
+		Response: RandomCodeResponse{},
+		Query:    []QueryParam{{Name: "category", Type: "integer", Description: "HomeKit category ID", Required: true}},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/validate", OperationID: "validateInputs",
+		Summary:  "Validate setup parameters against the HAP rules",
+		Request:  ValidationRequest{},
+		Response: ValidationResponse{},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/categories", OperationID: "listCategories",
+		Summary:  "List all HomeKit categories",
+		Response: []Category{},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/decode", OperationID: "decodeURI",
+		Summary:  "Decode an X-HM:// setup URI",
+		Request:  DecodeURIRequest{},
+		Response: DecodeURIResponse{},
+	},
+}
+
+// OpenAPI returns the OpenAPI 3.0 document for the HTTP API.
+// bearerAuth adds the bearer token security scheme to every operation.
+func OpenAPI(version string, bearerAuth bool) map[string]interface{} {
+	schemas := map[string]interface{}{}
+	paths := map[string]interface{}{}
+
+	errorRef := schemaRef(reflect.TypeOf(ErrorResponse{}), schemas)
+
+	for _, ep := range Endpoints {
+		op := map[string]interface{}{
+			"operationId": ep.OperationID,
+			"summary":     ep.Summary,
+		}
+
+		if ep.Request != nil {
+			op["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": schemaRef(reflect.TypeOf(ep.Request), schemas)},
+				},
+			}
+		}
+
+		if len(ep.Query) > 0 {
+			var params []interface{}
+			for _, q := range ep.Query {
+				params = append(params, map[string]interface{}{
+					"name":        q.Name,
+					"in":          "query",
+					"required":    q.Required,
+					"description": q.Description,
+					"schema":      map[string]interface{}{"type": q.Type},
+				})
+			}
+			op["parameters"] = params
+		}
+
+		content := map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": schemaRef(reflect.TypeOf(ep.Response), schemas)},
+		}
+		for _, ct := range ep.Binary {
+			content[ct] = map[string]interface{}{"schema": map[string]interface{}{"type": "string", "format": "binary"}}
+		}
+		errorContent := map[string]interface{}{"application/json": map[string]interface{}{"schema": errorRef}}
+		responses := map[string]interface{}{
+			"200": map[string]interface{}{"description": "Success", "content": content},
+			"400": map[string]interface{}{"description": "Invalid request", "content": errorContent},
+		}
+		if bearerAuth {
+			responses["401"] = map[string]interface{}{"description": "Missing or invalid bearer token", "content": errorContent}
+			op["security"] = []interface{}{map[string]interface{}{"bearerAuth": []interface{}{}}}
+		}
+		op["responses"] = responses
+
+		path, ok := paths[ep.Path].(map[string]interface{})
+		if !ok {
+			path = map[string]interface{}{}
+			paths[ep.Path] = path
+		}
+		path[strings.ToLower(ep.Method)] = op
+	}
+
+	components := map[string]interface{}{"schemas": schemas}
+	if bearerAuth {
+		components["securitySchemes"] = map[string]interface{}{
+			"bearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer"},
+		}
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "HomeKitGenQRCode API",
+			"description": "Generate and validate HomeKit setup labels.",
+			"version":     version,
+		},
+		"paths":      paths,
+		"components": components,
+	}
+}
+
+// schemaRef returns a schema for t, registering struct types in schemas and
+// referencing them with $ref.
+func schemaRef(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Struct:
+		if _, exists := schemas[t.Name()]; !exists {
+			schemas[t.Name()] = nil // Reserve the name to stop recursion
+			schemas[t.Name()] = structSchema(t, schemas)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaRef(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaRef(t.Elem(), schemas)}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	default:
+		return map[string]interface{}{"type": "string"}
+	}
+}
+
+// structSchema builds an object schema from the json, doc, example and enum tags of a struct.
+// Fields without omitempty are listed as required.
+func structSchema(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	properties := map[string]interface{}{}
+	var required []string
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := schemaRef(field.Type, schemas)
+		if doc := field.Tag.Get("doc"); doc != "" {
+			prop["description"] = doc
+		}
+		if example := field.Tag.Get("example"); example != "" {
+			if prop["type"] == "integer" {
+				if n, err := strconv.Atoi(example); err == nil {
+					prop["example"] = n
+				}
+			} else {
+				prop["example"] = example
+			}
+		}
+		if enum := field.Tag.Get("enum"); enum != "" {
+			prop["enum"] = strings.Split(enum, ",")
+		}
+		properties[name] = prop
+
+		if !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
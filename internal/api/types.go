@@ -0,0 +1,96 @@
+// Package api defines the request and response types shared by the WASM build
+// and the HTTP server, and generates the OpenAPI document describing them.
+package api
+
+import "github.com/lordbasex/HomeKitGenQRCode/pkg/homekit"
+
+// GenerateLabelRequest represents a label generation request.
+// Format selects the response encoding for the HTTP API: png, svg, pdf or json
+// (base64 data URL, as returned to JavaScript by the WASM build).
+type GenerateLabelRequest struct {
+	Category int    `json:"category" doc:"HomeKit category ID" example:"5"`
+	Password string `json:"password" doc:"Setup code in format XXX-XX-XXX" example:"613-80-755"`
+	SetupID  string `json:"setupId" doc:"Setup ID: 4 alphanumeric characters (0-9, A-Z)" example:"ABCD"`
+	MAC      string `json:"mac" doc:"MAC address (EUI-48 or EUI-64, any common notation)" example:"AABBCCDDEEFF"`
+	Format   string `json:"format,omitempty" doc:"Response format: png, svg (vector QR codes), pdf or json" enum:"png,svg,pdf,json" example:"png"`
+}
+
+// SetupInfo converts the request into the shared pairing data model.
+func (r GenerateLabelRequest) SetupInfo() homekit.SetupInfo {
+	return homekit.SetupInfo{Category: r.Category, SetupCode: r.Password, SetupID: r.SetupID, MAC: r.MAC}
+}
+
+// GenerateLabelResponse represents the JSON response to a label request.
+type GenerateLabelResponse struct {
+	ImageBase64 string `json:"imageBase64" doc:"Label image as a data URL (data:image/png;base64,...)"`
+	Error       string `json:"error,omitempty" doc:"Error message if generation failed"`
+}
+
+// ValidationRequest represents a validation request
+type ValidationRequest struct {
+	Category int    `json:"category" doc:"HomeKit category ID" example:"5"`
+	Password string `json:"password" doc:"Setup code in format XXX-XX-XXX" example:"613-80-755"`
+	SetupID  string `json:"setupId" doc:"Setup ID: 4 alphanumeric characters (0-9, A-Z)" example:"ABCD"`
+	MAC      string `json:"mac" doc:"MAC address (EUI-48 or EUI-64, any common notation)" example:"AABBCCDDEEFF"`
+}
+
+// ValidationResponse represents a validation response.
+// Field and Rule identify the rejected input and the violated rule (see homekit.ValidationError).
+type ValidationResponse struct {
+	Valid bool   `json:"valid" doc:"True if every value is valid"`
+	Error string `json:"error,omitempty" doc:"Human-readable reason for rejection"`
+	Field string `json:"field,omitempty" doc:"Rejected input: category, password, setupId or mac"`
+	Rule  string `json:"rule,omitempty" doc:"Violated rule, e.g. length, charset, repeated-digits, sequence"`
+	// Warning is set for valid setup codes that are easy to guess (see homekit.SetupCodeWarning).
+	Warning string `json:"warning,omitempty" doc:"Set when the setup code is allowed by the HAP specification but easy to guess"`
+}
+
+// RandomCodeResponse holds randomly generated setup values for a category.
+type RandomCodeResponse struct {
+	SetupCode string `json:"setupCode" doc:"Setup code in format XXX-XX-XXX" example:"613-80-755"`
+	SetupID   string `json:"setupID" doc:"Setup ID" example:"ABCD"`
+	MAC       string `json:"mac" doc:"MAC address (12 hexadecimal characters)" example:"AABBCCDDEEFF"`
+	Category  int    `json:"category" doc:"HomeKit category ID" example:"5"`
+}
+
+// Category is one entry of the category list.
+type Category struct {
+	ID   int    `json:"id" doc:"HomeKit category ID" example:"5"`
+	Name string `json:"name" doc:"Human-readable category name" example:"Light"`
+}
+
+// DecodeURIRequest asks for an X-HM:// setup URI to be decoded.
+type DecodeURIRequest struct {
+	URI string `json:"uri" doc:"HomeKit setup URI" example:"X-HM://0053158R7ABCD"`
+}
+
+// DecodeURIResponse is the decoded content of a setup URI.
+type DecodeURIResponse struct {
+	Version      int      `json:"version" doc:"Payload version"`
+	Category     int      `json:"category" doc:"HomeKit category ID"`
+	CategoryName string   `json:"categoryName" doc:"Human-readable category name"`
+	Flags        int      `json:"flags" doc:"Payload flags (1 = NFC, 2 = IP, 4 = BLE)"`
+	FlagNames    []string `json:"flagNames" doc:"Names of the flags that are set"`
+	SetupCode    string   `json:"setupCode" doc:"Setup code in format XXX-XX-XXX"`
+	SetupID      string   `json:"setupId,omitempty" doc:"Setup ID, if present in the URI"`
+}
+
+// NewDecodeURIResponse builds the response for a decoded setup payload.
+func NewDecodeURIResponse(p homekit.SetupPayload) DecodeURIResponse {
+	return DecodeURIResponse{
+		Version:      p.Version,
+		Category:     p.Category,
+		CategoryName: homekit.CategoryName(p.Category),
+		Flags:        p.Flags,
+		FlagNames:    p.FlagNames(),
+		SetupCode:    p.SetupCode,
+		SetupID:      p.SetupID,
+	}
+}
+
+// ErrorResponse is returned by the HTTP API for failed requests.
+type ErrorResponse struct {
+	Error string `json:"error" doc:"Error message"`
+	Field string `json:"field,omitempty" doc:"Rejected input, for validation errors"`
+	Rule  string `json:"rule,omitempty" doc:"Violated rule, for validation errors"`
+}
@@ -0,0 +1,148 @@
+// Package bitmap exports a QR code as monochrome bitmaps that firmware can
+// compile in to show the setup code on an e-paper or OLED screen: a packed
+// 1-bit C array, XBM, an LVGL image descriptor and an Adafruit-GFX bitmap.
+package bitmap
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Format is a bitmap source format.
+type Format string
+
+// Supported formats.
+const (
+	FormatC    Format = "c"    // Packed 1-bit C array, rows MSB first
+	FormatXBM  Format = "xbm"  // X BitMap, rows LSB first (U8g2 drawXBM)
+	FormatLVGL Format = "lvgl" // LVGL v8 lv_img_dsc_t with a 1-bit indexed palette
+	FormatGFX  Format = "gfx"  // Adafruit-GFX drawBitmap() array in PROGMEM
+)
+
+// formats lists the supported formats in output order.
+var formats = []Format{FormatC, FormatXBM, FormatLVGL, FormatGFX}
+
+// Limits of the module scale and quiet zone.
+const (
+	MaxScale     = 16
+	MaxQuietZone = 16
+)
+
+// ParseFormat converts a format name (case-insensitive) into a Format.
+func ParseFormat(name string) (Format, error) {
+	f := Format(strings.ToLower(strings.TrimSpace(name)))
+	for _, known := range formats {
+		if f == known {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown bitmap format %q. Expected c, xbm, lvgl or gfx", name)
+}
+
+// identPattern matches a valid C identifier.
+var identPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidateIdent checks that ident can be used as a C identifier.
+func ValidateIdent(ident string) error {
+	if !identPattern.MatchString(ident) {
+		return fmt.Errorf("bitmap name %q is not a valid C identifier", ident)
+	}
+	return nil
+}
+
+// Bitmap is a QR code scaled to whole pixels per module, with its quiet zone.
+type Bitmap struct {
+	Width, Height int
+	Modules       int // Modules per side of the QR symbol
+	Scale         int // Pixels per module
+	QuietZone     int // Quiet zone in modules
+	Content       string
+	dark          [][]bool
+}
+
+// New builds the bitmap of modules (indexed [y][x], true for dark, without a
+// quiet zone) with scale pixels per module and a quiet zone of quietZone
+// modules. content is the text encoded in the symbol, used in comments.
+func New(content string, modules [][]bool, scale, quietZone int) (*Bitmap, error) {
+	if scale < 1 || scale > MaxScale {
+		return nil, fmt.Errorf("bitmap scale %d is out of range (1-%d)", scale, MaxScale)
+	}
+	if quietZone < 0 || quietZone > MaxQuietZone {
+		return nil, fmt.Errorf("bitmap quiet zone of %d modules is out of range (0-%d)", quietZone, MaxQuietZone)
+	}
+
+	size := (len(modules) + 2*quietZone) * scale
+	dark := make([][]bool, size)
+	for y := range dark {
+		dark[y] = make([]bool, size)
+		my := y/scale - quietZone
+		if my < 0 || my >= len(modules) {
+			continue
+		}
+		for x := range dark[y] {
+			mx := x/scale - quietZone
+			dark[y][x] = mx >= 0 && mx < len(modules[my]) && modules[my][mx]
+		}
+	}
+	return &Bitmap{
+		Width:     size,
+		Height:    size,
+		Modules:   len(modules),
+		Scale:     scale,
+		QuietZone: quietZone,
+		Content:   content,
+		dark:      dark,
+	}, nil
+}
+
+// Stride returns the number of bytes per packed row.
+func (b *Bitmap) Stride() int {
+	return (b.Width + 7) / 8
+}
+
+// Pack returns the pixels packed 8 per byte with 1 for dark pixels, each row
+// padded to a whole byte. With lsbFirst the leftmost pixel of each byte is
+// the least significant bit (XBM); otherwise it is the most significant bit.
+func (b *Bitmap) Pack(lsbFirst bool) []byte {
+	stride := b.Stride()
+	data := make([]byte, stride*b.Height)
+	for y, row := range b.dark {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			bit := byte(0x80 >> (x % 8))
+			if lsbFirst {
+				bit = 1 << (x % 8)
+			}
+			data[y*stride+x/8] |= bit
+		}
+	}
+	return data
+}
+
+// Source returns the bitmap in format f, declared under the C identifier ident.
+func (b *Bitmap) Source(f Format, ident string) (string, error) {
+	if err := ValidateIdent(ident); err != nil {
+		return "", err
+	}
+	switch f {
+	case FormatC:
+		return b.cArray(ident), nil
+	case FormatXBM:
+		return b.xbm(ident), nil
+	case FormatLVGL:
+		return b.lvgl(ident), nil
+	case FormatGFX:
+		return b.gfx(ident), nil
+	}
+	return "", fmt.Errorf("unknown bitmap format %q", f)
+}
+
+// description is the comment line identifying the bitmap.
+func (b *Bitmap) description() string {
+	content := strings.ReplaceAll(b.Content, "*/", "* /")
+	return fmt.Sprintf("QR code %s: %dx%d pixels, %d modules, scale %d, quiet zone %d",
+		content, b.Width, b.Height, b.Modules, b.Scale, b.QuietZone)
+}
@@ -0,0 +1,189 @@
+package bitmap
+
+import (
+	"bytes"
+	"testing"
+)
+
+// testModules is a 3x3 module matrix:
+//
+//	X . X
+//	. X .
+//	X X .
+var testModules = [][]bool{
+	{true, false, true},
+	{false, true, false},
+	{true, true, false},
+}
+
+func TestPack(t *testing.T) {
+	tests := []struct {
+		name             string
+		scale, quietZone int
+		msb, lsb         []byte
+	}{
+		{
+			// 10 pixels per row: 2 bytes, the last 6 bits padding
+			name: "scale 2, quiet zone 1", scale: 2, quietZone: 1,
+			msb: []byte{
+				0x00, 0x00, 0x00, 0x00,
+				0x33, 0x00, 0x33, 0x00,
+				0x0C, 0x00, 0x0C, 0x00,
+				0x3C, 0x00, 0x3C, 0x00,
+				0x00, 0x00, 0x00, 0x00,
+			},
+			lsb: []byte{
+				0x00, 0x00, 0x00, 0x00,
+				0xCC, 0x00, 0xCC, 0x00,
+				0x30, 0x00, 0x30, 0x00,
+				0x3C, 0x00, 0x3C, 0x00,
+				0x00, 0x00, 0x00, 0x00,
+			},
+		},
+		{
+			// 9 pixels per row: the ninth pixel is the first bit of the second byte
+			name: "scale 3, no quiet zone", scale: 3, quietZone: 0,
+			msb: []byte{
+				0xE3, 0x80, 0xE3, 0x80, 0xE3, 0x80,
+				0x1C, 0x00, 0x1C, 0x00, 0x1C, 0x00,
+				0xFC, 0x00, 0xFC, 0x00, 0xFC, 0x00,
+			},
+			lsb: []byte{
+				0xC7, 0x01, 0xC7, 0x01, 0xC7, 0x01,
+				0x38, 0x00, 0x38, 0x00, 0x38, 0x00,
+				0x3F, 0x00, 0x3F, 0x00, 0x3F, 0x00,
+			},
+		},
+	}
+	for _, tt := range tests {
+		b, err := New("X-HM://TEST", testModules, tt.scale, tt.quietZone)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := b.Pack(false); !bytes.Equal(got, tt.msb) {
+			t.Errorf("%s: Pack(false) = % X, want % X", tt.name, got, tt.msb)
+		}
+		if got := b.Pack(true); !bytes.Equal(got, tt.lsb) {
+			t.Errorf("%s: Pack(true) = % X, want % X", tt.name, got, tt.lsb)
+		}
+	}
+}
+
+func TestSource(t *testing.T) {
+	b, err := New("X-HM://TEST", testModules, 3, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{FormatC, `/* QR code X-HM://TEST: 9x9 pixels, 3 modules, scale 3, quiet zone 0 */
+/* Rows of 2 bytes, most significant bit first, 1 = dark */
+#ifndef QR_H
+#define QR_H
+
+#include <stdint.h>
+
+#define QR_WIDTH  9
+#define QR_HEIGHT 9
+
+static const uint8_t qr[18] = {
+	0xE3, 0x80, 0xE3, 0x80, 0xE3, 0x80, 0x1C, 0x00, 0x1C, 0x00, 0x1C, 0x00,
+	0xFC, 0x00, 0xFC, 0x00, 0xFC, 0x00,
+};
+
+#endif /* QR_H */
+`},
+		{FormatXBM, `/* QR code X-HM://TEST: 9x9 pixels, 3 modules, scale 3, quiet zone 0 */
+#define qr_width 9
+#define qr_height 9
+static unsigned char qr_bits[] = {
+	0xC7, 0x01, 0xC7, 0x01, 0xC7, 0x01, 0x38, 0x00, 0x38, 0x00, 0x38, 0x00,
+	0x3F, 0x00, 0x3F, 0x00, 0x3F, 0x00,
+};
+`},
+		{FormatLVGL, `/* QR code X-HM://TEST: 9x9 pixels, 3 modules, scale 3, quiet zone 0 */
+#ifdef LV_LVGL_H_INCLUDE_SIMPLE
+#include "lvgl.h"
+#else
+#include "lvgl/lvgl.h"
+#endif
+
+#ifndef LV_ATTRIBUTE_MEM_ALIGN
+#define LV_ATTRIBUTE_MEM_ALIGN
+#endif
+
+#ifndef LV_ATTRIBUTE_IMG_QR
+#define LV_ATTRIBUTE_IMG_QR
+#endif
+
+const LV_ATTRIBUTE_MEM_ALIGN LV_ATTRIBUTE_LARGE_CONST LV_ATTRIBUTE_IMG_QR uint8_t qr_map[] = {
+	0xFF, 0xFF, 0xFF, 0xFF, /* Color of index 0 */
+	0x00, 0x00, 0x00, 0xFF, /* Color of index 1 */
+	0xE3, 0x80, 0xE3, 0x80, 0xE3, 0x80, 0x1C, 0x00, 0x1C, 0x00, 0x1C, 0x00,
+	0xFC, 0x00, 0xFC, 0x00, 0xFC, 0x00,
+};
+
+const lv_img_dsc_t qr = {
+	.header.cf = LV_IMG_CF_INDEXED_1BIT,
+	.header.always_zero = 0,
+	.header.reserved = 0,
+	.header.w = 9,
+	.header.h = 9,
+	.data_size = 26,
+	.data = qr_map,
+};
+`},
+		{FormatGFX, `/* QR code X-HM://TEST: 9x9 pixels, 3 modules, scale 3, quiet zone 0 */
+/* display.drawBitmap(x, y, qr, QR_WIDTH, QR_HEIGHT, BLACK) on a white background */
+#ifndef QR_GFX_H
+#define QR_GFX_H
+
+#include <Adafruit_GFX.h>
+
+#define QR_WIDTH  9
+#define QR_HEIGHT 9
+
+const uint8_t qr[] PROGMEM = {
+	0xE3, 0x80, 0xE3, 0x80, 0xE3, 0x80, 0x1C, 0x00, 0x1C, 0x00, 0x1C, 0x00,
+	0xFC, 0x00, 0xFC, 0x00, 0xFC, 0x00,
+};
+
+#endif /* QR_GFX_H */
+`},
+	}
+	for _, tt := range tests {
+		got, err := b.Source(tt.format, "qr")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("Source(%s) =\n%s\nwant\n%s", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	tests := []struct{ scale, quietZone int }{
+		{0, 4}, {MaxScale + 1, 4}, {1, -1}, {1, MaxQuietZone + 1},
+	}
+	for _, tt := range tests {
+		if _, err := New("X-HM://TEST", testModules, tt.scale, tt.quietZone); err == nil {
+			t.Errorf("New accepted scale %d and quiet zone %d", tt.scale, tt.quietZone)
+		}
+	}
+}
+
+func TestValidateIdent(t *testing.T) {
+	for _, ident := range []string{"homekit_qr", "_qr", "QR2"} {
+		if err := ValidateIdent(ident); err != nil {
+			t.Errorf("ValidateIdent(%q) = %v, want nil", ident, err)
+		}
+	}
+	for _, ident := range []string{"", "2qr", "homekit-qr", "qr code"} {
+		if err := ValidateIdent(ident); err == nil {
+			t.Errorf("ValidateIdent(%q) accepted an invalid identifier", ident)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package bitmap
+
+import (
+	"fmt"
+	"strings"
+)
+
+// cArray returns a header with the bitmap as a packed 1-bit array, rows most
+// significant bit first, 1 for dark pixels.
+func (b *Bitmap) cArray(ident string) string {
+	upper := strings.ToUpper(ident)
+	data := b.Pack(false)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "/* %s */\n", b.description())
+	fmt.Fprintf(&sb, "/* Rows of %d bytes, most significant bit first, 1 = dark */\n", b.Stride())
+	fmt.Fprintf(&sb, "#ifndef %s_H\n#define %s_H\n\n", upper, upper)
+	sb.WriteString("#include <stdint.h>\n\n")
+	fmt.Fprintf(&sb, "#define %s_WIDTH  %d\n", upper, b.Width)
+	fmt.Fprintf(&sb, "#define %s_HEIGHT %d\n\n", upper, b.Height)
+	writeBytes(&sb, fmt.Sprintf("static const uint8_t %s[%d] = {", ident, len(data)), data, 12, "};")
+	fmt.Fprintf(&sb, "\n#endif /* %s_H */\n", upper)
+	return sb.String()
+}
+
+// xbm returns the bitmap as an X BitMap, rows least significant bit first,
+// 1 for dark (foreground) pixels, as drawn by U8g2's drawXBM.
+func (b *Bitmap) xbm(ident string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "/* %s */\n", b.description())
+	fmt.Fprintf(&sb, "#define %s_width %d\n", ident, b.Width)
+	fmt.Fprintf(&sb, "#define %s_height %d\n", ident, b.Height)
+	writeBytes(&sb, fmt.Sprintf("static unsigned char %s_bits[] = {", ident), b.Pack(true), 12, "};")
+	return sb.String()
+}
+
+// lvgl returns a C source file declaring an LVGL v8 lv_img_dsc_t in the
+// LV_IMG_CF_INDEXED_1BIT format: a palette of white (index 0) and black
+// (index 1) followed by the rows most significant bit first, as written by
+// LVGL's image converter.
+func (b *Bitmap) lvgl(ident string) string {
+	upper := strings.ToUpper(ident)
+	palette := []byte{
+		0xFF, 0xFF, 0xFF, 0xFF, // Index 0: white (B, G, R, A)
+		0x00, 0x00, 0x00, 0xFF, // Index 1: black
+	}
+	data := b.Pack(false)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "/* %s */\n", b.description())
+	sb.WriteString("#ifdef LV_LVGL_H_INCLUDE_SIMPLE\n#include \"lvgl.h\"\n#else\n#include \"lvgl/lvgl.h\"\n#endif\n\n")
+	sb.WriteString("#ifndef LV_ATTRIBUTE_MEM_ALIGN\n#define LV_ATTRIBUTE_MEM_ALIGN\n#endif\n\n")
+	fmt.Fprintf(&sb, "#ifndef LV_ATTRIBUTE_IMG_%s\n#define LV_ATTRIBUTE_IMG_%s\n#endif\n\n", upper, upper)
+	fmt.Fprintf(&sb, "const LV_ATTRIBUTE_MEM_ALIGN LV_ATTRIBUTE_LARGE_CONST LV_ATTRIBUTE_IMG_%s uint8_t %s_map[] = {\n", upper, ident)
+	for i := 0; i < len(palette); i += 4 {
+		p := palette[i : i+4]
+		fmt.Fprintf(&sb, "\t0x%02X, 0x%02X, 0x%02X, 0x%02X, /* Color of index %d */\n", p[0], p[1], p[2], p[3], i/4)
+	}
+	writeBytes(&sb, "", data, 12, "};")
+	sb.WriteString("\n")
+	fmt.Fprintf(&sb, "const lv_img_dsc_t %s = {\n", ident)
+	sb.WriteString("\t.header.cf = LV_IMG_CF_INDEXED_1BIT,\n")
+	sb.WriteString("\t.header.always_zero = 0,\n")
+	sb.WriteString("\t.header.reserved = 0,\n")
+	fmt.Fprintf(&sb, "\t.header.w = %d,\n", b.Width)
+	fmt.Fprintf(&sb, "\t.header.h = %d,\n", b.Height)
+	fmt.Fprintf(&sb, "\t.data_size = %d,\n", len(palette)+len(data))
+	fmt.Fprintf(&sb, "\t.data = %s_map,\n", ident)
+	sb.WriteString("};\n")
+	return sb.String()
+}
+
+// gfx returns a header with the bitmap in the layout of Adafruit-GFX's
+// drawBitmap(): rows most significant bit first, 1 for pixels drawn in the
+// given color, stored in PROGMEM.
+func (b *Bitmap) gfx(ident string) string {
+	upper := strings.ToUpper(ident)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "/* %s */\n", b.description())
+	fmt.Fprintf(&sb, "/* display.drawBitmap(x, y, %s, %s_WIDTH, %s_HEIGHT, BLACK) on a white background */\n", ident, upper, upper)
+	fmt.Fprintf(&sb, "#ifndef %s_GFX_H\n#define %s_GFX_H\n\n", upper, upper)
+	sb.WriteString("#include <Adafruit_GFX.h>\n\n")
+	fmt.Fprintf(&sb, "#define %s_WIDTH  %d\n", upper, b.Width)
+	fmt.Fprintf(&sb, "#define %s_HEIGHT %d\n\n", upper, b.Height)
+	writeBytes(&sb, fmt.Sprintf("const uint8_t %s[] PROGMEM = {", ident), b.Pack(false), 12, "};")
+	fmt.Fprintf(&sb, "\n#endif /* %s_GFX_H */\n", upper)
+	return sb.String()
+}
+
+// writeBytes writes data as comma-separated hex bytes, perLine per line,
+// between the open and end lines. An empty open line is not written.
+func writeBytes(sb *strings.Builder, open string, data []byte, perLine int, end string) {
+	if open != "" {
+		sb.WriteString(open + "\n")
+	}
+	for i := 0; i < len(data); i += perLine {
+		sb.WriteString("\t")
+		for j, c := range data[i:min(i+perLine, len(data))] {
+			if j > 0 {
+				sb.WriteString(" ")
+			}
+			fmt.Fprintf(sb, "0x%02X,", c)
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString(end + "\n")
+}
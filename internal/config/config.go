@@ -0,0 +1,327 @@
+// Package config loads CLI settings from config files, named profiles and
+// HOMEKITGENQRCODE_* environment variables.
+//
+// Settings are resolved in this order, later layers winning:
+//
+//  1. Built-in defaults
+//  2. Top-level settings of the user config file, then of the project file
+//  3. The selected profile of the user config file, then of the project file
+//  4. HOMEKITGENQRCODE_* environment variables
+//
+// Command-line flags override all of them; they are applied by the CLI.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lordbasex/HomeKitGenQRCode/internal/generator"
+	"github.com/lordbasex/HomeKitGenQRCode/pkg/homekit"
+
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is the prefix of environment variables overriding settings.
+// A key maps to EnvPrefix + upper case key with '-' replaced by '_',
+// e.g. output-dir is HOMEKITGENQRCODE_OUTPUT_DIR.
+const EnvPrefix = "HOMEKITGENQRCODE_"
+
+// ProfileEnv selects the profile when no --profile flag is given.
+const ProfileEnv = EnvPrefix + "PROFILE"
+
+// ProjectFileName is the project-local config file, searched for in the
+// current directory and its parents.
+const ProjectFileName = ".homekitgenqrcode.yaml"
+
+// Setting keys. They match the names of the CLI flags they provide values for.
+const (
+	KeyCategory        = "category"
+	KeyBrand           = "brand"
+	KeyOutputDir       = "output-dir"
+	KeyOutputFormat    = "output-format"
+	KeyMACStyle        = "mac-style"
+	KeyMACBarcodeStyle = "mac-barcode-style"
+)
+
+// Keys lists all setting keys in display order.
+var Keys = []string{KeyCategory, KeyBrand, KeyOutputDir, KeyOutputFormat, KeyMACStyle, KeyMACBarcodeStyle}
+
+// Defaults holds the built-in value of every key. An empty value means unset.
+var Defaults = map[string]string{
+	KeyCategory:        "",
+	KeyBrand:           generator.DefaultBrand,
+	KeyOutputDir:       "",
+	KeyOutputFormat:    "table",
+	KeyMACStyle:        string(homekit.MACStyleColon),
+	KeyMACBarcodeStyle: string(homekit.MACStyleBare),
+}
+
+// SourceDefault is the source of values that were not configured anywhere.
+const SourceDefault = "default"
+
+// Settings is one layer of configured values. Nil fields are not set.
+type Settings struct {
+	Category        *int    `yaml:"category,omitempty"`
+	Brand           *string `yaml:"brand,omitempty"`
+	OutputDir       *string `yaml:"output-dir,omitempty"`
+	OutputFormat    *string `yaml:"output-format,omitempty"`
+	MACStyle        *string `yaml:"mac-style,omitempty"`
+	MACBarcodeStyle *string `yaml:"mac-barcode-style,omitempty"`
+}
+
+// values returns the set fields keyed by setting key.
+func (s Settings) values() map[string]string {
+	values := map[string]string{}
+	if s.Category != nil {
+		values[KeyCategory] = strconv.Itoa(*s.Category)
+	}
+	for key, field := range map[string]*string{
+		KeyBrand:           s.Brand,
+		KeyOutputDir:       s.OutputDir,
+		KeyOutputFormat:    s.OutputFormat,
+		KeyMACStyle:        s.MACStyle,
+		KeyMACBarcodeStyle: s.MACBarcodeStyle,
+	} {
+		if field != nil {
+			values[key] = *field
+		}
+	}
+	return values
+}
+
+// File is the content of a config file:
+//
+//	profile: acme            # profile used when none is selected
+//	category: 5              # top-level settings apply to every profile
+//	profiles:
+//	  acme:
+//	    brand: Designed by ACME
+//	    output-dir: labels/acme
+//	    output-format: json
+type File struct {
+	Settings `yaml:",inline"`
+	Profile  string              `yaml:"profile,omitempty"`
+	Profiles map[string]Settings `yaml:"profiles,omitempty"`
+}
+
+// ReadFile parses a config file. Unknown keys are rejected.
+func ReadFile(path string) (File, error) {
+	var file File
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return file, err
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return file, fmt.Errorf("error parsing config file %s: %w", path, err)
+	}
+	return file, nil
+}
+
+// Value is an effective setting and where it came from.
+type Value struct {
+	Key    string `json:"key" yaml:"key"`
+	Value  string `json:"value" yaml:"value"`
+	Source string `json:"source" yaml:"source"`
+}
+
+// Config is the merged configuration.
+type Config struct {
+	Profile string   // Selected profile ("" for none)
+	Files   []string // Config files that were loaded, lowest precedence first
+	values  map[string]Value
+}
+
+// Get returns the effective value of key.
+func (c *Config) Get(key string) Value {
+	return c.values[key]
+}
+
+// Set overrides the effective value of key, e.g. with a command-line flag.
+func (c *Config) Set(key, value, source string) {
+	c.values[key] = Value{Key: key, Value: value, Source: source}
+}
+
+// Values returns all effective values in Keys order.
+func (c *Config) Values() []Value {
+	values := make([]Value, 0, len(Keys))
+	for _, key := range Keys {
+		values = append(values, c.values[key])
+	}
+	return values
+}
+
+// ApplyFlags applies the command-line flag layer to flags: a flag given on the
+// command line overrides the configured value, and every other flag named
+// after a setting key takes the configured value unless it is the default.
+func (c *Config) ApplyFlags(flags *pflag.FlagSet) error {
+	for _, key := range Keys {
+		flag := flags.Lookup(key)
+		if flag == nil {
+			continue
+		}
+		if flag.Changed {
+			c.Set(key, flag.Value.String(), "flag --"+key)
+			continue
+		}
+
+		value := c.Get(key)
+		if value.Source == SourceDefault || value.Value == "" {
+			continue
+		}
+		if err := flag.Value.Set(value.Value); err != nil {
+			return fmt.Errorf("invalid %s %q from %s: %w", key, value.Value, value.Source, err)
+		}
+		flag.Changed = true // Satisfies required flags such as --category
+	}
+	return nil
+}
+
+// UserFile returns the path of the user config file:
+// $XDG_CONFIG_HOME/homekitgenqrcode/config.yaml, or ~/.config/homekitgenqrcode/config.yaml.
+func UserFile(getenv func(string) string) string {
+	dir := getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "homekitgenqrcode", "config.yaml")
+}
+
+// ProjectFile returns the nearest project config file in dir or its parents,
+// or "" if there is none.
+func ProjectFile(dir string) string {
+	for {
+		path := filepath.Join(dir, ProjectFileName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Load resolves the configuration from the user and project config files and
+// the environment. profile selects a profile; if empty, HOMEKITGENQRCODE_PROFILE
+// and then the "profile" key of the config files are used.
+func Load(profile string, getenv func(string) string) (*Config, error) {
+	var paths []string
+	if path := UserFile(getenv); path != "" {
+		paths = append(paths, path)
+	}
+	if wd, err := os.Getwd(); err == nil {
+		if path := ProjectFile(wd); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return LoadFiles(paths, profile, getenv)
+}
+
+// LoadFiles is like Load with an explicit list of config files, lowest
+// precedence first. Missing files are skipped.
+func LoadFiles(paths []string, profile string, getenv func(string) string) (*Config, error) {
+	cfg := &Config{values: map[string]Value{}}
+	for _, key := range Keys {
+		cfg.values[key] = Value{Key: key, Value: Defaults[key], Source: SourceDefault}
+	}
+
+	var files []File
+	for _, path := range paths {
+		file, err := ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+		cfg.Files = append(cfg.Files, path)
+	}
+
+	// Select the profile: argument, environment, then the last file naming one
+	if profile == "" {
+		profile = getenv(ProfileEnv)
+	}
+	if profile == "" {
+		for _, file := range files {
+			if file.Profile != "" {
+				profile = file.Profile
+			}
+		}
+	}
+	cfg.Profile = profile
+
+	// Top-level settings, then the selected profile, in file order
+	for i, file := range files {
+		cfg.apply(file.Settings, cfg.Files[i])
+	}
+	if profile != "" {
+		found := false
+		for i, file := range files {
+			if settings, ok := file.Profiles[profile]; ok {
+				found = true
+				cfg.apply(settings, fmt.Sprintf("profile %s (%s)", profile, cfg.Files[i]))
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown profile %q. Available profiles: %s", profile, availableProfiles(files))
+		}
+	}
+
+	// Environment variables
+	for _, key := range Keys {
+		name := EnvVar(key)
+		if value := getenv(name); value != "" {
+			cfg.values[key] = Value{Key: key, Value: value, Source: "env " + name}
+		}
+	}
+
+	return cfg, nil
+}
+
+// EnvVar returns the environment variable name for a setting key.
+func EnvVar(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+}
+
+// apply overlays the set fields of settings.
+func (c *Config) apply(settings Settings, source string) {
+	for key, value := range settings.values() {
+		c.values[key] = Value{Key: key, Value: value, Source: source}
+	}
+}
+
+// availableProfiles lists the profile names defined in files.
+func availableProfiles(files []File) string {
+	seen := map[string]bool{}
+	var names []string
+	for _, file := range files {
+		for name := range file.Profiles {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,151 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+// writeFile writes a config file into dir and returns its path.
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// env returns a getenv function backed by vars.
+func env(vars map[string]string) func(string) string {
+	return func(name string) string { return vars[name] }
+}
+
+func TestPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	user := writeFile(t, dir, "config.yaml", `
+profile: acme
+category: 5
+brand: User brand
+output-dir: user
+output-format: yaml
+mac-style: hyphen
+profiles:
+  acme:
+    brand: Profile brand
+    output-dir: profile
+    output-format: json
+`)
+	project := writeFile(t, dir, ProjectFileName, `
+brand: Project brand
+mac-style: dot
+`)
+
+	cfg, err := LoadFiles([]string{user, filepath.Join(dir, "missing.yaml"), project}, "", env(map[string]string{
+		EnvPrefix + "OUTPUT_DIR":    "env",
+		EnvPrefix + "OUTPUT_FORMAT": "csv",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	outputDir := flags.String(KeyOutputDir, "", "")
+	outputFormat := flags.String(KeyOutputFormat, "table", "")
+	category := flags.Int(KeyCategory, 0, "")
+	macBarcodeStyle := flags.String(KeyMACBarcodeStyle, "bare", "")
+	if err := flags.Parse([]string{"--output-dir", "flag"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.ApplyFlags(flags); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct{ key, value, source string }{
+		{KeyOutputDir, "flag", "flag --output-dir"},                       // flag > env > profile > file
+		{KeyOutputFormat, "csv", "env HOMEKITGENQRCODE_OUTPUT_FORMAT"},    // env > profile > file
+		{KeyBrand, "Profile brand", "profile acme (" + user + ")"},        // profile > project file > user file
+		{KeyMACStyle, "dot", project},                                     // project file > user file
+		{KeyCategory, "5", user},                                          // user file > default
+		{KeyMACBarcodeStyle, Defaults[KeyMACBarcodeStyle], SourceDefault}, // default
+	}
+	for _, tt := range tests {
+		got := cfg.Get(tt.key)
+		if got.Value != tt.value || got.Source != tt.source {
+			t.Errorf("Get(%s) = %q from %q, want %q from %q", tt.key, got.Value, got.Source, tt.value, tt.source)
+		}
+	}
+	if *outputDir != "flag" || *outputFormat != "csv" || *category != 5 || *macBarcodeStyle != "bare" {
+		t.Errorf("flags = %q, %q, %d, %q, want flag, csv, 5, bare", *outputDir, *outputFormat, *category, *macBarcodeStyle)
+	}
+	if !flags.Lookup(KeyCategory).Changed {
+		t.Error("configured --category not marked as changed; required flags would fail")
+	}
+	if cfg.Profile != "acme" {
+		t.Errorf("Profile = %q, want acme", cfg.Profile)
+	}
+	if len(cfg.Files) != 2 || cfg.Files[0] != user || cfg.Files[1] != project {
+		t.Errorf("Files = %v, want [%s %s]", cfg.Files, user, project)
+	}
+}
+
+func TestProfileSelection(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "config.yaml", `
+profile: a
+profiles:
+  a:
+    brand: A
+  b:
+    brand: B
+  c:
+    brand: C
+`)
+	tests := []struct {
+		profile, envProfile, want string
+	}{
+		{"", "", "A"},   // the file's profile key
+		{"", "b", "B"},  // HOMEKITGENQRCODE_PROFILE over the file
+		{"c", "b", "C"}, // --profile over the environment
+	}
+	for _, tt := range tests {
+		cfg, err := LoadFiles([]string{path}, tt.profile, env(map[string]string{ProfileEnv: tt.envProfile}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := cfg.Get(KeyBrand).Value; got != tt.want {
+			t.Errorf("profile %q, env %q: brand = %q, want %q", tt.profile, tt.envProfile, got, tt.want)
+		}
+	}
+
+	if _, err := LoadFiles([]string{path}, "missing", env(nil)); err == nil {
+		t.Error("LoadFiles accepted an unknown profile")
+	}
+}
+
+func TestReadFileUnknownKey(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "config.yaml", "colour: red\n")
+	if _, err := ReadFile(path); err == nil {
+		t.Error("ReadFile accepted an unknown key")
+	}
+}
+
+func TestEnvVar(t *testing.T) {
+	if got, want := EnvVar(KeyMACBarcodeStyle), "HOMEKITGENQRCODE_MAC_BARCODE_STYLE"; got != want {
+		t.Errorf("EnvVar(%s) = %s, want %s", KeyMACBarcodeStyle, got, want)
+	}
+}
+
+func TestApplyFlagsInvalidValue(t *testing.T) {
+	cfg, err := LoadFiles(nil, "", env(map[string]string{EnvPrefix + "CATEGORY": "lamp"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.Int(KeyCategory, 0, "")
+	if err := cfg.ApplyFlags(flags); err == nil {
+		t.Error("ApplyFlags accepted a non-numeric category")
+	}
+}
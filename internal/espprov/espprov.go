@@ -0,0 +1,150 @@
+// Package espprov builds the QR payload that Espressif's provisioning apps
+// (ESP BLE Provisioning and ESP SoftAP Provisioning) scan to put an ESP32
+// device on Wi-Fi with the ESP-IDF unified provisioning protocol.
+package espprov
+
+import (
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Version is the payload version understood by the provisioning apps.
+const Version = "v1"
+
+// PoPLength is the length in hex characters of generated proofs of possession.
+const PoPLength = 12
+
+// MinKeyLength is the minimum length in bytes of the key used by DerivePoP.
+const MinKeyLength = 16
+
+// Maximum name lengths: a SoftAP SSID holds 32 bytes, and protocomm limits
+// the BLE device name to 29 bytes so it fits the advertisement.
+const (
+	maxSoftAPName = 32
+	maxBLEName    = 29
+)
+
+// maxPoPLength bounds the proof of possession accepted in a payload.
+const maxPoPLength = 64
+
+// Transport is the provisioning transport the device listens on.
+type Transport string
+
+// Supported transports.
+const (
+	TransportSoftAP Transport = "softap" // Device opens a Wi-Fi access point named after the device
+	TransportBLE    Transport = "ble"    // Device advertises over Bluetooth LE under the device name
+)
+
+// ParseTransport converts a transport name (case-insensitive) into a Transport.
+func ParseTransport(name string) (Transport, error) {
+	switch Transport(strings.ToLower(strings.TrimSpace(name))) {
+	case TransportSoftAP:
+		return TransportSoftAP, nil
+	case TransportBLE:
+		return TransportBLE, nil
+	}
+	return "", fmt.Errorf("unknown provisioning transport %q. Expected softap or ble", name)
+}
+
+// Payload is the JSON content of the provisioning QR code. Field order
+// matches the payload printed by ESP-IDF's qrcode example.
+type Payload struct {
+	Version   string    `json:"ver" yaml:"ver"`
+	Name      string    `json:"name" yaml:"name"`
+	PoP       string    `json:"pop,omitempty" yaml:"pop,omitempty"`
+	Transport Transport `json:"transport" yaml:"transport"`
+}
+
+// DefaultName returns the service name ESP-IDF's provisioning example uses for
+// a device: PROV_ followed by the last three bytes of its MAC address in
+// uppercase hex. mac is a normalized (bare hex) address.
+func DefaultName(mac string) string {
+	mac = strings.ToUpper(mac)
+	if len(mac) > 6 {
+		mac = mac[len(mac)-6:]
+	}
+	return "PROV_" + mac
+}
+
+// RandomPoP returns a random proof of possession of PoPLength hex characters.
+func RandomPoP() string {
+	b := make([]byte, PoPLength/2)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// DerivePoP derives a proof of possession of PoPLength hex characters from a
+// factory key and the device MAC address with HKDF-SHA256, so that firmware
+// holding the key can compute the same value on the device. The info
+// parameter is "HomeKitGenQRCode provisioning PoP|<MAC>", with the MAC in
+// uppercase bare hex.
+func DerivePoP(key []byte, mac string) (string, error) {
+	if len(key) < MinKeyLength {
+		return "", fmt.Errorf("provisioning key too short (%d bytes). At least %d bytes are required", len(key), MinKeyLength)
+	}
+	mac = strings.ToUpper(strings.TrimSpace(mac))
+	if mac == "" {
+		return "", fmt.Errorf("MAC address cannot be empty")
+	}
+	okm, err := hkdf.Key(sha256.New, key, nil, "HomeKitGenQRCode provisioning PoP|"+mac, PoPLength/2)
+	if err != nil {
+		return "", fmt.Errorf("error deriving proof of possession: %w", err)
+	}
+	return hex.EncodeToString(okm), nil
+}
+
+// Validate checks the payload against the limits of the provisioning
+// transports: a name that fits the SSID or BLE device name, and a printable
+// ASCII proof of possession.
+func (p Payload) Validate() error {
+	if p.Version != Version {
+		return fmt.Errorf("unsupported provisioning payload version %q (expected %s)", p.Version, Version)
+	}
+	if _, err := ParseTransport(string(p.Transport)); err != nil {
+		return err
+	}
+	limit := maxSoftAPName
+	if p.Transport == TransportBLE {
+		limit = maxBLEName
+	}
+	if p.Name == "" || len(p.Name) > limit {
+		return fmt.Errorf("provisioning name %q must be 1-%d bytes for %s", p.Name, limit, p.Transport)
+	}
+	if !printable(p.Name) {
+		return fmt.Errorf("provisioning name %q must be printable ASCII", p.Name)
+	}
+	if len(p.PoP) > maxPoPLength || !printable(p.PoP) {
+		return fmt.Errorf("proof of possession must be at most %d printable ASCII characters", maxPoPLength)
+	}
+	return nil
+}
+
+// QRCode returns the compact JSON printed in the provisioning QR code.
+func (p Payload) QRCode() (string, error) {
+	if err := p.Validate(); err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	enc := json.NewEncoder(&sb)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(p); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(sb.String(), "\n"), nil
+}
+
+// printable reports whether s only holds printable ASCII characters.
+func printable(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] > 0x7E {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,92 @@
+package espprov
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestQRCode(t *testing.T) {
+	tests := []struct {
+		p    Payload
+		want string
+	}{
+		{
+			Payload{Version: Version, Name: "PROV_DDEEFF", PoP: "abcd1234", Transport: TransportSoftAP},
+			`{"ver":"v1","name":"PROV_DDEEFF","pop":"abcd1234","transport":"softap"}`,
+		},
+		{
+			// No PoP (security 0): the key is omitted
+			Payload{Version: Version, Name: "PROV_DDEEFF", Transport: TransportBLE},
+			`{"ver":"v1","name":"PROV_DDEEFF","transport":"ble"}`,
+		},
+		{
+			// Not HTML-escaped: the apps parse the QR text as plain JSON
+			Payload{Version: Version, Name: "Lamp<1>&", PoP: "a&b", Transport: TransportBLE},
+			`{"ver":"v1","name":"Lamp<1>&","pop":"a&b","transport":"ble"}`,
+		},
+	}
+	for _, tt := range tests {
+		got, err := tt.p.QRCode()
+		if err != nil {
+			t.Errorf("QRCode(%+v) = %v", tt.p, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("QRCode(%+v) = %s, want %s", tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		p    Payload
+	}{
+		{"version", Payload{Version: "v2", Name: "PROV_DDEEFF", Transport: TransportSoftAP}},
+		{"transport", Payload{Version: Version, Name: "PROV_DDEEFF", Transport: "wifi"}},
+		{"empty name", Payload{Version: Version, Transport: TransportSoftAP}},
+		{"long SoftAP name", Payload{Version: Version, Name: strings.Repeat("A", 33), Transport: TransportSoftAP}},
+		{"long BLE name", Payload{Version: Version, Name: strings.Repeat("A", 30), Transport: TransportBLE}},
+		{"non-ASCII name", Payload{Version: Version, Name: "Lámpara", Transport: TransportSoftAP}},
+		{"long PoP", Payload{Version: Version, Name: "PROV_DDEEFF", PoP: strings.Repeat("a", 65), Transport: TransportSoftAP}},
+	}
+	for _, tt := range tests {
+		if err := tt.p.Validate(); err == nil {
+			t.Errorf("Validate accepted %s", tt.name)
+		}
+	}
+	ok := Payload{Version: Version, Name: strings.Repeat("A", 32), Transport: TransportSoftAP}
+	if err := ok.Validate(); err != nil {
+		t.Errorf("Validate rejected a 32-byte SoftAP name: %v", err)
+	}
+}
+
+func TestDefaultName(t *testing.T) {
+	if got, want := DefaultName("aabbccddeeff"), "PROV_DDEEFF"; got != want {
+		t.Errorf("DefaultName = %s, want %s", got, want)
+	}
+}
+
+func TestDerivePoP(t *testing.T) {
+	key := []byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0A, 0x0B, 0x0C, 0x0D, 0x0E, 0x0F}
+	// HKDF-SHA256 with no salt and info "HomeKitGenQRCode provisioning PoP|AABBCCDDEEFF"
+	got, err := DerivePoP(key, "aabbccddeeff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "d454d2662832"; got != want {
+		t.Errorf("DerivePoP = %s, want %s", got, want)
+	}
+	if _, err := DerivePoP(key[:MinKeyLength-1], "AABBCCDDEEFF"); err == nil {
+		t.Error("DerivePoP accepted a short key")
+	}
+}
+
+func TestParseTransport(t *testing.T) {
+	if tr, err := ParseTransport(" BLE "); err != nil || tr != TransportBLE {
+		t.Errorf("ParseTransport(\" BLE \") = %s, %v, want ble", tr, err)
+	}
+	if _, err := ParseTransport("wifi"); err == nil {
+		t.Error("ParseTransport accepted wifi")
+	}
+}
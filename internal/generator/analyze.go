@@ -0,0 +1,486 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"math/rand"
+	"slices"
+
+	"github.com/lordbasex/HomeKitGenQRCode/internal/scan"
+)
+
+// PrintCondition is a simulated print and scan of a label: the label is
+// resampled to the printer resolution and thresholded into dots, then ink
+// spread and blur are applied. The print is read back by a scanner that adds
+// contrast loss and noise at its own resolution (see scanFeaturePixels).
+type PrintCondition struct {
+	Name      string
+	DPI       float64 // Printer resolution in dots per inch
+	Blur      float64 // Gaussian blur sigma in printer dots
+	InkSpread float64 // Radius by which printed dots grow, in printer dots
+	Noise     float64 // Noise standard deviation in gray levels (0-255)
+	Contrast  float64 // Ink darkness relative to the paper: 1 is black, 0 invisible
+}
+
+// DefaultPrintDPIs are the printer resolutions simulated by default: common
+// thermal label printers and office laser printers.
+var DefaultPrintDPIs = []float64{203, 300, 600}
+
+// printDegradations are the degradations simulated at each printer resolution.
+// The last one, combining all of them, is used to find minimum print sizes.
+var printDegradations = []PrintCondition{
+	{Name: "clean", Contrast: 1},
+	{Name: "blur", Blur: 0.8, Contrast: 1},
+	{Name: "ink spread", InkSpread: 1, Contrast: 1},
+	{Name: "noise", Noise: 32, Contrast: 1},
+	{Name: "low contrast", Noise: 8, Contrast: 0.3},
+	{Name: "worn", Blur: 0.6, InkSpread: 0.5, Noise: 16, Contrast: 0.6},
+}
+
+// PrintConditions returns the default degradations at each of the given
+// printer resolutions.
+func PrintConditions(dpis []float64) []PrintCondition {
+	var conditions []PrintCondition
+	for _, dpi := range dpis {
+		for _, c := range printDegradations {
+			c.DPI = dpi
+			conditions = append(conditions, c)
+		}
+	}
+	return conditions
+}
+
+// PrintResult is the outcome of reading a label under one PrintCondition.
+type PrintResult struct {
+	Condition  PrintCondition
+	ModuleDots float64 // QR module size in printer dots
+	QR         bool    // The QR code decoded to the expected text
+	Corrected  int     // Codewords repaired by error correction
+	Barcodes   int     // Number of barcodes read back
+	Score      float64 // 0-1: two thirds for the QR code, one third for the barcodes
+}
+
+// MinPrintSize is the smallest print width at which a label still reads
+// under the combined degradation at one printer resolution. A zero width
+// means the label does not read even at the analysed width.
+type MinPrintSize struct {
+	DPI          float64
+	QRWidthMM    float64 // Smallest width at which the QR code reads
+	LabelWidthMM float64 // Smallest width at which the QR code and all barcodes read
+}
+
+// PrintAnalysis reports how well a label survives printing at a given width.
+type PrintAnalysis struct {
+	WidthMM, HeightMM float64
+	QR                scan.QRCode // The QR code as read from the unprinted label
+	ModuleMM          float64     // QR module size on paper
+	Barcodes          []string    // Barcode texts read from the unprinted label
+	Results           []PrintResult
+	Score             int // 0-100, the average result score
+	// RecommendedLevel is the error correction level suggested by the
+	// results: the current level, or a stronger one if the QR code failed
+	// or needed more than half of its correction capacity.
+	RecommendedLevel scan.Level
+	MinSizes         []MinPrintSize
+}
+
+// scanFeaturePixels is the number of scanner pixels across the smallest
+// feature of a code, a QR module or a narrow bar, when a print is read back.
+// Like a phone camera framing a code, the scanner resolves finer prints no
+// better than this, so noise is added per scanner pixel rather than per
+// printer dot and does not grow with the print size.
+const scanFeaturePixels = 6
+
+// minModuleDots is the QR module size below which failures are attributed to
+// the printer resolution rather than to missing error correction.
+const minModuleDots = 2
+
+// AnalyzeLabel simulates printing img at widthMM under each condition and
+// reads the QR code and barcodes back. It also searches, for each printer
+// resolution, the smallest width at which the label still reads.
+func AnalyzeLabel(img image.Image, widthMM float64, conditions []PrintCondition) (PrintAnalysis, error) {
+	if widthMM <= 0 {
+		return PrintAnalysis{}, errors.New("print width must be positive")
+	}
+	codes, err := scan.DecodeQRCodes(img)
+	if err != nil {
+		return PrintAnalysis{}, fmt.Errorf("error reading the unprinted label: %w", err)
+	}
+	b := img.Bounds()
+	pxPerMM := float64(b.Dx()) / widthMM
+
+	analysis := PrintAnalysis{
+		WidthMM:  widthMM,
+		HeightMM: float64(b.Dy()) / pxPerMM,
+		QR:       codes[0],
+		ModuleMM: codes[0].ModuleSize / pxPerMM,
+	}
+	var barcodeArea image.Rectangle
+	barcodeFeature := math.Inf(1)
+	for _, bc := range scan.ReadBarcodes(img) {
+		if !slices.Contains(analysis.Barcodes, bc.Text) {
+			analysis.Barcodes = append(analysis.Barcodes, bc.Text)
+			barcodeArea = barcodeArea.Union(image.Rect(bc.Left, bc.Top, bc.Right+1, bc.Bottom+1))
+			barcodeFeature = math.Min(barcodeFeature, narrowestBar(img, bc))
+		}
+	}
+
+	// Only the areas around the QR code and the barcodes are simulated, with
+	// margins for their quiet zones
+	qrArea := image.Rectangle{Min: analysis.QR.Corners[0], Max: analysis.QR.Corners[0]}
+	for _, p := range analysis.QR.Corners[1:] {
+		qrArea = qrArea.Union(image.Rectangle{Min: p, Max: p.Add(image.Pt(1, 1))})
+	}
+	qrSource := newPlane(img, qrArea.Inset(-int(4*analysis.QR.ModuleSize)).Intersect(b))
+	var barcodeSource *plane
+	if len(analysis.Barcodes) > 0 {
+		barcodeSource = newPlane(img, barcodeArea.Inset(-barcodeArea.Dy()/4).Intersect(b))
+	}
+	readQR := func(c PrintCondition, widthMM float64) (bool, int) {
+		scale := c.DPI / 25.4 * widthMM / float64(b.Dx())
+		return readsQR(simulatePrint(qrSource, scale, analysis.QR.ModuleSize, c), analysis.QR.Text)
+	}
+	readBarcodes := func(c PrintCondition, widthMM float64) int {
+		if barcodeSource == nil {
+			return 0
+		}
+		scale := c.DPI / 25.4 * widthMM / float64(b.Dx())
+		return countBarcodes(simulatePrint(barcodeSource, scale, barcodeFeature, c), analysis.Barcodes)
+	}
+
+	total, maxUse, qrFailures := 0.0, 0.0, 0
+	for _, c := range conditions {
+		result := PrintResult{Condition: c, ModuleDots: analysis.ModuleMM * c.DPI / 25.4}
+		result.QR, result.Corrected = readQR(c, widthMM)
+		result.Barcodes = readBarcodes(c, widthMM)
+
+		if result.QR {
+			result.Score = 2.0 / 3
+			if capacity := analysis.QR.Capacity(); capacity > 0 {
+				maxUse = math.Max(maxUse, float64(result.Corrected)/float64(capacity))
+			}
+		} else if result.ModuleDots >= minModuleDots {
+			qrFailures++
+		}
+		if len(analysis.Barcodes) > 0 {
+			result.Score += float64(result.Barcodes) / float64(len(analysis.Barcodes)) / 3
+		} else if result.QR {
+			result.Score = 1
+		}
+		total += result.Score
+		analysis.Results = append(analysis.Results, result)
+	}
+	if len(conditions) > 0 {
+		analysis.Score = int(math.Round(100 * total / float64(len(conditions))))
+	}
+
+	analysis.RecommendedLevel = analysis.QR.Level
+	if qrFailures > 0 || maxUse > 0.5 {
+		analysis.RecommendedLevel = analysis.QR.Level.Next()
+	}
+
+	worn := printDegradations[len(printDegradations)-1]
+	for _, dpi := range uniqueDPIs(conditions) {
+		c := worn
+		c.DPI = dpi
+		size := MinPrintSize{DPI: dpi}
+		size.QRWidthMM = minWidth(widthMM, func(width float64) bool {
+			ok, _ := readQR(c, width)
+			return ok
+		})
+		size.LabelWidthMM = size.QRWidthMM
+		if barcodeSource != nil && size.QRWidthMM > 0 {
+			barcodes := minWidth(widthMM, func(width float64) bool {
+				return readBarcodes(c, width) == len(analysis.Barcodes)
+			})
+			if barcodes == 0 {
+				size.LabelWidthMM = 0
+			} else {
+				size.LabelWidthMM = math.Max(size.LabelWidthMM, barcodes)
+			}
+		}
+		analysis.MinSizes = append(analysis.MinSizes, size)
+	}
+	return analysis, nil
+}
+
+// readsQR reports whether img contains a QR code with the given text, and how
+// many codewords had to be corrected to read it.
+func readsQR(img image.Image, text string) (bool, int) {
+	codes, err := scan.DecodeQRCodes(img)
+	if err != nil {
+		return false, 0
+	}
+	for _, code := range codes {
+		if code.Text == text {
+			return true, code.Corrected
+		}
+	}
+	return false, 0
+}
+
+// countBarcodes returns how many of the expected barcode texts are read in img.
+func countBarcodes(img image.Image, expected []string) int {
+	if len(expected) == 0 {
+		return 0
+	}
+	n := 0
+	read := scan.ReadBarcodes(img)
+	for _, text := range expected {
+		if slices.ContainsFunc(read, func(b scan.Barcode) bool { return b.Text == text }) {
+			n++
+		}
+	}
+	return n
+}
+
+// uniqueDPIs returns the distinct printer resolutions of conditions, in order.
+func uniqueDPIs(conditions []PrintCondition) []float64 {
+	var dpis []float64
+	for _, c := range conditions {
+		if !slices.Contains(dpis, c.DPI) {
+			dpis = append(dpis, c.DPI)
+		}
+	}
+	return dpis
+}
+
+// narrowestBar returns the width in pixels of the narrowest bar of bc in img,
+// measured along its middle row.
+func narrowestBar(img image.Image, bc scan.Barcode) float64 {
+	y := (bc.Top + bc.Bottom) / 2
+	row := newPlane(img, image.Rect(bc.Left, y, bc.Right+1, y+1))
+	narrowest, run := row.width, 0
+	for _, v := range append(row.pix, 1) {
+		if v < 0.5 {
+			run++
+		} else if run > 0 {
+			narrowest, run = min(narrowest, run), 0
+		}
+	}
+	return float64(narrowest)
+}
+
+// minWidthStep is the ratio between the widths tried by minWidth before it
+// bisects.
+const minWidthStep = 1.25
+
+// minWidth searches the smallest print width, down to 1/32 of max, for which
+// reads succeeds, to within about 5%. It returns 0 if reads fails at max.
+//
+// Reading is not strictly monotonic in the width: thresholding into printer
+// dots aliases differently at each size, so a smaller print can read where a
+// larger one did not. The widths are therefore stepped down from max until
+// the first failure, and only the last step is bisected; the result is a
+// width above which every width tried reads.
+func minWidth(max float64, reads func(width float64) bool) float64 {
+	if !reads(max) {
+		return 0
+	}
+	lo, hi := max/32, max
+	for width := max / minWidthStep; width > max/32; width /= minWidthStep {
+		if !reads(width) {
+			lo = width
+			break
+		}
+		hi = width
+	}
+	for hi/lo > 1.05 {
+		mid := math.Sqrt(lo * hi)
+		if reads(mid) {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	return hi
+}
+
+// plane is a grayscale image with samples from 0 (ink) to 1 (paper).
+type plane struct {
+	width, height int
+	pix           []float32
+}
+
+// newPlane copies the r part of img, compositing transparent pixels over white.
+func newPlane(img image.Image, r image.Rectangle) *plane {
+	p := &plane{width: r.Dx(), height: r.Dy(), pix: make([]float32, r.Dx()*r.Dy())}
+	for y := 0; y < p.height; y++ {
+		for x := 0; x < p.width; x++ {
+			cr, cg, cb, ca := img.At(r.Min.X+x, r.Min.Y+y).RGBA()
+			luma := (299*cr+587*cg+114*cb)/1000 + 0xFFFF - ca
+			p.pix[y*p.width+x] = float32(luma) / 0xFFFF
+		}
+	}
+	return p
+}
+
+// simulatePrint renders src as printed and scanned under c, where scale is
+// the number of printer dots per source pixel and feature the size of the
+// smallest feature of the code in source pixels. The noise is seeded, so
+// results are reproducible.
+func simulatePrint(src *plane, scale, feature float64, c PrintCondition) *image.Gray {
+	dots := src.resize(scale)
+	for i, v := range dots.pix {
+		if v < 0.5 {
+			dots.pix[i] = 0
+		} else {
+			dots.pix[i] = 1
+		}
+	}
+	if c.InkSpread > 0 {
+		dots = dots.dilate(c.InkSpread)
+	}
+	if c.Blur > 0 {
+		dots = dots.blur(c.Blur)
+	}
+	// The scanner averages prints finer than its resolution
+	if featureDots := feature * scale; featureDots > scanFeaturePixels {
+		dots = dots.resize(scanFeaturePixels / featureDots)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	out := image.NewGray(image.Rect(0, 0, dots.width, dots.height))
+	for i, v := range dots.pix {
+		gray := 255*(1-c.Contrast*(1-float64(v))) + c.Noise*rng.NormFloat64()
+		out.Pix[i] = uint8(math.Max(0, math.Min(255, math.Round(gray))))
+	}
+	return out
+}
+
+// resize scales the plane by s with area averaging.
+func (p *plane) resize(s float64) *plane {
+	w, h := max(1, int(math.Round(float64(p.width)*s))), max(1, int(math.Round(float64(p.height)*s)))
+	cols, rows := resampleWeights(p.width, w), resampleWeights(p.height, h)
+
+	tmp := make([]float32, w*p.height)
+	for y := 0; y < p.height; y++ {
+		row := p.pix[y*p.width : (y+1)*p.width]
+		for x, weights := range cols {
+			var sum float32
+			for _, wt := range weights {
+				sum += row[wt.index] * wt.weight
+			}
+			tmp[y*w+x] = sum
+		}
+	}
+	out := &plane{width: w, height: h, pix: make([]float32, w*h)}
+	for y, weights := range rows {
+		dst := out.pix[y*w : (y+1)*w]
+		for _, wt := range weights {
+			src := tmp[wt.index*w : (wt.index+1)*w]
+			for x := range dst {
+				dst[x] += src[x] * wt.weight
+			}
+		}
+	}
+	return out
+}
+
+// sampleWeight is the contribution of one source sample to an output sample.
+type sampleWeight struct {
+	index  int
+	weight float32
+}
+
+// resampleWeights returns, for each of n output samples, the source samples
+// among size that its interval covers, weighted by the overlap.
+func resampleWeights(size, n int) [][]sampleWeight {
+	step := float64(size) / float64(n)
+	weights := make([][]sampleWeight, n)
+	for i := range weights {
+		start, end := float64(i)*step, float64(i+1)*step
+		for j := int(start); j < size && float64(j) < end; j++ {
+			overlap := math.Min(end, float64(j+1)) - math.Max(start, float64(j))
+			if overlap > 0 {
+				weights[i] = append(weights[i], sampleWeight{j, float32(overlap / step)})
+			}
+		}
+	}
+	return weights
+}
+
+// dilate grows the ink by radius r: a sample becomes as dark as the darkest
+// sample within the whole part of r, and the fractional part darkens the
+// samples next to the ink proportionally.
+func (p *plane) dilate(r float64) *plane {
+	n := int(r)
+	var offsets []image.Point
+	for dy := -n; dy <= n; dy++ {
+		for dx := -n; dx <= n; dx++ {
+			if dx*dx+dy*dy <= n*n {
+				offsets = append(offsets, image.Pt(dx, dy))
+			}
+		}
+	}
+	out := p.darkest(offsets, 1)
+	if f := float32(r - float64(n)); f > 0 {
+		out = out.darkest([]image.Point{{0, 0}, {-1, 0}, {1, 0}, {0, -1}, {0, 1}}, f)
+	}
+	return out
+}
+
+// darkest returns a plane in which each sample is darkened towards the darkest
+// sample at the given offsets, by the fraction f.
+func (p *plane) darkest(offsets []image.Point, f float32) *plane {
+	out := &plane{width: p.width, height: p.height, pix: make([]float32, len(p.pix))}
+	for y := 0; y < p.height; y++ {
+		for x := 0; x < p.width; x++ {
+			v := float32(1)
+			for _, o := range offsets {
+				if sx, sy := x+o.X, y+o.Y; sx >= 0 && sy >= 0 && sx < p.width && sy < p.height {
+					v = min(v, p.pix[sy*p.width+sx])
+				}
+			}
+			own := p.pix[y*p.width+x]
+			out.pix[y*p.width+x] = min(own, 1-f*(1-v))
+		}
+	}
+	return out
+}
+
+// blur applies a Gaussian blur with the given sigma. Samples beyond the
+// edges are paper.
+func (p *plane) blur(sigma float64) *plane {
+	radius := int(math.Ceil(3 * sigma))
+	kernel := make([]float32, 2*radius+1)
+	var sum float32
+	for i := range kernel {
+		d := float64(i - radius)
+		kernel[i] = float32(math.Exp(-d * d / (2 * sigma * sigma)))
+		sum += kernel[i]
+	}
+	for i := range kernel {
+		kernel[i] /= sum
+	}
+
+	at := func(pix []float32, x, y int) float32 {
+		if x < 0 || y < 0 || x >= p.width || y >= p.height {
+			return 1
+		}
+		return pix[y*p.width+x]
+	}
+	tmp := make([]float32, len(p.pix))
+	out := &plane{width: p.width, height: p.height, pix: make([]float32, len(p.pix))}
+	for y := 0; y < p.height; y++ {
+		for x := 0; x < p.width; x++ {
+			var v float32
+			for i, k := range kernel {
+				v += k * at(p.pix, x+i-radius, y)
+			}
+			tmp[y*p.width+x] = v
+		}
+	}
+	for y := 0; y < p.height; y++ {
+		for x := 0; x < p.width; x++ {
+			var v float32
+			for i, k := range kernel {
+				v += k * at(tmp, x, y+i-radius)
+			}
+			out.pix[y*p.width+x] = v
+		}
+	}
+	return out
+}
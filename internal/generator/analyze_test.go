@@ -0,0 +1,74 @@
+package generator
+
+import (
+	"image"
+	"math"
+	"testing"
+
+	"github.com/lordbasex/HomeKitGenQRCode/internal/scan"
+	"github.com/lordbasex/HomeKitGenQRCode/pkg/homekit"
+)
+
+func TestMinWidth(t *testing.T) {
+	tests := []struct {
+		name  string
+		reads func(width float64) bool
+		want  float64 // 0 when the label never reads
+	}{
+		{"monotonic", func(w float64) bool { return w >= 50 }, 50},
+		{"small blip", func(w float64) bool { return w >= 50 || (w >= 15 && w < 25) }, 50},
+		{"large gap", func(w float64) bool { return w >= 150 || (w >= 20 && w < 120) }, 150},
+		{"always", func(float64) bool { return true }, 200.0 / 32},
+		{"never", func(float64) bool { return false }, 0},
+	}
+	for _, tt := range tests {
+		got := minWidth(200, tt.reads)
+		if tt.want == 0 {
+			if got != 0 {
+				t.Errorf("%s: minWidth = %.1f, want 0", tt.name, got)
+			}
+			continue
+		}
+		if got < tt.want || got > tt.want*1.05 {
+			t.Errorf("%s: minWidth = %.1f, want %.1f to %.1f", tt.name, got, tt.want, tt.want*1.05)
+		}
+		if !tt.reads(got) {
+			t.Errorf("%s: minWidth = %.1f does not read", tt.name, got)
+		}
+	}
+}
+
+// TestSimulatePrintNoise checks that noise is added per scanner pixel, so a
+// larger print of the QR code never reads worse than a smaller one.
+func TestSimulatePrintNoise(t *testing.T) {
+	info, err := homekit.ParseSetupInfo(5, "613-80-755", "ABCD", "8E:17:87:A9:C4:32")
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := RenderLabel(NewLabel(info), LabelOptions{NoVerify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := scan.DecodeQR(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	area := image.Rectangle{Min: code.Corners[0], Max: code.Corners[2]}.Canon().Inset(-int(4 * code.ModuleSize))
+	src := newPlane(img, area.Intersect(img.Bounds()))
+
+	for _, c := range []PrintCondition{
+		{Name: "noise", DPI: 600, Noise: 32, Contrast: 1},
+		{Name: "low contrast", DPI: 600, Noise: 8, Contrast: 0.3},
+	} {
+		for _, widthMM := range []float64{250, 200, 150, 100, 50} {
+			scale := c.DPI / 25.4 * widthMM / float64(img.Bounds().Dx())
+			printed := simulatePrint(src, scale, code.ModuleSize, c)
+			if modulePx := code.ModuleSize * float64(printed.Bounds().Dx()) / float64(src.width); math.Round(modulePx) > scanFeaturePixels {
+				t.Errorf("%s at %.0f mm: %.1f scanner pixels per module, want at most %d", c.Name, widthMM, modulePx, scanFeaturePixels)
+			}
+			if ok, _ := readsQR(printed, code.Text); !ok {
+				t.Errorf("%s at %.0f mm and %.0f dpi: QR code not read", c.Name, widthMM, c.DPI)
+			}
+		}
+	}
+}
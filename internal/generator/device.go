@@ -6,19 +6,6 @@ import (
 	"strings"
 )
 
-// CategoryReference maps HomeKit category IDs to their human-readable names.
-// This map contains all supported HomeKit device categories.
-var CategoryReference = map[int]string{
-	1: "Other", 2: "Bridge", 3: "Fan", 4: "Garage Door Opener", 5: "Light",
-	6: "Lock", 7: "Outlet", 8: "Switch", 9: "Thermostat", 10: "Sensor",
-	11: "Security system", 12: "Door", 13: "Window", 14: "Window covering",
-	15: "Programmable switch", 16: "Range extender", 17: "IP camera",
-	18: "Video doorbell", 19: "Air purifier", 20: "Heater", 21: "Air conditioner",
-	22: "Humidifier", 23: "Dehumidifier", 24: "Apple TV", 26: "Speaker",
-	27: "Airport", 28: "Sprinkler", 29: "Faucet", 30: "Shower head",
-	31: "Television", 32: "Target remote",
-}
-
 // Note: As of Go 1.20+, rand.Seed is deprecated.
 // The global random generator is automatically seeded, so we don't need init()
 
@@ -69,6 +56,25 @@ func GenerateSerial() string {
 	return strings.Join(pattern, "")
 }
 
+// MaxSerialLength is the longest serial number whose barcode fits the label.
+const MaxSerialLength = 20
+
+// NormalizeSerial uppercases a serial number and checks that it can be printed
+// as a Code 39 barcode on the label: 1-MaxSerialLength characters from A-Z,
+// 0-9, '-' and '.'.
+func NormalizeSerial(serial string) (string, error) {
+	serial = strings.ToUpper(strings.TrimSpace(serial))
+	if serial == "" || len(serial) > MaxSerialLength {
+		return "", fmt.Errorf("serial number must be 1-%d characters", MaxSerialLength)
+	}
+	for i, r := range serial {
+		if !((r >= '0' && r <= '9') || (r >= 'A' && r <= 'Z') || r == '-' || r == '.') {
+			return "", fmt.Errorf("invalid character '%c' at position %d. Serial number must contain only 0-9, A-Z, '-' and '.'", r, i+1)
+		}
+	}
+	return serial, nil
+}
+
 // GenerateCSN generates a CSN (Customer Serial Number) matching the Python implementation format.
 // Format: {20 digits}{3 letters}{4 digits}{letter}{digit}{letter}{3 digits}
 // Total length: 20 + 3 + 4 + 1 + 1 + 1 + 3 = 33 characters
@@ -0,0 +1,221 @@
+package generator
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"io"
+	"strings"
+)
+
+// LabelDPI is the print resolution the label template is designed for.
+// It is used to compute physical sizes for SVG and PDF output.
+const LabelDPI = 300
+
+// ImageFormat is an output encoding for rendered labels.
+type ImageFormat string
+
+// Supported label output formats.
+const (
+	FormatPNG ImageFormat = "png" // Raster PNG
+	FormatSVG ImageFormat = "svg" // SVG document with vector QR codes over the rest of the label as PNG
+	FormatPDF ImageFormat = "pdf" // Single-page PDF sized to the label at LabelDPI
+)
+
+// ParseImageFormat converts a format name (case-insensitive) into an ImageFormat.
+// An empty name selects FormatPNG.
+func ParseImageFormat(name string) (ImageFormat, error) {
+	switch ImageFormat(strings.ToLower(strings.TrimSpace(name))) {
+	case "", FormatPNG:
+		return FormatPNG, nil
+	case FormatSVG:
+		return FormatSVG, nil
+	case FormatPDF:
+		return FormatPDF, nil
+	}
+	return "", fmt.Errorf("unknown image format %q. Expected png, svg or pdf", name)
+}
+
+// ContentType returns the MIME type of the format.
+func (f ImageFormat) ContentType() string {
+	switch f {
+	case FormatSVG:
+		return "image/svg+xml"
+	case FormatPDF:
+		return "application/pdf"
+	default:
+		return "image/png"
+	}
+}
+
+// Extension returns the file extension of the format, including the dot.
+func (f ImageFormat) Extension() string {
+	switch f {
+	case FormatSVG:
+		return ".svg"
+	case FormatPDF:
+		return ".pdf"
+	default:
+		return ".png"
+	}
+}
+
+// EncodeLabel renders label and writes it to w in the given format.
+// SVG output draws the QR codes as vector paths, so they stay sharp at any
+// size; the rest of the label is embedded as a PNG image.
+func EncodeLabel(w io.Writer, label Label, opts LabelOptions, format ImageFormat) error {
+	if format != FormatSVG {
+		img, err := RenderLabel(label, opts)
+		if err != nil {
+			return err
+		}
+		return EncodeImage(w, img, format)
+	}
+	var symbols []qrSymbol
+	img, err := renderLabel(label, opts, &symbols)
+	if err != nil {
+		return err
+	}
+	return encodeSVG(w, img, symbols)
+}
+
+// EncodeImage writes img to w as PNG or PDF. SVG output needs the label's
+// QR codes and is written by EncodeLabel.
+func EncodeImage(w io.Writer, img image.Image, format ImageFormat) error {
+	switch format {
+	case FormatSVG:
+		return fmt.Errorf("SVG output is only supported for labels (see EncodeLabel)")
+	case FormatPDF:
+		return encodePDF(w, img)
+	case FormatPNG, "":
+		return png.Encode(w, img)
+	}
+	return fmt.Errorf("unknown image format %q", format)
+}
+
+// pixelsToMM converts a pixel length at LabelDPI to millimetres.
+func pixelsToMM(px int) float64 {
+	return float64(px) * 25.4 / LabelDPI
+}
+
+// encodeSVG writes an SVG document with the QR codes in symbols as vector
+// paths over the rest of img, which is embedded as a PNG data URI. The
+// document carries the physical size so it prints at LabelDPI.
+func encodeSVG(w io.Writer, img *image.RGBA, symbols []qrSymbol) error {
+	// Restore the pixels under the dark modules so only the vector modules remain
+	background := image.NewRGBA(img.Bounds())
+	draw.Draw(background, background.Bounds(), img, img.Bounds().Min, draw.Src)
+	for _, sym := range symbols {
+		for y, row := range sym.modules {
+			for x, dark := range row {
+				if dark {
+					px, py := sym.x0+x*sym.modulePx, sym.y0+y*sym.modulePx
+					r := image.Rect(px, py, px+sym.modulePx, py+sym.modulePx)
+					draw.Draw(background, r, sym.under, r.Min, draw.Src)
+				}
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, background); err != nil {
+		return fmt.Errorf("error encoding PNG: %w", err)
+	}
+
+	b := img.Bounds()
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" width="%.2fmm" height="%.2fmm" viewBox="0 0 %d %d">
+  <image width="%d" height="%d" image-rendering="pixelated" xlink:href="data:image/png;base64,%s"/>
+`, pixelsToMM(b.Dx()), pixelsToMM(b.Dy()), b.Dx(), b.Dy(), b.Dx(), b.Dy(), base64.StdEncoding.EncodeToString(buf.Bytes()))
+	for _, sym := range symbols {
+		fmt.Fprintf(&sb, `  <path fill="#000" shape-rendering="crispEdges" d="%s"/>
+`, modulePath(sym))
+	}
+	sb.WriteString("</svg>\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// modulePath returns SVG path data for the dark modules of sym, one
+// rectangle per horizontal run of dark modules.
+func modulePath(sym qrSymbol) string {
+	var sb strings.Builder
+	for y, row := range sym.modules {
+		for x := 0; x < len(row); {
+			if !row[x] {
+				x++
+				continue
+			}
+			run := x
+			for run < len(row) && row[run] {
+				run++
+			}
+			fmt.Fprintf(&sb, "M%d %dh%dv%dh-%dz", sym.x0+x*sym.modulePx, sym.y0+y*sym.modulePx,
+				(run-x)*sym.modulePx, sym.modulePx, (run-x)*sym.modulePx)
+			x = run
+		}
+	}
+	return sb.String()
+}
+
+// encodePDF writes a single-page PDF containing the image at LabelDPI.
+// The image is stored as a Flate-compressed DeviceRGB XObject.
+func encodePDF(w io.Writer, img image.Image) error {
+	b := img.Bounds()
+
+	// Raw RGB samples, compressed with zlib (PDF FlateDecode)
+	var pixels bytes.Buffer
+	zw := zlib.NewWriter(&pixels)
+	row := make([]byte, 0, b.Dx()*3)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row = row[:0]
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, a := img.At(x, y).RGBA()
+			// Composite transparent pixels over white paper
+			r, g, bl = r+0xFFFF-a, g+0xFFFF-a, bl+0xFFFF-a
+			row = append(row, byte(r>>8), byte(g>>8), byte(bl>>8))
+		}
+		if _, err := zw.Write(row); err != nil {
+			return fmt.Errorf("error compressing PDF image: %w", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("error compressing PDF image: %w", err)
+	}
+
+	// Page size in points (1/72 inch)
+	pageW := float64(b.Dx()) * 72 / LabelDPI
+	pageH := float64(b.Dy()) * 72 / LabelDPI
+	content := fmt.Sprintf("q %.2f 0 0 %.2f 0 0 cm /Im0 Do Q\n", pageW, pageH)
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /XObject << /Im0 5 0 R >> >> /Contents 4 0 R >>", pageW, pageH),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(content), content),
+		fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode /Length %d >>\nstream\n%s\nendstream",
+			b.Dx(), b.Dy(), pixels.Len(), pixels.String()),
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
@@ -0,0 +1,99 @@
+package generator
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/draw"
+	"image/png"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/lordbasex/HomeKitGenQRCode/pkg/homekit"
+)
+
+var (
+	svgImagePattern = regexp.MustCompile(`xlink:href="data:image/png;base64,([^"]+)"`)
+	svgPathPattern  = regexp.MustCompile(`<path [^>]*d="([^"]*)"`)
+	svgRectPattern  = regexp.MustCompile(`M(\d+) (\d+)h(\d+)v(\d+)h-\d+z`)
+)
+
+// TestEncodeLabelSVG checks that the SVG label draws its QR codes as vector
+// paths and that the background plus the paths match the PNG label.
+func TestEncodeLabelSVG(t *testing.T) {
+	info, err := homekit.ParseSetupInfo(5, "613-80-755", "ABCD", "AA:BB:CC:DD:EE:FF")
+	if err != nil {
+		t.Fatal(err)
+	}
+	label := NewLabel(info)
+	label.SecondQR = &SecondQR{Content: "WIFI:S:test;;", Caption: []string{"Wi-Fi: test"}}
+
+	var buf bytes.Buffer
+	if err := EncodeLabel(&buf, label, LabelOptions{}, FormatSVG); err != nil {
+		t.Fatal(err)
+	}
+	svg := buf.String()
+
+	paths := svgPathPattern.FindAllStringSubmatch(svg, -1)
+	if len(paths) != 2 {
+		t.Fatalf("SVG has %d QR paths, want 2", len(paths))
+	}
+	m := svgImagePattern.FindStringSubmatch(svg)
+	if m == nil {
+		t.Fatal("SVG has no background image")
+	}
+	data, err := base64.StdEncoding.DecodeString(m[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	bg, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Paint the paths onto the background and compare with the PNG label,
+	// both decoded from PNG so transparent pixels round the same way
+	got := toNRGBA(bg)
+	original := bytes.Clone(got.Pix)
+	for _, p := range paths {
+		for _, r := range svgRectPattern.FindAllStringSubmatch(p[1], -1) {
+			x, _ := strconv.Atoi(r[1])
+			y, _ := strconv.Atoi(r[2])
+			w, _ := strconv.Atoi(r[3])
+			h, _ := strconv.Atoi(r[4])
+			draw.Draw(got, image.Rect(x, y, x+w, y+h), image.Black, image.Point{}, draw.Src)
+		}
+	}
+	if bytes.Equal(got.Pix, original) {
+		t.Error("SVG QR paths draw nothing over the background")
+	}
+
+	img, err := RenderLabel(label, LabelOptions{NoVerify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raster bytes.Buffer
+	if err := png.Encode(&raster, img); err != nil {
+		t.Fatal(err)
+	}
+	want, err := png.Decode(&raster)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Pix, toNRGBA(want).Pix) {
+		t.Error("SVG background and QR paths do not match the PNG label")
+	}
+}
+
+func TestEncodeImageRejectsSVG(t *testing.T) {
+	if err := EncodeImage(&bytes.Buffer{}, image.NewRGBA(image.Rect(0, 0, 1, 1)), FormatSVG); err == nil {
+		t.Error("EncodeImage accepted SVG without the label's QR codes")
+	}
+}
+
+func toNRGBA(img image.Image) *image.NRGBA {
+	out := image.NewNRGBA(img.Bounds())
+	draw.Draw(out, out.Bounds(), img, img.Bounds().Min, draw.Src)
+	return out
+}
@@ -0,0 +1,85 @@
+package generator
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+
+	"github.com/lordbasex/HomeKitGenQRCode/pkg/homekit"
+)
+
+// NewMatterLabel builds the label values for a Matter device, generating a
+// random device code, serial number and CSN. The QR code carries the MT:
+// onboarding payload and the setup code is the formatted manual pairing code.
+// The category only selects the digit in the device code.
+func NewMatterLabel(payload homekit.MatterPayload, category int, mac string) (Label, error) {
+	uri, err := payload.QRCode()
+	if err != nil {
+		return Label{}, err
+	}
+	manual, err := payload.ManualCode()
+	if err != nil {
+		return Label{}, err
+	}
+	return Label{
+		SetupInfo:  homekit.SetupInfo{Category: category, SetupCode: homekit.FormatMatterManualCode(manual), MAC: mac},
+		URI:        uri,
+		Matter:     &payload,
+		DeviceCode: GenerateDeviceCode(category),
+		Serial:     GenerateSerial(),
+		CSN:        GenerateCSN(),
+	}, nil
+}
+
+// homeKitIconArea is the area of the template holding the HomeKit house icon,
+// in 842-pixel template units. It lies inside the QR frame, clear of its border.
+var homeKitIconArea = [4]float64{8, 14, 75, 72}
+
+// matterCodeWidth is the width (in template units) available to the manual
+// pairing code inside the QR frame.
+const matterCodeWidth = 150.0
+
+// clearHomeKitIcon removes the HomeKit house icon from the template, leaving
+// the area transparent.
+func clearHomeKitIcon(img *image.RGBA, scale float64) {
+	x0, y0 := scaleCoords(homeKitIconArea[0], homeKitIconArea[1], scale)
+	x1, y1 := scaleCoords(homeKitIconArea[2], homeKitIconArea[3], scale)
+	r := image.Rect(x0, y0, x1, y1).Intersect(img.Bounds())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetRGBA(x, y, color.RGBA{})
+		}
+	}
+}
+
+// drawMatterCode draws the manual pairing code in the top of the QR frame,
+// where HomeKit labels show the icon and setup code digits. An 11-digit code
+// is printed under the word Matter; a 21-digit code takes both rows. The font
+// is reduced until the longest row fits the frame.
+func drawMatterCode(img *image.RGBA, code string, fontSize, scale float64) error {
+	rows := []string{"Matter", code}
+	if parts := strings.Split(code, "-"); len(parts) > 3 {
+		rows = []string{strings.Join(parts[:3], "-"), strings.Join(parts[3:], "-")}
+	}
+
+	face, err := loadFontFace(textFontData, fontSize)
+	if err != nil {
+		return fmt.Errorf("error loading code font: %w", err)
+	}
+	widest := 0.0
+	for _, row := range rows {
+		widest = max(widest, measureStringWidth(face, row))
+	}
+	if limit := matterCodeWidth * scale; widest > limit {
+		if face, err = loadFontFace(textFontData, fontSize*limit/widest); err != nil {
+			return fmt.Errorf("error loading code font: %w", err)
+		}
+	}
+
+	for i, row := range rows {
+		cx, cy := scaleCoords(12, 12+float64(i)*27, scale)
+		drawTextWithFace(img, face, row, cx, cy, color.Black)
+	}
+	return nil
+}
@@ -12,8 +12,7 @@ import (
 	"strings"
 
 	"github.com/fogleman/gg"
-	qrcode "github.com/skip2/go-qrcode"
-	xdraw "golang.org/x/image/draw"
+	"github.com/lordbasex/HomeKitGenQRCode/pkg/homekit"
 	"golang.org/x/image/font"
 	"golang.org/x/image/font/opentype"
 	"golang.org/x/image/math/fixed"
@@ -84,225 +83,140 @@ func drawScaledTextOTF(img *image.RGBA, face font.Face, text string, x, y float6
 	drawTextWithFace(img, face, text, sx, sy, color.Black)
 }
 
-// GenerateHomeKitLabel generates a HomeKit QR code label matching the Python implementation.
-// This is the main function that creates the complete HomeKit setup label image.
-//
-// Parameters:
-//   - category: HomeKit device category ID
-//   - password: Setup password in format XXX-XX-XXX
-//   - setupID: Setup ID (4 alphanumeric characters)
-//   - mac: MAC address (12 hexadecimal characters)
-//   - output: Output image file path (PNG format)
-//
-// The function:
-//  1. Generates the HomeKit setup URI
-//  2. Generates device codes, serial numbers, and CSN
-//  3. Loads the template image and fonts
-//  4. Draws all text elements and barcodes
-//  5. Generates and positions the QR code
-//  6. Saves the final image
-func GenerateHomeKitLabel(category int, password, setupID, mac, output string) error {
-	// Generate URI and codes
-	uri := GenHomeKitSetupURI(category, password, setupID)
-	device := GenerateDeviceCode(category)
-	serial := GenerateSerial()
-	csn := GenerateCSN()
+// macRightEdge is the rightmost x coordinate (in 842-pixel template units)
+// available to the MAC text and barcode inside the label frame.
+const macRightEdge = 828.0
+
+// qrSlot is the square of the QR symbol on the template, in 842-pixel
+// template units: x0, y0 and side. It lies below the setup code digits and
+// left of the text column.
+var qrSlot = [3]float64{19, 77, 136}
+
+// qrAreaSide is the side, in template units, of the square centered on the
+// QR slot that holds the symbol and its quiet zone. The quiet zone extends
+// over the white margin around the slot.
+const qrAreaSide = 191.0
+
+// DefaultBrand is the brand line printed when LabelOptions.Brand is empty.
+const DefaultBrand = "Designed by StudioPeters"
+
+// LabelOptions controls optional aspects of label rendering.
+// The zero value renders the default label.
+type LabelOptions struct {
+	// MACStyle selects the human-readable MAC text (default homekit.MACStyleColon).
+	MACStyle homekit.MACStyle
+	// MACBarcodeStyle selects the MAC barcode content (default homekit.MACStyleBare).
+	// Code 39 cannot encode ':', so homekit.MACStyleColon is not allowed here.
+	MACBarcodeStyle homekit.MACStyle
+	// Brand is the brand line printed with a superscript ® (default DefaultBrand).
+	Brand string
+	// NoVerify skips reading back the QR code and barcodes after rendering
+	// (see VerifyLabel).
+	NoVerify bool
+	// QR selects the error correction level and version of the QR code.
+	QR QRSymbolOptions
+	// QRQuietZone is the light border around the QR code in modules
+	// (0 selects DefaultQuietZone).
+	QRQuietZone int
+	// QRBackground selects whether the QR code's light modules and quiet zone
+	// are painted white (default QRBackgroundTransparent).
+	QRBackground QRBackground
+}
 
-	// Load base template image from embedded data
-	templateImg, _, err := image.Decode(bytes.NewReader(templateImageData))
-	if err != nil {
-		return fmt.Errorf("error decoding template: %w", err)
+// withDefaults returns a copy of the options with empty fields set to their defaults.
+func (o LabelOptions) withDefaults() LabelOptions {
+	if o.MACStyle == "" {
+		o.MACStyle = homekit.MACStyleColon
 	}
-
-	// Calculate scale factor based on template width
-	// Base template width is 842 pixels, scale is calculated as templateWidth / 842
-	templateWidth := float64(templateImg.Bounds().Dx())
-	scale := templateWidth / 842.0
-
-	W := templateImg.Bounds().Dx()
-	H := templateImg.Bounds().Dy()
-
-	// Create new image context using gg library
-	dc := gg.NewContext(W, H)
-	dc.DrawImage(templateImg, 0, 0)
-
-	// Calculate font sizes based on scale factor
-	textFontSize := 18 * scale
-	barcodeFontSize := 36 * scale
-	superscriptFontSize := 8 * scale
-	codeFontSize := 28 * scale
-
-	// Load OTF font using opentype for proper OpenType support (from embedded data)
-	textFace, err := loadFontFace(textFontData, textFontSize)
-	if err != nil {
-		return fmt.Errorf("error loading text font: %w", err)
+	if o.MACBarcodeStyle == "" {
+		o.MACBarcodeStyle = homekit.MACStyleBare
 	}
-
-	// Load barcode font (TTF) from embedded data
-	barcodeFace, err := loadFontFace(barcodeFontData, barcodeFontSize)
-	if err != nil {
-		return fmt.Errorf("error loading barcode font: %w", err)
+	if o.Brand == "" {
+		o.Brand = DefaultBrand
 	}
-
-	// Load superscript font for trademark symbol (from embedded data)
-	superscriptFace, err := loadFontFace(textFontData, superscriptFontSize)
-	if err != nil {
-		// Fallback to regular text face if superscript fails
-		superscriptFace = textFace
+	if o.QR.Level == "" {
+		o.QR.Level = DefaultQRLevel
 	}
-
-	// Load code font for setup code digits (from embedded data)
-	codeFace, err := loadFontFace(textFontData, codeFontSize)
-	if err != nil {
-		return fmt.Errorf("error loading code font: %w", err)
+	if o.QRQuietZone == 0 {
+		o.QRQuietZone = DefaultQuietZone
 	}
-
-	// Generate QR code first (before converting to RGBA)
-	// Use Medium error correction (matching Python's ERROR_CORRECT_Q)
-	qr, err := qrcode.New(uri, qrcode.Medium)
-	if err != nil {
-		return fmt.Errorf("error generating QR code: %w", err)
+	if o.QRBackground == "" {
+		o.QRBackground = QRBackgroundTransparent
 	}
+	return o
+}
 
-	// Calculate final QR code size
-	// Increased to 653x653 pixels to better fill the available space while maintaining centering
-	qrFinalSize := 653
-
-	// Generate QR code with high resolution first
-	// Use a multiple of the final size to ensure we can scale down cleanly
-	// QR codes have 25x25 modules (version 2), so we want box_size to be a multiple
-	// that when scaled gives us sharp squares
-	// Generate at 4x resolution for better quality, then scale down
-	boxSizeMultiplier := 4
-	boxSize := (qrFinalSize / 25) * boxSizeMultiplier
-	if boxSize < boxSizeMultiplier {
-		boxSize = boxSizeMultiplier
+// Validate checks that the options can be rendered.
+func (o LabelOptions) Validate() error {
+	if o.MACBarcodeStyle == homekit.MACStyleColon {
+		return fmt.Errorf("MAC barcode style %q is not supported: Code 39 cannot encode ':'", o.MACBarcodeStyle)
 	}
-
-	// Generate QR image at high resolution
-	qrImgHighRes := qr.Image(boxSize)
-
-	// Make QR code background transparent (white pixels become transparent)
-	qrImgTransparent := makeQRTransparent(qrImgHighRes)
-
-	// Scale down using NearestNeighbor to preserve sharp square edges
-	// NearestNeighbor is perfect for QR codes as it doesn't smooth/blur the edges
-	qrImg := resizeQRCode(qrImgTransparent, qrFinalSize, qrFinalSize)
-
-	// Draw QR code directly on RGBA image to ensure it's properly included
-	// First convert gg context to RGBA
-	baseImg := dc.Image()
-	rgbaImg, ok := baseImg.(*image.RGBA)
-	if !ok {
-		// Convert to RGBA if not already
-		bounds := baseImg.Bounds()
-		rgbaImg = image.NewRGBA(bounds)
-		draw.Draw(rgbaImg, bounds, baseImg, bounds.Min, draw.Src)
+	if err := o.QR.Validate(); err != nil {
+		return err
 	}
-
-	// Now draw QR code on the RGBA image at the correct position
-	// Calculate center position based on original QR code position and size
-	// Original QR was at (19, 77) with size 136*scale
-	// We want to center the new larger QR code (qrFinalSize) at the same center point
-	originalQRSize := 136 * scale
-	originalQRX := 19 * scale
-	originalQRY := 77 * scale
-	originalCenterX := originalQRX + originalQRSize/2
-	originalCenterY := originalQRY + originalQRSize/2
-
-	// Calculate new position to center the larger QR code
-	qrX := int(originalCenterX - float64(qrFinalSize)/2)
-	qrY := int(originalCenterY - float64(qrFinalSize)/2)
-
-	qrBounds := qrImg.Bounds()
-	// Ensure QR code is drawn at exact size
-	qrDestRect := image.Rect(qrX, qrY, qrX+qrFinalSize, qrY+qrFinalSize)
-	draw.Draw(rgbaImg, qrDestRect, qrImg, qrBounds.Min, draw.Over)
-
-	// Get category name from reference map
-	categoryName := CategoryReference[category]
-	if categoryName == "" {
-		categoryName = "Unknown"
+	if o.QRQuietZone < 0 || o.QRQuietZone > 16 {
+		return fmt.Errorf("QR quiet zone of %d modules is out of range (1-16)", o.QRQuietZone)
 	}
-
-	// Positioning variables (matching Python code)
-	// These values define the layout and spacing of text elements
-	y := 6.0
-	x := 200.0
-	spacingTop := 20.0
-	spacingBody := 18.0
-	spacingExtra := 6.0
-	barcodeSpacing := 30.0
-
-	// Draw header text using OTF font
-	headerText := fmt.Sprintf("HomeKit %s | %s | WIFI", categoryName, device)
-	drawScaledTextOTF(rgbaImg, textFace, headerText, x, y, scale)
-	y += spacingTop
-
-	// Draw brand with superscript trademark symbol
-	brand := "Designed by StudioPeters"
-	drawScaledTextOTF(rgbaImg, textFace, brand, x, y, scale)
-
-	// Get brand width for superscript positioning
-	brandWidth := measureStringWidth(textFace, brand)
-	supRX := x*scale + brandWidth
-	supRY := y*scale + 3*scale
-
-	// Draw ® with superscript font
-	drawTextWithFace(rgbaImg, superscriptFace, "®", int(supRX), int(supRY), color.Black)
-
-	y += spacingTop
-	drawScaledTextOTF(rgbaImg, textFace, "Assembled in the Netherlands", x, y, scale)
-	y += spacingTop
-
-	// Draw device code
-	drawScaledTextOTF(rgbaImg, textFace, fmt.Sprintf("(1P)%s", device), x, y, scale)
-
-	// Draw MAC address if provided
-	if mac != "" {
-		// Format MAC address (add colons every 2 characters)
-		formattedMAC := formatMAC(mac)
-		drawScaledTextOTF(rgbaImg, textFace, fmt.Sprintf("MAC: %s", formattedMAC), 560, y, scale)
-
-		// Draw MAC barcode
-		drawScaledTextOTF(rgbaImg, barcodeFace, fmt.Sprintf("*%s*", strings.ToUpper(mac)), 560, y+spacingBody+spacingExtra, scale)
+	if o.QRBackground != "" {
+		if _, err := ParseQRBackground(string(o.QRBackground)); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	y += spacingBody + spacingExtra
-
-	// Draw device code barcode
-	drawScaledTextOTF(rgbaImg, barcodeFace, fmt.Sprintf("*%s*", device), x, y, scale)
-
-	y += barcodeSpacing
-
-	// Draw serial number
-	drawScaledTextOTF(rgbaImg, textFace, fmt.Sprintf("(S) Serial No. %s", serial), x, y, scale)
-	y += spacingBody + spacingExtra
-
-	// Draw serial barcode
-	drawScaledTextOTF(rgbaImg, barcodeFace, fmt.Sprintf("*%s*", serial), x, y, scale)
-
-	y += barcodeSpacing
-
-	// Draw CSN (Customer Serial Number)
-	drawScaledTextOTF(rgbaImg, textFace, fmt.Sprintf("CSN %s", csn), x, y, scale)
-	y += spacingBody + spacingExtra
-
-	// Draw CSN barcode
-	drawScaledTextOTF(rgbaImg, barcodeFace, fmt.Sprintf("*%s*", csn), x, y, scale)
+// Label is the complete set of values printed on a label: the pairing data
+// plus the setup URI encoded in the QR code and the generated device code,
+// serial number and CSN.
+type Label struct {
+	homekit.SetupInfo
+	URI        string
+	DeviceCode string
+	Serial     string
+	CSN        string
+	// Matter is set for Matter devices (see NewMatterLabel), which use the
+	// Matter layout: URI is the MT: payload and SetupCode the manual pairing code.
+	Matter *homekit.MatterPayload
+	// SecondQR is an optional second QR code, such as a Wi-Fi provisioning
+	// payload, printed on the right of the label.
+	SecondQR *SecondQR
+}
 
-	// Draw setup code digits (password without dashes) using OTF font
-	code := strings.ReplaceAll(password, "-", "")
+// NewLabel builds the label values for info, generating a random device code,
+// serial number and CSN. A valid setup code and setup ID are printed in their
+// normalized form (XXX-XX-XXX, uppercase), whatever form info uses.
+func NewLabel(info homekit.SetupInfo) Label {
+	if code, err := homekit.NormalizeSetupCode(info.SetupCode); err == nil {
+		info.SetupCode = code
+	}
+	if id, err := homekit.NormalizeSetupID(info.SetupID); err == nil {
+		info.SetupID = id
+	}
+	return Label{
+		SetupInfo:  info,
+		URI:        homekit.GenHomeKitSetupURI(info.Category, info.SetupCode, info.SetupID),
+		DeviceCode: GenerateDeviceCode(info.Category),
+		Serial:     GenerateSerial(),
+		CSN:        GenerateCSN(),
+	}
+}
 
-	// Draw code digits in two rows (4 digits per row)
-	for i := 0; i < 4; i++ {
-		// Top row
-		cx, cy := scaleCoords(76+float64(i)*20, 12, scale)
-		drawTextWithFace(rgbaImg, codeFace, string(code[i]), cx, cy, color.Black)
+// GenerateHomeKitLabel generates a HomeKit QR code label matching the Python implementation
+// and saves it as a PNG file. The output directory is created if needed.
+//
+// Parameters:
+//   - info: Category, setup code (XXX-XX-XXX), setup ID and MAC address
+//   - output: Output image file path (PNG format)
+//   - opts: Rendering options (zero value for defaults)
+func GenerateHomeKitLabel(info homekit.SetupInfo, output string, opts LabelOptions) error {
+	return SaveLabel(NewLabel(info), output, opts)
+}
 
-		// Bottom row
-		cx, cy = scaleCoords(76+float64(i)*20, 39, scale)
-		drawTextWithFace(rgbaImg, codeFace, string(code[i+4]), cx, cy, color.Black)
+// SaveLabel renders label and saves it as a PNG file, creating the output directory if needed.
+func SaveLabel(label Label, output string, opts LabelOptions) error {
+	rgbaImg, err := RenderLabel(label, opts)
+	if err != nil {
+		return err
 	}
 
 	// Create output directory if needed
@@ -313,7 +227,7 @@ func GenerateHomeKitLabel(category int, password, setupID, mac, output string) e
 		}
 	}
 
-	// Save image (use rgbaImg which has all the text drawn)
+	// Save image
 	out, err := os.Create(output)
 	if err != nil {
 		return fmt.Errorf("error creating output file: %w", err)
@@ -328,13 +242,48 @@ func GenerateHomeKitLabel(category int, password, setupID, mac, output string) e
 
 // GenerateHomeKitLabelBytes generates a HomeKit QR code label and returns PNG bytes.
 // This version is used for WASM where filesystem access is limited.
-// It reuses all the logic from GenerateHomeKitLabel but returns bytes instead of saving to file.
-func GenerateHomeKitLabelBytes(category int, password, setupID, mac string) ([]byte, error) {
-	// Generate URI and codes
-	uri := GenHomeKitSetupURI(category, password, setupID)
-	device := GenerateDeviceCode(category)
-	serial := GenerateSerial()
-	csn := GenerateCSN()
+func GenerateHomeKitLabelBytes(info homekit.SetupInfo, opts LabelOptions) ([]byte, error) {
+	rgbaImg, err := RenderHomeKitLabel(info, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, rgbaImg); err != nil {
+		return nil, fmt.Errorf("error encoding PNG: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// RenderHomeKitLabel renders the complete HomeKit setup label image with a
+// random device code, serial number and CSN. See RenderLabel.
+func RenderHomeKitLabel(info homekit.SetupInfo, opts LabelOptions) (*image.RGBA, error) {
+	return RenderLabel(NewLabel(info), opts)
+}
+
+// RenderLabel renders the complete HomeKit setup label image.
+// This is the main function shared by GenerateHomeKitLabel and GenerateHomeKitLabelBytes.
+//
+// The function:
+//  1. Loads the template image and fonts
+//  2. Generates and positions the QR code for the setup URI
+//  3. Draws all text elements and barcodes, and the second QR code if any
+//  4. Reads the QR code and barcodes back unless opts.NoVerify is set
+func RenderLabel(label Label, opts LabelOptions) (*image.RGBA, error) {
+	return renderLabel(label, opts, nil)
+}
+
+// renderLabel implements RenderLabel. When symbols is not nil the QR codes
+// drawn on the label are appended to it for vector output.
+func renderLabel(label Label, opts LabelOptions, symbols *[]qrSymbol) (*image.RGBA, error) {
+	category, password, mac := label.Category, label.SetupCode, label.MAC
+	uri, device, serial, csn := label.URI, label.DeviceCode, label.Serial, label.CSN
+
+	opts = opts.withDefaults()
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
 
 	// Load base template image from embedded data
 	templateImg, _, err := image.Decode(bytes.NewReader(templateImageData))
@@ -343,6 +292,7 @@ func GenerateHomeKitLabelBytes(category int, password, setupID, mac string) ([]b
 	}
 
 	// Calculate scale factor based on template width
+	// Base template width is 842 pixels, scale is calculated as templateWidth / 842
 	templateWidth := float64(templateImg.Bounds().Dx())
 	scale := templateWidth / 842.0
 
@@ -359,74 +309,69 @@ func GenerateHomeKitLabelBytes(category int, password, setupID, mac string) ([]b
 	superscriptFontSize := 8 * scale
 	codeFontSize := 28 * scale
 
-	// Load fonts from embedded data
+	// Load OTF font using opentype for proper OpenType support (from embedded data)
 	textFace, err := loadFontFace(textFontData, textFontSize)
 	if err != nil {
 		return nil, fmt.Errorf("error loading text font: %w", err)
 	}
 
+	// Load barcode font (TTF) from embedded data
 	barcodeFace, err := loadFontFace(barcodeFontData, barcodeFontSize)
 	if err != nil {
 		return nil, fmt.Errorf("error loading barcode font: %w", err)
 	}
 
+	// Load superscript font for trademark symbol (from embedded data)
 	superscriptFace, err := loadFontFace(textFontData, superscriptFontSize)
 	if err != nil {
+		// Fallback to regular text face if superscript fails
 		superscriptFace = textFace
 	}
 
+	// Load code font for setup code digits (from embedded data)
 	codeFace, err := loadFontFace(textFontData, codeFontSize)
 	if err != nil {
 		return nil, fmt.Errorf("error loading code font: %w", err)
 	}
 
-	// Generate QR code
-	qr, err := qrcode.New(uri, qrcode.Medium)
+	// Encode the setup URI; the modules are drawn after converting to RGBA
+	modules, err := EncodeQR(uri, opts.QR)
 	if err != nil {
-		return nil, fmt.Errorf("error generating QR code: %w", err)
+		return nil, err
 	}
 
-	qrFinalSize := 653
-	boxSizeMultiplier := 4
-	boxSize := (qrFinalSize / 25) * boxSizeMultiplier
-	if boxSize < boxSizeMultiplier {
-		boxSize = boxSizeMultiplier
-	}
+	// The symbol fills the QR slot; its quiet zone extends over the margin
+	// around it, up to qrAreaSide
+	qrSlotSize := int(qrSlot[2] * scale)
+	qrAreaSize := int(qrAreaSide * scale)
+	qrCenterX := int((qrSlot[0] + qrSlot[2]/2) * scale)
+	qrCenterY := int((qrSlot[1] + qrSlot[2]/2) * scale)
 
-	qrImgHighRes := qr.Image(boxSize)
-	qrImgTransparent := makeQRTransparent(qrImgHighRes)
-	qrImg := resizeQRCode(qrImgTransparent, qrFinalSize, qrFinalSize)
-
-	// Convert gg context to RGBA
+	// Draw QR code directly on RGBA image to ensure it's properly included
+	// First convert gg context to RGBA
 	baseImg := dc.Image()
 	rgbaImg, ok := baseImg.(*image.RGBA)
 	if !ok {
+		// Convert to RGBA if not already
 		bounds := baseImg.Bounds()
 		rgbaImg = image.NewRGBA(bounds)
 		draw.Draw(rgbaImg, bounds, baseImg, bounds.Min, draw.Src)
 	}
+	if label.Matter != nil {
+		clearHomeKitIcon(rgbaImg, scale)
+	}
 
-	// Draw QR code
-	originalQRSize := 136 * scale
-	originalQRX := 19 * scale
-	originalQRY := 77 * scale
-	originalCenterX := originalQRX + originalQRSize/2
-	originalCenterY := originalQRY + originalQRSize/2
-
-	qrX := int(originalCenterX - float64(qrFinalSize)/2)
-	qrY := int(originalCenterY - float64(qrFinalSize)/2)
-
-	qrBounds := qrImg.Bounds()
-	qrDestRect := image.Rect(qrX, qrY, qrX+qrFinalSize, qrY+qrFinalSize)
-	draw.Draw(rgbaImg, qrDestRect, qrImg, qrBounds.Min, draw.Over)
-
-	// Get category name
-	categoryName := CategoryReference[category]
-	if categoryName == "" {
-		categoryName = "Unknown"
+	// The template is rendered at LabelDPI, so whole-pixel modules are whole
+	// printer dots
+	if err := drawQRModules(rgbaImg, modules, qrCenterX, qrCenterY, qrSlotSize, qrAreaSize, opts.QRQuietZone, opts.QRBackground, symbols); err != nil {
+		return nil, err
 	}
 
-	// Positioning variables
+	// Get category name from reference map
+	categoryName := homekit.CategoryName(category)
+
+	// Positioning variables (matching Python code)
+	// These values define the layout and spacing of text elements
 	y := 6.0
 	x := 200.0
 	spacingTop := 20.0
@@ -434,17 +379,24 @@ func GenerateHomeKitLabelBytes(category int, password, setupID, mac string) ([]b
 	spacingExtra := 6.0
 	barcodeSpacing := 30.0
 
-	// Draw header text
+	// Draw header text using OTF font
 	headerText := fmt.Sprintf("HomeKit %s | %s | WIFI", categoryName, device)
+	if label.Matter != nil {
+		headerText = fmt.Sprintf("Matter | %s | WIFI", device)
+	}
 	drawScaledTextOTF(rgbaImg, textFace, headerText, x, y, scale)
 	y += spacingTop
 
-	// Draw brand with trademark
-	brand := "Designed by StudioPeters"
+	// Draw brand with superscript trademark symbol
+	brand := opts.Brand
 	drawScaledTextOTF(rgbaImg, textFace, brand, x, y, scale)
+
+	// Get brand width for superscript positioning
 	brandWidth := measureStringWidth(textFace, brand)
 	supRX := x*scale + brandWidth
 	supRY := y*scale + 3*scale
+
+	// Draw ® with superscript font
 	drawTextWithFace(rgbaImg, superscriptFace, "®", int(supRX), int(supRY), color.Black)
 
 	y += spacingTop
@@ -456,9 +408,29 @@ func GenerateHomeKitLabelBytes(category int, password, setupID, mac string) ([]b
 
 	// Draw MAC address if provided
 	if mac != "" {
-		formattedMAC := formatMAC(mac)
-		drawScaledTextOTF(rgbaImg, textFace, fmt.Sprintf("MAC: %s", formattedMAC), 560, y, scale)
-		drawScaledTextOTF(rgbaImg, barcodeFace, fmt.Sprintf("*%s*", strings.ToUpper(mac)), 560, y+spacingBody+spacingExtra, scale)
+		addr, err := homekit.ParseMAC(mac)
+		if err != nil {
+			return nil, err
+		}
+
+		// Format MAC address text and barcode content per the chosen styles
+		macText := fmt.Sprintf("MAC: %s", addr.Format(opts.MACStyle))
+		macBarcode := fmt.Sprintf("*%s*", addr.Format(opts.MACBarcodeStyle))
+
+		// Longer addresses (EUI-64) are shifted left so the barcode stays inside the frame
+		macX := 560.0
+		macWidth := measureStringWidth(barcodeFace, macBarcode) / scale
+		if textWidth := measureStringWidth(textFace, macText) / scale; textWidth > macWidth {
+			macWidth = textWidth
+		}
+		if macX+macWidth > macRightEdge {
+			macX = macRightEdge - macWidth
+		}
+
+		drawScaledTextOTF(rgbaImg, textFace, macText, macX, y, scale)
+
+		// Draw MAC barcode
+		drawScaledTextOTF(rgbaImg, barcodeFace, macBarcode, macX, y+spacingBody+spacingExtra, scale)
 	}
 
 	y += spacingBody + spacingExtra
@@ -477,29 +449,47 @@ func GenerateHomeKitLabelBytes(category int, password, setupID, mac string) ([]b
 
 	y += barcodeSpacing
 
-	// Draw CSN
+	// Draw CSN (Customer Serial Number)
 	drawScaledTextOTF(rgbaImg, textFace, fmt.Sprintf("CSN %s", csn), x, y, scale)
 	y += spacingBody + spacingExtra
 
 	// Draw CSN barcode
 	drawScaledTextOTF(rgbaImg, barcodeFace, fmt.Sprintf("*%s*", csn), x, y, scale)
 
-	// Draw setup code digits
-	code := strings.ReplaceAll(password, "-", "")
-	for i := 0; i < 4; i++ {
-		cx, cy := scaleCoords(76+float64(i)*20, 12, scale)
-		drawTextWithFace(rgbaImg, codeFace, string(code[i]), cx, cy, color.Black)
-		cx, cy = scaleCoords(76+float64(i)*20, 39, scale)
-		drawTextWithFace(rgbaImg, codeFace, string(code[i+4]), cx, cy, color.Black)
+	if label.SecondQR != nil {
+		if err := drawSecondQR(rgbaImg, *label.SecondQR, opts, textFontSize, scale, symbols); err != nil {
+			return nil, err
+		}
 	}
 
-	// Encode to PNG bytes instead of saving to file
-	var buf bytes.Buffer
-	if err := png.Encode(&buf, rgbaImg); err != nil {
-		return nil, fmt.Errorf("error encoding PNG: %w", err)
+	if label.Matter != nil {
+		// Matter labels show the manual pairing code in place of the icon and digits
+		if err := drawMatterCode(rgbaImg, password, codeFontSize, scale); err != nil {
+			return nil, err
+		}
+	} else {
+		// Draw setup code digits (password without dashes) using OTF font
+		code := strings.ReplaceAll(password, "-", "")
+
+		// Draw code digits in two rows (4 digits per row)
+		for i := 0; i < 4; i++ {
+			// Top row
+			cx, cy := scaleCoords(76+float64(i)*20, 12, scale)
+			drawTextWithFace(rgbaImg, codeFace, string(code[i]), cx, cy, color.Black)
+
+			// Bottom row
+			cx, cy = scaleCoords(76+float64(i)*20, 39, scale)
+			drawTextWithFace(rgbaImg, codeFace, string(code[i+4]), cx, cy, color.Black)
+		}
 	}
 
-	return buf.Bytes(), nil
+	if !opts.NoVerify {
+		if err := VerifyLabel(rgbaImg, label, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	return rgbaImg, nil
 }
 
 // measureStringWidth measures the width of a string using a font face.
@@ -514,52 +504,3 @@ func measureStringWidth(face font.Face, text string) float64 {
 	}
 	return float64(width) / 64.0
 }
-
-// formatMAC formats a MAC address string by adding colons every 2 characters.
-// Example: "AABBCCDDEEFF" -> "AA:BB:CC:DD:EE:FF"
-// If the MAC address is not 12 characters, returns it unchanged.
-func formatMAC(mac string) string {
-	if len(mac) != 12 {
-		return mac
-	}
-	var parts []string
-	for i := 0; i < 12; i += 2 {
-		parts = append(parts, mac[i:i+2])
-	}
-	return strings.ToUpper(strings.Join(parts, ":"))
-}
-
-// makeQRTransparent converts white pixels to transparent in QR code image.
-// This allows the QR code to blend seamlessly with the template background.
-// Pixels with RGB values greater than 200 are considered white and made transparent.
-func makeQRTransparent(img image.Image) image.Image {
-	bounds := img.Bounds()
-	rgba := image.NewRGBA(bounds)
-
-	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-		for x := bounds.Min.X; x < bounds.Max.X; x++ {
-			c := img.At(x, y)
-			r, g, b, _ := c.RGBA()
-
-			// If pixel is mostly white (RGB > 200), make it transparent
-			if r>>8 > 200 && g>>8 > 200 && b>>8 > 200 {
-				rgba.Set(x, y, color.RGBA{255, 255, 255, 0})
-			} else {
-				rgba.Set(x, y, c)
-			}
-		}
-	}
-
-	return rgba
-}
-
-// resizeQRCode resizes a QR code image preserving sharp square edges.
-// Uses NearestNeighbor algorithm to avoid smoothing/rounding the square modules.
-// This is essential for QR codes to maintain their scannable appearance.
-func resizeQRCode(img image.Image, width, height int) image.Image {
-	dst := image.NewRGBA(image.Rect(0, 0, width, height))
-	// Use NearestNeighbor for QR codes to preserve sharp square edges
-	// This prevents the "rounded" appearance that happens with interpolation
-	xdraw.NearestNeighbor.Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Over, nil)
-	return dst
-}
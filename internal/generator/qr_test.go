@@ -0,0 +1,72 @@
+package generator
+
+import (
+	"image"
+	"testing"
+
+	"github.com/lordbasex/HomeKitGenQRCode/internal/scan"
+	"github.com/lordbasex/HomeKitGenQRCode/pkg/homekit"
+)
+
+// TestRenderLabelQRVersions checks that forced QR versions stay inside the QR
+// slot, clear of the icon, digits and frame, and read back.
+func TestRenderLabelQRVersions(t *testing.T) {
+	info, err := homekit.ParseSetupInfo(5, "613-80-755", "ABCD", "8E:17:87:A9:C4:32")
+	if err != nil {
+		t.Fatal(err)
+	}
+	label := NewLabel(info)
+	tests := []struct {
+		version int
+		level   scan.Level
+	}{
+		{7, scan.LevelL},
+		{7, scan.LevelM},
+		{8, scan.LevelH},
+		{10, scan.LevelM},
+		{20, scan.LevelM},
+		{40, scan.LevelL},
+	}
+	for _, tt := range tests {
+		opts := LabelOptions{QR: QRSymbolOptions{Level: tt.level, Version: tt.version}}
+		var symbols []qrSymbol
+		img, err := renderLabel(label, opts, &symbols)
+		if err != nil {
+			t.Errorf("version %d-%s: %v", tt.version, tt.level, err)
+			continue
+		}
+		scale := float64(img.Bounds().Dx()) / 842
+		x0, y0 := scaleCoords(qrSlot[0], qrSlot[1], scale)
+		slot := image.Rect(x0, y0, x0+int(qrSlot[2]*scale), y0+int(qrSlot[2]*scale))
+		sym := symbols[0]
+		side := len(sym.modules) * sym.modulePx
+		if r := image.Rect(sym.x0, sym.y0, sym.x0+side, sym.y0+side); !r.In(slot) {
+			t.Errorf("version %d-%s: symbol %v outside the QR slot %v", tt.version, tt.level, r, slot)
+		}
+	}
+}
+
+// TestGenerateLabelUnnormalizedInput checks that a plain setup code and a
+// lowercase setup ID, which validation accepts, render and verify.
+func TestGenerateLabelUnnormalizedInput(t *testing.T) {
+	info := homekit.SetupInfo{Category: 5, SetupCode: "61380755", SetupID: "abcd", MAC: "8E:17:87:A9:C4:32"}
+	if err := info.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	label := NewLabel(info)
+	if label.SetupCode != "613-80-755" || label.SetupID != "ABCD" {
+		t.Errorf("NewLabel setup code/ID = %s/%s, want 613-80-755/ABCD", label.SetupCode, label.SetupID)
+	}
+	if label.URI != "X-HM://0053158R7ABCD" {
+		t.Errorf("NewLabel URI = %s, want X-HM://0053158R7ABCD", label.URI)
+	}
+	if _, err := GenerateHomeKitLabelBytes(info, LabelOptions{}); err != nil {
+		t.Errorf("GenerateHomeKitLabelBytes: %v", err)
+	}
+
+	// Labels built without NewLabel are verified against the same values
+	label.SetupCode, label.SetupID = info.SetupCode, info.SetupID
+	if _, err := expectedURI(label); err != nil {
+		t.Errorf("expectedURI: %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"regexp"
+	"strings"
+
+	"github.com/lordbasex/HomeKitGenQRCode/internal/scan"
+	"github.com/lordbasex/HomeKitGenQRCode/pkg/homekit"
+)
+
+// ErrNoSetupCode is returned by ReadLabel when an image contains no HomeKit
+// setup QR code.
+var ErrNoSetupCode = errors.New("no HomeKit setup QR code found")
+
+// Barcode text formats produced by GenerateDeviceCode, GenerateSerial and
+// GenerateCSN, used to tell the label barcodes apart.
+var (
+	deviceCodePattern = regexp.MustCompile(`^[A-Z]{2}\d+[A-Z]\d[A-Z]{2}/[A-Z]$`)
+	serialPattern     = regexp.MustCompile(`^[A-Z]\d[A-Z]{3}\d[A-Z]\d{3}[A-Z]{2}$`)
+	csnPattern        = regexp.MustCompile(`^\d{20}[A-Z]{3}\d{4}[A-Z]\d[A-Z]\d{3}$`)
+)
+
+// ScannedLabel is the pairing data read from a photo or scan of a label.
+// Fields whose barcode could not be read are empty.
+type ScannedLabel struct {
+	URI        string
+	Payload    homekit.SetupPayload
+	MAC        string
+	DeviceCode string
+	Serial     string
+	CSN        string
+	// QR is the decoded setup QR code, with its position in the image.
+	QR scan.QRCode
+}
+
+// ReadLabel locates the HomeKit setup QR code in img, which may be a rotated,
+// oblique or poorly lit photo, and decodes its payload. Barcodes printed
+// around the QR code are read from a rectified copy of the image and matched
+// to the device code, serial number, CSN and MAC by their format.
+func ReadLabel(img image.Image) (ScannedLabel, error) {
+	codes, err := scan.DecodeQRCodes(img)
+	if err != nil {
+		return ScannedLabel{}, fmt.Errorf("%w: %w", ErrNoSetupCode, err)
+	}
+
+	var result ScannedLabel
+	found := false
+	for _, code := range codes {
+		payload, err := homekit.DecodeSetupURI(code.Text)
+		if err == nil {
+			result = ScannedLabel{URI: strings.ToUpper(strings.TrimSpace(code.Text)), Payload: payload, QR: code}
+			found = true
+			break
+		}
+	}
+	if !found {
+		return ScannedLabel{}, fmt.Errorf("%w (QR code contains %q)", ErrNoSetupCode, codes[0].Text)
+	}
+
+	// The label template puts the QR code at the bottom left, with the
+	// barcodes above and to the right of it
+	var barcodes []scan.Barcode
+	if rectified, err := scan.Rectify(img, result.QR, 0.2, 0.6, 5.6, 0.2); err == nil {
+		barcodes = scan.ReadBarcodes(rectified)
+	} else {
+		barcodes = scan.ReadBarcodes(img)
+	}
+	for _, b := range barcodes {
+		if b.Symbology != scan.Code39 {
+			continue
+		}
+		switch {
+		case deviceCodePattern.MatchString(b.Text):
+			result.DeviceCode = firstNonEmpty(result.DeviceCode, b.Text)
+		case serialPattern.MatchString(b.Text):
+			result.Serial = firstNonEmpty(result.Serial, b.Text)
+		case csnPattern.MatchString(b.Text):
+			result.CSN = firstNonEmpty(result.CSN, b.Text)
+		default:
+			if mac, err := homekit.NormalizeMAC(b.Text); err == nil {
+				result.MAC = firstNonEmpty(result.MAC, mac)
+			}
+		}
+	}
+	return result, nil
+}
+
+// firstNonEmpty returns a if it is set, and b otherwise.
+func firstNonEmpty(a, b string) string {
+	if a != "" {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,64 @@
+package generator
+
+import (
+	"fmt"
+	"image"
+)
+
+// SecondQR is an optional second QR code printed in the free area on the
+// right of the label, between the MAC barcode and the CSN barcode, such as a
+// Wi-Fi provisioning payload. Up to two caption lines are printed to its left.
+type SecondQR struct {
+	Content string
+	Caption []string
+}
+
+// secondQRArea is the square area of the second QR code including its quiet
+// zone, in 842-pixel template units: x0, y0 and side. It lies below the MAC
+// barcode and above the CSN barcode, clear of the serial number barcode.
+var secondQRArea = [3]float64{742, 114, 82}
+
+// secondQRCaptionX is the x coordinate (in template units) of the caption,
+// aligned with the MAC address column.
+const secondQRCaptionX = 560.0
+
+// maxSecondQRCaption is the number of caption lines printed.
+const maxSecondQRCaption = 2
+
+// drawSecondQR draws the second QR code and its caption. The caption font is
+// reduced until the longest line fits between the caption column and the QR
+// code.
+func drawSecondQR(img *image.RGBA, second SecondQR, opts LabelOptions, fontSize, scale float64, symbols *[]qrSymbol) error {
+	if len(second.Caption) > maxSecondQRCaption {
+		return fmt.Errorf("second QR code caption has %d lines (at most %d)", len(second.Caption), maxSecondQRCaption)
+	}
+	modules, err := EncodeQR(second.Content, QRSymbolOptions{Level: opts.QR.Level})
+	if err != nil {
+		return fmt.Errorf("second QR code: %w", err)
+	}
+
+	x0, y0 := scaleCoords(secondQRArea[0], secondQRArea[1], scale)
+	size := int(secondQRArea[2] * scale)
+	if err := drawQRModules(img, modules, x0+size/2, y0+size/2, size, size, DefaultQuietZone, opts.QRBackground, symbols); err != nil {
+		return fmt.Errorf("second QR code: %w", err)
+	}
+
+	face, err := loadFontFace(textFontData, fontSize)
+	if err != nil {
+		return fmt.Errorf("error loading text font: %w", err)
+	}
+	widest := 0.0
+	for _, line := range second.Caption {
+		widest = max(widest, measureStringWidth(face, line))
+	}
+	limit := (secondQRArea[0] - secondQRCaptionX) * scale
+	if widest > limit {
+		if face, err = loadFontFace(textFontData, fontSize*limit/widest); err != nil {
+			return fmt.Errorf("error loading text font: %w", err)
+		}
+	}
+	for i, line := range second.Caption {
+		drawScaledTextOTF(img, face, line, secondQRCaptionX, secondQRArea[1]+12+float64(i)*24, scale)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package generator
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+
+	"github.com/lordbasex/HomeKitGenQRCode/internal/scan"
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// DefaultQRLevel is the error correction level of the label QR code. The
+// original Python implementation used Q; M keeps the setup URI at version 1.
+const DefaultQRLevel = scan.LevelM
+
+// QRBackground selects how the light modules and quiet zone of the label QR
+// code are drawn.
+type QRBackground string
+
+// Supported QR backgrounds.
+const (
+	QRBackgroundTransparent QRBackground = "transparent" // Light modules show the template underneath
+	QRBackgroundOpaque      QRBackground = "opaque"      // Light modules and quiet zone are painted white
+)
+
+// ParseQRBackground converts a background name (case-insensitive) into a
+// QRBackground. An empty name selects QRBackgroundTransparent.
+func ParseQRBackground(name string) (QRBackground, error) {
+	switch QRBackground(strings.ToLower(strings.TrimSpace(name))) {
+	case "", QRBackgroundTransparent:
+		return QRBackgroundTransparent, nil
+	case QRBackgroundOpaque:
+		return QRBackgroundOpaque, nil
+	}
+	return "", fmt.Errorf("unknown QR background %q. Expected transparent or opaque", name)
+}
+
+// QRSymbolOptions controls how content is encoded as a QR symbol.
+// The zero value selects DefaultQRLevel and the smallest version that fits.
+type QRSymbolOptions struct {
+	// Level is the error correction level (default DefaultQRLevel).
+	Level scan.Level
+	// Version forces the symbol version (1-40); 0 selects the smallest that fits.
+	Version int
+	// MinVersion is the smallest version used when Version is 0.
+	MinVersion int
+}
+
+// Validate checks the level and versions.
+func (o QRSymbolOptions) Validate() error {
+	if o.Level != "" {
+		if _, err := scan.ParseLevel(string(o.Level)); err != nil {
+			return err
+		}
+	}
+	if o.Version < 0 || o.Version > 40 {
+		return fmt.Errorf("QR version %d is out of range (1-40)", o.Version)
+	}
+	if o.MinVersion < 0 || o.MinVersion > 40 {
+		return fmt.Errorf("minimum QR version %d is out of range (1-40)", o.MinVersion)
+	}
+	return nil
+}
+
+// recoveryLevel maps an error correction level to the encoder's level.
+func recoveryLevel(l scan.Level) qrcode.RecoveryLevel {
+	switch l {
+	case scan.LevelL:
+		return qrcode.Low
+	case scan.LevelQ:
+		return qrcode.High
+	case scan.LevelH:
+		return qrcode.Highest
+	default:
+		return qrcode.Medium
+	}
+}
+
+// EncodeQR encodes content as a QR symbol and returns its modules without a
+// quiet zone, indexed [y][x], with true for dark modules.
+func EncodeQR(content string, opts QRSymbolOptions) ([][]bool, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.Level == "" {
+		opts.Level = DefaultQRLevel
+	}
+	level := recoveryLevel(opts.Level)
+
+	var qr *qrcode.QRCode
+	var err error
+	if opts.Version > 0 {
+		qr, err = qrcode.NewWithForcedVersion(content, opts.Version, level)
+	} else {
+		qr, err = qrcode.New(content, level)
+		if err == nil && qr.VersionNumber < opts.MinVersion {
+			qr, err = qrcode.NewWithForcedVersion(content, opts.MinVersion, level)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error generating QR code: %w", err)
+	}
+	qr.DisableBorder = true
+	return qr.Bitmap(), nil
+}
+
+// drawQRModules draws modules centered at (cx, cy) so that the symbol fits in
+// a square of side slot and the symbol with a quiet zone of quietZone modules
+// fits in a square of side area. Modules are a whole number of pixels wide,
+// so they stay sharp and equal at the print resolution. The symbol is made as
+// large as fits with at least a DefaultQuietZone border, so a narrower quiet
+// zone never grows it into the surrounding artwork. With an opaque background
+// the light modules and quiet zone are backed with white, keeping any
+// template artwork they overlap; otherwise only the dark modules are drawn.
+// When symbols is not nil the placement is appended to it for vector output
+// (see EncodeLabel).
+func drawQRModules(img *image.RGBA, modules [][]bool, cx, cy, slot, area, quietZone int, background QRBackground, symbols *[]qrSymbol) error {
+	total := len(modules) + 2*quietZone
+	modulePx := min(slot/len(modules), area/(len(modules)+2*max(quietZone, DefaultQuietZone)))
+	if modulePx < 1 {
+		return fmt.Errorf("QR code version %d (%d modules) with a %d-module quiet zone does not fit in %d pixels at 1 pixel per module",
+			(len(modules)-17)/4, len(modules), quietZone, min(slot, area))
+	}
+
+	side := total * modulePx
+	x0, y0 := cx-side/2, cy-side/2
+	if background == QRBackgroundOpaque {
+		fillUnder(img, image.Rect(x0, y0, x0+side, y0+side), color.RGBA{255, 255, 255, 255})
+	}
+	if symbols != nil {
+		area := image.Rect(x0, y0, x0+side, y0+side).Intersect(img.Bounds())
+		under := image.NewRGBA(area)
+		draw.Draw(under, area, img, area.Min, draw.Src)
+		*symbols = append(*symbols, qrSymbol{
+			modules:  modules,
+			x0:       x0 + quietZone*modulePx,
+			y0:       y0 + quietZone*modulePx,
+			modulePx: modulePx,
+			under:    under,
+		})
+	}
+	for y, row := range modules {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			px := x0 + (x+quietZone)*modulePx
+			py := y0 + (y+quietZone)*modulePx
+			fillRect(img, image.Rect(px, py, px+modulePx, py+modulePx), color.RGBA{0, 0, 0, 255})
+		}
+	}
+	return nil
+}
+
+// qrSymbol is a QR symbol drawn on a label, recorded for vector output.
+type qrSymbol struct {
+	modules  [][]bool
+	x0, y0   int // Top-left corner of the first module in pixels
+	modulePx int
+	under    *image.RGBA // The symbol area before the dark modules were drawn
+}
+
+// fillRect paints r in an opaque color, clipped to the image.
+func fillRect(img *image.RGBA, r image.Rectangle, c color.RGBA) {
+	r = r.Intersect(img.Bounds())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
+
+// fillUnder composites an opaque color underneath the pixels of r, so that
+// transparent areas take the color and opaque artwork is kept.
+func fillUnder(img *image.RGBA, r image.Rectangle, c color.RGBA) {
+	r = r.Intersect(img.Bounds())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			p := img.RGBAAt(x, y)
+			t := 255 - uint32(p.A)
+			img.SetRGBA(x, y, color.RGBA{
+				uint8(uint32(p.R) + uint32(c.R)*t/255),
+				uint8(uint32(p.G) + uint32(c.G)*t/255),
+				uint8(uint32(p.B) + uint32(c.B)*t/255),
+				255,
+			})
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultQuietZone is the quiet zone width in modules required around a QR code.
+const DefaultQuietZone = 4
+
+// TerminalOptions controls terminal QR rendering.
+type TerminalOptions struct {
+	// QuietZone is the light border width in modules (DefaultQuietZone for scanning reliability).
+	QuietZone int
+	// Invert swaps light and dark. By default light modules are drawn with block
+	// characters, which is correct on terminals with a dark background; set Invert
+	// for terminals with a light background.
+	Invert bool
+}
+
+// RenderTerminalQR renders content as a QR code using Unicode half-block
+// characters, packing two module rows into each text line.
+// It uses the default error correction level of the printed label.
+func RenderTerminalQR(content string, opts TerminalOptions) (string, error) {
+	if opts.QuietZone < 0 {
+		return "", fmt.Errorf("quiet zone must not be negative")
+	}
+
+	modules, err := EncodeQR(content, QRSymbolOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	size := len(modules) + 2*opts.QuietZone
+	// lit reports whether the module at (x, y) is drawn with a block character
+	lit := func(x, y int) bool {
+		if y >= size {
+			return false // Padding below an odd number of rows shows the terminal background
+		}
+		x -= opts.QuietZone
+		y -= opts.QuietZone
+		dark := x >= 0 && y >= 0 && x < len(modules) && y < len(modules) && modules[y][x]
+		return dark == opts.Invert
+	}
+
+	var sb strings.Builder
+	for y := 0; y < size; y += 2 {
+		for x := 0; x < size; x++ {
+			top, bottom := lit(x, y), lit(x, y+1)
+			switch {
+			case top && bottom:
+				sb.WriteRune('█')
+			case top:
+				sb.WriteRune('▀')
+			case bottom:
+				sb.WriteRune('▄')
+			default:
+				sb.WriteRune(' ')
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String(), nil
+}
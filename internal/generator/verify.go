@@ -0,0 +1,117 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"strings"
+
+	"github.com/lordbasex/HomeKitGenQRCode/internal/scan"
+	"github.com/lordbasex/HomeKitGenQRCode/pkg/homekit"
+)
+
+// ErrVerification is wrapped by the errors returned when a rendered label
+// does not read back correctly.
+var ErrVerification = errors.New("label verification failed")
+
+// VerifyLabel reads back a rendered label and checks that the QR code decodes
+// to the setup URI at the requested error correction level, that the URI
+// carries the label's category, setup code and setup ID (for Matter labels,
+// its onboarding payload), that the second QR code if any reads as its
+// content, and that each barcode reads as its text. It catches layout
+// problems such as a clipped QR code before a label is printed.
+func VerifyLabel(img image.Image, label Label, opts LabelOptions) error {
+	opts = opts.withDefaults()
+
+	wantURI, err := expectedURI(label)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrVerification, err)
+	}
+
+	codes, err := scan.DecodeQRCodes(img)
+	if err != nil {
+		return fmt.Errorf("%w: QR code could not be read: %w", ErrVerification, err)
+	}
+	var found *scan.QRCode
+	for i := range codes {
+		if codes[i].Text == wantURI {
+			found = &codes[i]
+		}
+	}
+	if found == nil {
+		return fmt.Errorf("%w: QR code decodes to %q, expected %q", ErrVerification, codes[0].Text, wantURI)
+	}
+	if found.Level != opts.QR.Level {
+		return fmt.Errorf("%w: QR code has error correction level %s, expected %s", ErrVerification, found.Level, opts.QR.Level)
+	}
+	if label.SecondQR != nil && !hasQRText(codes, label.SecondQR.Content) {
+		return fmt.Errorf("%w: second QR code %q could not be read", ErrVerification, label.SecondQR.Content)
+	}
+
+	barcodes := []struct{ name, text string }{
+		{"device code", label.DeviceCode},
+		{"serial number", label.Serial},
+		{"CSN", label.CSN},
+	}
+	if label.MAC != "" {
+		addr, err := homekit.ParseMAC(label.MAC)
+		if err != nil {
+			return err
+		}
+		barcodes = append(barcodes, struct{ name, text string }{"MAC", addr.Format(opts.MACBarcodeStyle)})
+	}
+
+	read := map[string]bool{}
+	for _, b := range scan.ReadBarcodes(img) {
+		if b.Symbology == scan.Code39 {
+			read[b.Text] = true
+		}
+	}
+	for _, b := range barcodes {
+		if !read[b.text] {
+			return fmt.Errorf("%w: %s barcode %q could not be read", ErrVerification, b.name, b.text)
+		}
+	}
+	return nil
+}
+
+// hasQRText reports whether one of codes decodes to text.
+func hasQRText(codes []scan.QRCode, text string) bool {
+	for _, c := range codes {
+		if c.Text == text {
+			return true
+		}
+	}
+	return false
+}
+
+// expectedURI returns the URI the label's QR code must carry, after checking
+// that it matches the label's pairing data.
+func expectedURI(label Label) (string, error) {
+	if label.Matter != nil {
+		want, err := label.Matter.QRCode()
+		if err != nil {
+			return "", err
+		}
+		if label.URI != "" && label.URI != want {
+			return "", fmt.Errorf("Matter payload %s does not match %s", label.URI, want)
+		}
+		return want, nil
+	}
+
+	wantURI := label.URI
+	if wantURI == "" {
+		wantURI = homekit.GenHomeKitSetupURI(label.Category, label.SetupCode, label.SetupID)
+	}
+	payload, err := homekit.DecodeSetupURI(wantURI)
+	if err != nil {
+		return "", err
+	}
+	if payload.Category != label.Category ||
+		homekit.PlainSetupCode(payload.SetupCode) != homekit.PlainSetupCode(strings.TrimSpace(label.SetupCode)) ||
+		!strings.EqualFold(payload.SetupID, strings.TrimSpace(label.SetupID)) {
+		return "", fmt.Errorf("setup URI %s does not match category %d, setup code %s and setup ID %s",
+			wantURI, label.Category, label.SetupCode, label.SetupID)
+	}
+	return wantURI, nil
+}
@@ -0,0 +1,368 @@
+package hap
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// Pair-setup error codes (kTLVError)
+const (
+	ErrorUnknown        byte = 0x01 // Generic error
+	ErrorAuthentication byte = 0x02 // Setup code or signature verification failed
+	ErrorBackoff        byte = 0x03 // Client must wait before retrying
+	ErrorMaxPeers       byte = 0x04 // No room for more pairings
+	ErrorMaxTries       byte = 0x05 // Too many failed authentication attempts
+	ErrorUnavailable    byte = 0x06 // Accessory is already paired
+	ErrorBusy           byte = 0x07 // Another pairing is in progress
+)
+
+// errorNames describes the pair-setup error codes.
+var errorNames = map[byte]string{
+	ErrorUnknown:        "unknown error",
+	ErrorAuthentication: "authentication failed (wrong setup code)",
+	ErrorBackoff:        "accessory asks to back off",
+	ErrorMaxPeers:       "accessory cannot accept more pairings",
+	ErrorMaxTries:       "too many failed authentication attempts",
+	ErrorUnavailable:    "accessory is already paired",
+	ErrorBusy:           "another pairing is in progress",
+}
+
+// MaxAuthAttempts is the number of failed pair-setup attempts after which an
+// accessory refuses further attempts until it is reset.
+const MaxAuthAttempts = 100
+
+// PairingError is an error code returned by the accessory in a pair-setup
+// response.
+type PairingError struct {
+	State int  // State of the response carrying the error
+	Code  byte // kTLVError code
+}
+
+func (e *PairingError) Error() string {
+	name, ok := errorNames[e.Code]
+	if !ok {
+		name = fmt.Sprintf("error 0x%02X", e.Code)
+	}
+	return fmt.Sprintf("M%d: %s", e.State, name)
+}
+
+// Session key derivation parameters (HKDF-SHA-512 salt and info)
+const (
+	encryptSalt        = "Pair-Setup-Encrypt-Salt"
+	encryptInfo        = "Pair-Setup-Encrypt-Info"
+	controllerSignSalt = "Pair-Setup-Controller-Sign-Salt"
+	controllerSignInfo = "Pair-Setup-Controller-Sign-Info"
+	accessorySignSalt  = "Pair-Setup-Accessory-Sign-Salt"
+	accessorySignInfo  = "Pair-Setup-Accessory-Sign-Info"
+)
+
+// Accessory is the accessory side of pair-setup, provisioned with the SRP salt
+// and verifier of its setup code. It handles one pair-setup at a time.
+type Accessory struct {
+	PairingID string // Accessory pairing identifier (device ID)
+	LTPK      ed25519.PublicKey
+	ltsk      ed25519.PrivateKey
+
+	salt     []byte
+	verifier []byte
+
+	// Controllers holds the long-term public keys of the paired controllers
+	// by pairing identifier.
+	Controllers map[string]ed25519.PublicKey
+	// FailedAttempts counts the pair-setup attempts with a wrong setup code.
+	FailedAttempts int
+
+	state int
+	srp   *srpServer
+}
+
+// NewAccessory returns an unpaired accessory with a new long-term key pair.
+func NewAccessory(pairingID string, salt, verifier []byte) *Accessory {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	return &Accessory{
+		PairingID:   pairingID,
+		LTPK:        pub,
+		ltsk:        priv,
+		salt:        salt,
+		verifier:    verifier,
+		Controllers: map[string]ed25519.PublicKey{},
+	}
+}
+
+// HandlePairSetup processes a pair-setup request (M1, M3 or M5) and returns
+// the TLV8 response (M2, M4 or M6). Failures are reported to the controller
+// with an error item, as the protocol requires.
+func (a *Accessory) HandlePairSetup(req []byte) []byte {
+	items, err := DecodeTLV8(req)
+	if err != nil {
+		return a.fail(2, ErrorUnknown)
+	}
+	switch state := tlvState(items); state {
+	case 1:
+		return a.handleM1(items)
+	case 3:
+		if a.state != 2 {
+			return a.fail(4, ErrorUnknown)
+		}
+		return a.handleM3(items)
+	case 5:
+		if a.state != 4 {
+			return a.fail(6, ErrorUnknown)
+		}
+		return a.handleM5(items)
+	default:
+		return a.fail(state+1, ErrorUnknown)
+	}
+}
+
+// Transport returns a Transport that delivers pair-setup requests to the
+// accessory in-process.
+func (a *Accessory) Transport() Transport {
+	return func(req []byte) ([]byte, error) {
+		return a.HandlePairSetup(req), nil
+	}
+}
+
+// handleM1 answers the start request with the salt and public key B.
+func (a *Accessory) handleM1(items map[byte][]byte) []byte {
+	switch {
+	case len(a.Controllers) > 0:
+		return a.fail(2, ErrorUnavailable)
+	case a.FailedAttempts >= MaxAuthAttempts:
+		return a.fail(2, ErrorMaxTries)
+	case !bytes.Equal(items[TLVMethod], []byte{MethodPairSetup}):
+		return a.fail(2, ErrorUnknown)
+	}
+	a.srp = newSRPServer(a.salt, a.verifier, randomExponent())
+	a.state = 2
+	return EncodeTLV8(TLVByte(TLVState, 2), TLV(TLVSalt, a.salt), TLV(TLVPublicKey, padBytes(a.srp.b)))
+}
+
+// handleM3 checks the controller proof, which only matches when the
+// controller used the setup code the verifier was made from.
+func (a *Accessory) handleM3(items map[byte][]byte) []byte {
+	if err := a.srp.setClientKey(items[TLVPublicKey]); err != nil {
+		return a.fail(4, ErrorAuthentication)
+	}
+	m1 := items[TLVProof]
+	if verifyProof(m1, a.srp.proof()) != nil {
+		a.FailedAttempts++
+		return a.fail(4, ErrorAuthentication)
+	}
+	a.state = 4
+	return EncodeTLV8(TLVByte(TLVState, 4), TLV(TLVProof, a.srp.serverProof(m1)))
+}
+
+// handleM5 verifies the controller's signed identity, stores its long-term
+// key and answers with the accessory's own signed identity.
+func (a *Accessory) handleM5(items map[byte][]byte) []byte {
+	key, err := deriveKey(a.srp.key, encryptSalt, encryptInfo)
+	if err != nil {
+		return a.fail(6, ErrorUnknown)
+	}
+	sub, err := openSubTLV(key, "PS-Msg05", items[TLVEncryptedData])
+	if err != nil {
+		return a.fail(6, ErrorAuthentication)
+	}
+	id, ltpk, sig := sub[TLVIdentifier], sub[TLVPublicKey], sub[TLVSignature]
+	x, err := deriveKey(a.srp.key, controllerSignSalt, controllerSignInfo)
+	if err != nil || len(ltpk) != ed25519.PublicKeySize || !ed25519.Verify(ltpk, concat(x, id, ltpk), sig) {
+		return a.fail(6, ErrorAuthentication)
+	}
+
+	x, err = deriveKey(a.srp.key, accessorySignSalt, accessorySignInfo)
+	if err != nil {
+		return a.fail(6, ErrorUnknown)
+	}
+	info := concat(x, []byte(a.PairingID), a.LTPK)
+	sealed, err := sealSubTLV(key, "PS-Msg06",
+		TLV(TLVIdentifier, []byte(a.PairingID)),
+		TLV(TLVPublicKey, a.LTPK),
+		TLV(TLVSignature, ed25519.Sign(a.ltsk, info)))
+	if err != nil {
+		return a.fail(6, ErrorUnknown)
+	}
+	a.Controllers[string(id)] = ltpk
+	a.state = 0
+	return EncodeTLV8(TLVByte(TLVState, 6), TLV(TLVEncryptedData, sealed))
+}
+
+// fail resets the pair-setup state and returns an error response.
+func (a *Accessory) fail(state int, code byte) []byte {
+	a.state = 0
+	a.srp = nil
+	return EncodeTLV8(TLVByte(TLVState, byte(state)), TLVByte(TLVError, code))
+}
+
+// Transport sends a pair-setup request to the accessory and returns its
+// response.
+type Transport func(req []byte) ([]byte, error)
+
+// Controller is the controller side of pair-setup.
+type Controller struct {
+	PairingID string // Controller pairing identifier
+	LTPK      ed25519.PublicKey
+	ltsk      ed25519.PrivateKey
+}
+
+// NewController returns a controller with a new long-term key pair.
+func NewController(pairingID string) *Controller {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	return &Controller{PairingID: pairingID, LTPK: pub, ltsk: priv}
+}
+
+// PairSetupOptions controls a pair-setup run.
+type PairSetupOptions struct {
+	// StopAfterM4 ends the exchange once the accessory has proved it knows
+	// the setup code, without exchanging long-term keys (M5-M6), so that the
+	// accessory is left unpaired.
+	StopAfterM4 bool
+	// Trace, when set, is called for every message with its state number and
+	// a short description.
+	Trace func(state int, desc string)
+}
+
+// PairSetupResult describes a successful pair-setup.
+type PairSetupResult struct {
+	Salt         []byte            // SRP salt sent by the accessory
+	AccessoryID  string            // Accessory pairing identifier (after M6)
+	AccessoryKey ed25519.PublicKey // Accessory long-term public key (after M6)
+	Paired       bool              // Whether M5-M6 completed
+}
+
+// PairSetup runs pair-setup with the accessory behind send, authenticating
+// with setupCode (XXX-XX-XXX).
+func (c *Controller) PairSetup(setupCode string, send Transport, opts PairSetupOptions) (*PairSetupResult, error) {
+	trace := opts.Trace
+	if trace == nil {
+		trace = func(int, string) {}
+	}
+	exchange := func(state int, req []byte, desc string) (map[byte][]byte, error) {
+		trace(state, desc)
+		data, err := send(req)
+		if err != nil {
+			return nil, fmt.Errorf("M%d: %w", state, err)
+		}
+		items, err := DecodeTLV8(data)
+		if err != nil {
+			return nil, fmt.Errorf("M%d: %w", state+1, err)
+		}
+		if code := items[TLVError]; len(code) == 1 {
+			return nil, &PairingError{State: state + 1, Code: code[0]}
+		}
+		if got := tlvState(items); got != state+1 {
+			return nil, fmt.Errorf("M%d: unexpected state %d in response", state+1, got)
+		}
+		return items, nil
+	}
+
+	m2, err := exchange(1, EncodeTLV8(TLVByte(TLVState, 1), TLVByte(TLVMethod, MethodPairSetup)), "start request")
+	if err != nil {
+		return nil, err
+	}
+	result := &PairSetupResult{Salt: m2[TLVSalt]}
+	if len(result.Salt) != SaltSize {
+		return nil, fmt.Errorf("M2: salt is %d bytes, expected %d", len(result.Salt), SaltSize)
+	}
+	trace(2, fmt.Sprintf("salt %X and SRP public key B (%d bytes)", result.Salt, len(m2[TLVPublicKey])))
+
+	srp := newSRPClient(randomExponent())
+	if err := srp.setServerKey(setupCode, result.Salt, m2[TLVPublicKey]); err != nil {
+		return nil, fmt.Errorf("M2: %w", err)
+	}
+	m1 := srp.proof()
+	m4, err := exchange(3, EncodeTLV8(TLVByte(TLVState, 3), TLV(TLVPublicKey, padBytes(srp.a)), TLV(TLVProof, m1)), "SRP public key A and proof M1")
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyProof(m4[TLVProof], srp.serverProof(m1)); err != nil {
+		return nil, fmt.Errorf("M4: accessory %w", err)
+	}
+	trace(4, "accessory proof M2 verified")
+	if opts.StopAfterM4 {
+		return result, nil
+	}
+
+	key, err := deriveKey(srp.key, encryptSalt, encryptInfo)
+	if err != nil {
+		return nil, err
+	}
+	x, err := deriveKey(srp.key, controllerSignSalt, controllerSignInfo)
+	if err != nil {
+		return nil, err
+	}
+	info := concat(x, []byte(c.PairingID), c.LTPK)
+	sealed, err := sealSubTLV(key, "PS-Msg05",
+		TLV(TLVIdentifier, []byte(c.PairingID)),
+		TLV(TLVPublicKey, c.LTPK),
+		TLV(TLVSignature, ed25519.Sign(c.ltsk, info)))
+	if err != nil {
+		return nil, err
+	}
+	m6, err := exchange(5, EncodeTLV8(TLVByte(TLVState, 5), TLV(TLVEncryptedData, sealed)), "encrypted controller identity")
+	if err != nil {
+		return nil, err
+	}
+
+	sub, err := openSubTLV(key, "PS-Msg06", m6[TLVEncryptedData])
+	if err != nil {
+		return nil, fmt.Errorf("M6: %w", err)
+	}
+	id, ltpk, sig := sub[TLVIdentifier], sub[TLVPublicKey], sub[TLVSignature]
+	if x, err = deriveKey(srp.key, accessorySignSalt, accessorySignInfo); err != nil {
+		return nil, err
+	}
+	if len(ltpk) != ed25519.PublicKeySize || !ed25519.Verify(ltpk, concat(x, id, ltpk), sig) {
+		return nil, errors.New("M6: accessory signature verification failed")
+	}
+	trace(6, fmt.Sprintf("accessory identity %s verified", id))
+	result.AccessoryID = string(id)
+	result.AccessoryKey = ltpk
+	result.Paired = true
+	return result, nil
+}
+
+// deriveKey derives a 32-byte key from the SRP session key with HKDF-SHA-512.
+func deriveKey(secret []byte, salt, info string) ([]byte, error) {
+	return hkdf.Key(sha512.New, secret, []byte(salt), info, 32)
+}
+
+// sealSubTLV encrypts a sub-TLV with ChaCha20-Poly1305 under the given
+// 8-byte nonce label.
+func sealSubTLV(key []byte, nonce string, items ...TLVItem) ([]byte, error) {
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(nil, pairNonce(nonce), EncodeTLV8(items...), nil), nil
+}
+
+// openSubTLV decrypts and decodes a sub-TLV sealed by sealSubTLV.
+func openSubTLV(key []byte, nonce string, sealed []byte) (map[byte][]byte, error) {
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := aead.Open(nil, pairNonce(nonce), sealed, nil)
+	if err != nil {
+		return nil, errors.New("encrypted data could not be authenticated")
+	}
+	return DecodeTLV8(plain)
+}
+
+// pairNonce pads an 8-byte nonce label to the 12-byte ChaCha20 nonce.
+func pairNonce(label string) []byte {
+	return append(make([]byte, 4), label...)
+}
+
+// concat returns the concatenation of parts.
+func concat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
@@ -0,0 +1,181 @@
+package hap
+
+import (
+	"crypto/rand"
+	"crypto/sha512"
+	"crypto/subtle"
+	"errors"
+	"math/big"
+)
+
+// SRPUsername is the SRP identity used by HAP pair-setup.
+const SRPUsername = "Pair-Setup"
+
+// SaltSize is the size of the SRP salt in bytes.
+const SaltSize = 16
+
+// srpN is the 3072-bit prime of RFC 5054, with generator srpG.
+var srpN, _ = new(big.Int).SetString(""+
+	"FFFFFFFFFFFFFFFFC90FDAA22168C234C4C6628B80DC1CD129024E088A67CC74"+
+	"020BBEA63B139B22514A08798E3404DDEF9519B3CD3A431B302B0A6DF25F1437"+
+	"4FE1356D6D51C245E485B576625E7EC6F44C42E9A637ED6B0BFF5CB6F406B7ED"+
+	"EE386BFB5A899FA5AE9F24117C4B1FE649286651ECE45B3DC2007CB8A163BF05"+
+	"98DA48361C55D39A69163FA8FD24CF5F83655D23DCA3AD961C62F356208552BB"+
+	"9ED529077096966D670C354E4ABC9804F1746C08CA18217C32905E462E36CE3B"+
+	"E39E772C180E86039B2783A2EC07A28FB5C55DF06F4C52C9DE2BCBF695581718"+
+	"3995497CEA956AE515D2261898FA051015728E5A8AAAC42DAD33170D04507A33"+
+	"A85521ABDF1CBA64ECFB850458DBEF0A8AEA71575D060C7DB3970F85A6E1E4C7"+
+	"ABF5AE8CDB0933D71E8C94E04A25619DCEE3D2261AD2EE6BF12FFA06D98A0864"+
+	"D87602733EC86A64521F2B18177B200CBBE117577A615D6C770988C0BAD946E2"+
+	"08E24FA074E5AB3143DB5BFCE0FD108E4B82D120A93AD2CAFFFFFFFFFFFFFFFF", 16)
+
+var srpG = big.NewInt(5)
+
+// srpLen is the size of the padded group elements in bytes.
+const srpLen = 384
+
+// srpK is the SRP-6a multiplier k = H(N | PAD(g)).
+var srpK = new(big.Int).SetBytes(srpHash(srpN.Bytes(), padBytes(srpG)))
+
+// NewSalt returns a random SRP salt.
+func NewSalt() []byte {
+	salt := make([]byte, SaltSize)
+	rand.Read(salt)
+	return salt
+}
+
+// SRPVerifier computes the verifier v = g^x an accessory is provisioned with,
+// where x = H(salt | H("Pair-Setup:" | setup code)). The setup code is given
+// in the XXX-XX-XXX form.
+func SRPVerifier(setupCode string, salt []byte) []byte {
+	return padBytes(new(big.Int).Exp(srpG, srpX(SRPUsername, setupCode, salt), srpN))
+}
+
+// srpX returns the private key x derived from the salt, identity and password.
+func srpX(identity, password string, salt []byte) *big.Int {
+	inner := srpHash([]byte(identity + ":" + password))
+	return new(big.Int).SetBytes(srpHash(salt, inner))
+}
+
+// srpSession holds the values both sides derive during the SRP exchange.
+type srpSession struct {
+	salt []byte
+	a, b *big.Int // Public keys A and B
+	key  []byte   // Session key K = H(S)
+}
+
+// srpServer is the accessory side of the SRP exchange.
+type srpServer struct {
+	srpSession
+	verifier *big.Int
+	secret   *big.Int
+}
+
+// newSRPServer starts the accessory side with the provisioned salt and
+// verifier and the private exponent b, computing B = k*v + g^b.
+func newSRPServer(salt, verifier []byte, secret *big.Int) *srpServer {
+	s := &srpServer{verifier: new(big.Int).SetBytes(verifier), secret: secret}
+	s.salt = salt
+	s.b = new(big.Int).Exp(srpG, s.secret, srpN)
+	s.b.Add(s.b, new(big.Int).Mul(srpK, s.verifier))
+	s.b.Mod(s.b, srpN)
+	return s
+}
+
+// setClientKey computes the session key from the controller's public key A:
+// S = (A * v^u)^b.
+func (s *srpServer) setClientKey(a []byte) error {
+	s.a = new(big.Int).SetBytes(a)
+	if new(big.Int).Mod(s.a, srpN).Sign() == 0 {
+		return errors.New("invalid SRP public key A")
+	}
+	u := srpU(s.a, s.b)
+	S := new(big.Int).Exp(s.verifier, u, srpN)
+	S.Mul(S, s.a)
+	S.Exp(S, s.secret, srpN)
+	s.key = srpHash(padBytes(S))
+	return nil
+}
+
+// srpClient is the controller side of the SRP exchange.
+type srpClient struct {
+	srpSession
+	secret *big.Int
+}
+
+// newSRPClient starts the controller side with the private exponent a,
+// computing A = g^a.
+func newSRPClient(secret *big.Int) *srpClient {
+	c := &srpClient{secret: secret}
+	c.a = new(big.Int).Exp(srpG, c.secret, srpN)
+	return c
+}
+
+// setServerKey computes the session key from the salt, the accessory's public
+// key B and the setup code: S = (B - k*g^x)^(a + u*x).
+func (c *srpClient) setServerKey(setupCode string, salt, b []byte) error {
+	c.salt = salt
+	c.b = new(big.Int).SetBytes(b)
+	if new(big.Int).Mod(c.b, srpN).Sign() == 0 {
+		return errors.New("invalid SRP public key B")
+	}
+	x := srpX(SRPUsername, setupCode, salt)
+	u := srpU(c.a, c.b)
+	base := new(big.Int).Exp(srpG, x, srpN)
+	base.Mul(base, srpK)
+	base.Sub(c.b, base)
+	base.Mod(base, srpN)
+	exp := new(big.Int).Mul(u, x)
+	exp.Add(exp, c.secret)
+	S := new(big.Int).Exp(base, exp, srpN)
+	c.key = srpHash(padBytes(S))
+	return nil
+}
+
+// proof returns the controller proof M1 = H(H(N) xor H(g) | H(I) | s | A | B | K).
+func (s *srpSession) proof() []byte {
+	hn, hg := srpHash(srpN.Bytes()), srpHash(srpG.Bytes())
+	for i := range hn {
+		hn[i] ^= hg[i]
+	}
+	return srpHash(hn, srpHash([]byte(SRPUsername)), s.salt, padBytes(s.a), padBytes(s.b), s.key)
+}
+
+// serverProof returns the accessory proof M2 = H(A | M1 | K).
+func (s *srpSession) serverProof(m1 []byte) []byte {
+	return srpHash(padBytes(s.a), m1, s.key)
+}
+
+// verifyProof checks a proof received from the other side.
+func verifyProof(got, want []byte) error {
+	if subtle.ConstantTimeCompare(got, want) != 1 {
+		return errors.New("SRP proof mismatch")
+	}
+	return nil
+}
+
+// srpU returns the scrambling parameter u = H(PAD(A) | PAD(B)).
+func srpU(a, b *big.Int) *big.Int {
+	return new(big.Int).SetBytes(srpHash(padBytes(a), padBytes(b)))
+}
+
+// randomExponent returns a random 256-bit private exponent.
+func randomExponent() *big.Int {
+	buf := make([]byte, 32)
+	rand.Read(buf)
+	return new(big.Int).SetBytes(buf)
+}
+
+// srpHash returns the SHA-512 hash of the concatenated parts.
+func srpHash(parts ...[]byte) []byte {
+	h := sha512.New()
+	for _, p := range parts {
+		h.Write(p)
+	}
+	return h.Sum(nil)
+}
+
+// padBytes returns n as a big-endian number padded to the size of N.
+func padBytes(n *big.Int) []byte {
+	return n.FillBytes(make([]byte, srpLen))
+}
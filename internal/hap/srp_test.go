@@ -0,0 +1,188 @@
+package hap
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+// SRP test vector of the HAP specification (SRP-6a, 3072-bit group of
+// RFC 5054, SHA-512).
+var (
+	specIdentity = "alice"
+	specPassword = "password123"
+	specSalt     = "BEB25379D1A8581EB5A727673A2441EE"
+	specA        = "60975527035CF2AD1989806F0407210BC81EDC04E2762A56AFD529DDDA2D4393"
+	specB        = "E487CB59D31AC550471E81F00F6928E01DDA08E974A004F49E61F5D105284D20"
+
+	specK = "A9C2E2559BF0EBB53F0CBBF62282906BEDE7F2182F00678211FBD5BDE5B28503" +
+		"3A4993503B87397F9BE5EC02080FEDBC0835587AD039060879B8621E8C3659E0"
+	specX = "B149ECB0946B0B206D77E73D95DEB7C41BD12E86A5E2EEA3893D5416591A002F" +
+		"F94BFEA384DC0E1C550F7ED4D5A9D2AD1F1526F01C56B5C10577730CC4A4D709"
+	specV = "9B5E061701EA7AEB39CF6E3519655A853CF94C75CAF2555EF1FAF759BB79CB47" +
+		"7014E04A88D68FFC05323891D4C205B8DE81C2F203D8FAD1B24D2C109737F1BE" +
+		"BBD71F912447C4A03C26B9FAD8EDB3E780778E302529ED1EE138CCFC36D4BA31" +
+		"3CC48B14EA8C22A0186B222E655F2DF5603FD75DF76B3B08FF8950069ADD03A7" +
+		"54EE4AE88587CCE1BFDE36794DBAE4592B7B904F442B041CB17AEBAD1E3AEBE3" +
+		"CBE99DE65F4BB1FA00B0E7AF06863DB53B02254EC66E781E3B62A8212C86BEB0" +
+		"D50B5BA6D0B478D8C4E9BBCEC21765326FBD14058D2BBDE2C33045F03873E539" +
+		"48D78B794F0790E48C36AED6E880F557427B2FC06DB5E1E2E1D7E661AC482D18" +
+		"E528D7295EF7437295FF1A72D402771713F16876DD050AE5B7AD53CCB90855C9" +
+		"3956648358ADFD966422F52498732D68D1D7FBEF10D78034AB8DCB6F0FCF885C" +
+		"C2B2EA2C3E6AC86609EA058A9DA8CC63531DC915414DF568B09482DDAC1954DE" +
+		"C7EB714F6FF7D44CD5B86F6BD115810930637C01D0F6013BC9740FA2C633BA89"
+	specPubA = "FAB6F5D2615D1E323512E7991CC37443F487DA604CA8C9230FCB04E541DCE628" +
+		"0B27CA4680B0374F179DC3BDC7553FE62459798C701AD864A91390A28C93B644" +
+		"ADBF9C00745B942B79F9012A21B9B78782319D83A1F8362866FBD6F46BFC0DDB" +
+		"2E1AB6E4B45A9906B82E37F05D6F97F6A3EB6E182079759C4F6847837B62321A" +
+		"C1B4FA68641FCB4BB98DD697A0C73641385F4BAB25B793584CC39FC8D48D4BD8" +
+		"67A9A3C10F8EA12170268E34FE3BBE6FF89998D60DA2F3E4283CBEC1393D52AF" +
+		"724A57230C604E9FBCE583D7613E6BFFD67596AD121A8707EEC4694495703368" +
+		"6A155F644D5C5863B48F61BDBF19A53EAB6DAD0A186B8C152E5F5D8CAD4B0EF8" +
+		"AA4EA5008834C3CD342E5E0F167AD04592CD8BD279639398EF9E114DFAAAB919" +
+		"E14E850989224DDD98576D79385D2210902E9F9B1F2D86CFA47EE244635465F7" +
+		"1058421A0184BE51DD10CC9D079E6F1604E7AA9B7CF7883C7D4CE12B06EBE160" +
+		"81E23F27A231D18432D7D1BB55C28AE21FFCF005F57528D15A88881BB3BBB7FE"
+	specPubB = "40F57088A482D4C7733384FE0D301FDDCA9080AD7D4F6FDF09A01006C3CB6D56" +
+		"2E41639AE8FA21DE3B5DBA7585B275589BDB279863C562807B2B99083CD1429C" +
+		"DBE89E25BFBD7E3CAD3173B2E3C5A0B174DA6D5391E6A06E465F037A40062548" +
+		"39A56BF76DA84B1C94E0AE208576156FE5C140A4BA4FFC9E38C3B07B88845FC6" +
+		"F7DDDA93381FE0CA6084C4CD2D336E5451C464CCB6EC65E7D16E548A273E8262" +
+		"84AF2559B6264274215960FFF47BDD63D3AFF064D6137AF769661C9D4FEE4738" +
+		"2603C88EAA0980581D07758461B777E4356DDA5835198B51FEEA308D70F75450" +
+		"B71675C08C7D8302FD7539DD1FF2A11CB4258AA70D234436AA42B6A0615F3F91" +
+		"5D55CC3B966B2716B36E4D1A06CE5E5D2EA3BEE5A1270E8751DA45B60B997B0F" +
+		"FDB0F9962FEE4F03BEE780BA0A845B1D9271421783AE6601A61EA2E342E4F2E8" +
+		"BC935A409EAD19F221BD1B74E2964DD19FC845F60EFC09338B60B6B256D8CAC8" +
+		"89CCA306CC370A0B18C8B886E95DA0AF5235FEF4393020D2B7F3056904759042"
+	specU = "03AE5F3C3FA9EFF1A50D7DBB8D2F60A1EA66EA712D50AE976EE34641A1CD0E51" +
+		"C4683DA383E8595D6CB56A15D5FBC7543E07FBDDD316217E01A391A18EF06DFF"
+	specKey = "5CBC219DB052138EE1148C71CD4498963D682549CE91CA24F098468F06015BEB" +
+		"6AF245C2093F98C3651BCA83AB8CAB2B580BBF02184FEFDF26142F73DF95AC50"
+)
+
+func hexInt(s string) *big.Int {
+	n, _ := new(big.Int).SetString(s, 16)
+	return n
+}
+
+func hexBytes(s string) []byte {
+	b, _ := hex.DecodeString(s)
+	return b
+}
+
+func TestSRPSpecVector(t *testing.T) {
+	salt := hexBytes(specSalt)
+	if srpK.Cmp(hexInt(specK)) != 0 {
+		t.Errorf("k = %X, want %s", srpK, specK)
+	}
+	x := srpX(specIdentity, specPassword, salt)
+	if x.Cmp(hexInt(specX)) != 0 {
+		t.Errorf("x = %X, want %s", x, specX)
+	}
+	v := padBytes(new(big.Int).Exp(srpG, x, srpN))
+	if !bytes.Equal(v, padBytes(hexInt(specV))) {
+		t.Errorf("v = %X, want %s", v, specV)
+	}
+
+	server := newSRPServer(salt, v, hexInt(specB))
+	if server.b.Cmp(hexInt(specPubB)) != 0 {
+		t.Errorf("B = %X, want %s", server.b, specPubB)
+	}
+	client := newSRPClient(hexInt(specA))
+	if client.a.Cmp(hexInt(specPubA)) != 0 {
+		t.Errorf("A = %X, want %s", client.a, specPubA)
+	}
+	if u := srpU(client.a, server.b); u.Cmp(hexInt(specU)) != 0 {
+		t.Errorf("u = %X, want %s", u, specU)
+	}
+
+	if err := server.setClientKey(padBytes(client.a)); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(server.key, hexBytes(specKey)) {
+		t.Errorf("K = %X, want %s", server.key, specKey)
+	}
+}
+
+func TestSRPExchange(t *testing.T) {
+	salt := hexBytes(specSalt)
+	verifier := SRPVerifier("613-80-755", salt)
+	if len(verifier) != srpLen {
+		t.Fatalf("verifier is %d bytes, want %d", len(verifier), srpLen)
+	}
+
+	tests := []struct {
+		code  string
+		match bool
+	}{
+		{"613-80-755", true},
+		{"613-80-756", false},
+	}
+	for _, tt := range tests {
+		server := newSRPServer(salt, verifier, randomExponent())
+		client := newSRPClient(randomExponent())
+		if err := client.setServerKey(tt.code, salt, padBytes(server.b)); err != nil {
+			t.Fatal(err)
+		}
+		if err := server.setClientKey(padBytes(client.a)); err != nil {
+			t.Fatal(err)
+		}
+		m1 := client.proof()
+		err := verifyProof(m1, server.proof())
+		if (err == nil) != tt.match {
+			t.Errorf("setup code %s: proof check = %v, want match %v", tt.code, err, tt.match)
+		}
+		if tt.match && verifyProof(server.serverProof(m1), client.serverProof(m1)) != nil {
+			t.Errorf("setup code %s: server proof M2 does not match", tt.code)
+		}
+	}
+}
+
+func TestSRPRejectsZeroKeys(t *testing.T) {
+	salt := hexBytes(specSalt)
+	server := newSRPServer(salt, SRPVerifier("613-80-755", salt), randomExponent())
+	client := newSRPClient(randomExponent())
+	for _, key := range []*big.Int{big.NewInt(0), srpN} {
+		if err := server.setClientKey(padBytes(key)); err == nil {
+			t.Errorf("setClientKey accepted A = %X", key)
+		}
+		if err := client.setServerKey("613-80-755", salt, padBytes(key)); err == nil {
+			t.Errorf("setServerKey accepted B = %X", key)
+		}
+	}
+}
+
+func TestPairSetup(t *testing.T) {
+	salt := NewSalt()
+	verifier := SRPVerifier("613-80-755", salt)
+
+	accessory := NewAccessory("8E:17:87:A9:C4:32", salt, verifier)
+	controller := NewController("controller")
+	result, err := controller.PairSetup("613-80-755", accessory.Transport(), PairSetupOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Paired || result.AccessoryID != accessory.PairingID || !bytes.Equal(result.AccessoryKey, accessory.LTPK) {
+		t.Errorf("PairSetup result = %+v, want paired with %s", result, accessory.PairingID)
+	}
+	if !bytes.Equal(accessory.Controllers[controller.PairingID], controller.LTPK) {
+		t.Error("accessory did not store the controller's long-term key")
+	}
+
+	accessory = NewAccessory("8E:17:87:A9:C4:32", salt, verifier)
+	_, err = controller.PairSetup("613-80-756", accessory.Transport(), PairSetupOptions{})
+	var perr *PairingError
+	if !errors.As(err, &perr) || perr.State != 4 || perr.Code != ErrorAuthentication {
+		t.Errorf("PairSetup with a wrong code = %v, want M4 authentication error", err)
+	}
+	if accessory.FailedAttempts != 1 {
+		t.Errorf("FailedAttempts = %d, want 1", accessory.FailedAttempts)
+	}
+	if !strings.Contains(err.Error(), "wrong setup code") {
+		t.Errorf("error %q does not mention the wrong setup code", err)
+	}
+}
@@ -0,0 +1,92 @@
+// Package hap implements the parts of the HomeKit Accessory Protocol needed to
+// check a setup code end to end: TLV8 encoding, SRP-6a with the 3072-bit group
+// and SHA-512, and the pair-setup exchange (M1-M6) for both the controller and
+// the accessory side.
+package hap
+
+import "fmt"
+
+// TLV8 types used by pair-setup
+const (
+	TLVMethod        byte = 0x00 // Pairing method
+	TLVIdentifier    byte = 0x01 // Pairing identifier
+	TLVSalt          byte = 0x02 // 16-byte SRP salt
+	TLVPublicKey     byte = 0x03 // SRP or Ed25519 public key
+	TLVProof         byte = 0x04 // SRP proof
+	TLVEncryptedData byte = 0x05 // ChaCha20-Poly1305 sealed sub-TLV
+	TLVState         byte = 0x06 // Pairing state (M1-M6)
+	TLVError         byte = 0x07 // Error code
+	TLVSignature     byte = 0x0A // Ed25519 signature
+)
+
+// Pairing methods
+const (
+	MethodPairSetup byte = 0x00 // Pair-setup without MFi authentication
+)
+
+// TLVItem is one entry of a TLV8 message.
+type TLVItem struct {
+	Type  byte
+	Value []byte
+}
+
+// TLV builds a TLV8 item.
+func TLV(t byte, value []byte) TLVItem {
+	return TLVItem{Type: t, Value: value}
+}
+
+// TLVByte builds a TLV8 item holding a single byte.
+func TLVByte(t, value byte) TLVItem {
+	return TLVItem{Type: t, Value: []byte{value}}
+}
+
+// EncodeTLV8 encodes items as TLV8. Values longer than 255 bytes are split
+// into consecutive fragments of the same type.
+func EncodeTLV8(items ...TLVItem) []byte {
+	var out []byte
+	for _, item := range items {
+		v := item.Value
+		for {
+			n := min(len(v), 255)
+			out = append(out, item.Type, byte(n))
+			out = append(out, v[:n]...)
+			v = v[n:]
+			if len(v) == 0 {
+				break
+			}
+		}
+	}
+	return out
+}
+
+// DecodeTLV8 decodes a TLV8 message into a map from type to value, joining
+// the fragments of values longer than 255 bytes.
+func DecodeTLV8(data []byte) (map[byte][]byte, error) {
+	items := map[byte][]byte{}
+	last := -1
+	for len(data) > 0 {
+		if len(data) < 2 {
+			return nil, fmt.Errorf("truncated TLV8 item header")
+		}
+		t, n := data[0], int(data[1])
+		if len(data) < 2+n {
+			return nil, fmt.Errorf("TLV8 item 0x%02X is truncated: %d of %d bytes", t, len(data)-2, n)
+		}
+		if int(t) == last {
+			items[t] = append(items[t], data[2:2+n]...)
+		} else {
+			items[t] = append([]byte(nil), data[2:2+n]...)
+		}
+		last = int(t)
+		data = data[2+n:]
+	}
+	return items, nil
+}
+
+// tlvState returns the pairing state of a decoded message.
+func tlvState(items map[byte][]byte) int {
+	if v := items[TLVState]; len(v) == 1 {
+		return int(v[0])
+	}
+	return -1
+}
@@ -0,0 +1,73 @@
+package hap
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ContentTypeTLV8 is the HTTP content type of pairing messages.
+const ContentTypeTLV8 = "application/pairing+tlv8"
+
+// Conn is an HTTP connection to a HAP accessory. Pair-setup keeps its state
+// per connection, so every message of one exchange goes over the same Conn.
+type Conn struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	host    string
+	timeout time.Duration
+}
+
+// Dial connects to the HAP accessory at addr (host:port).
+func Dial(addr string, timeout time.Duration) (*Conn, error) {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &Conn{conn: conn, reader: bufio.NewReader(conn), host: addr, timeout: timeout}, nil
+}
+
+// Close closes the connection.
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+// PairSetup posts a pair-setup message to /pair-setup and returns the TLV8
+// response body. It has the signature of a Transport.
+func (c *Conn) PairSetup(body []byte) ([]byte, error) {
+	return c.post("/pair-setup", body)
+}
+
+// post sends a TLV8 request to path and reads the response.
+func (c *Conn) post(path string, body []byte) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodPost, "http://"+c.host+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", ContentTypeTLV8)
+	if c.timeout > 0 {
+		c.conn.SetDeadline(time.Now().Add(c.timeout))
+	}
+	if err := req.Write(c.conn); err != nil {
+		return nil, fmt.Errorf("error sending request: %w", err)
+	}
+	resp, err := http.ReadResponse(c.reader, req)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response: %w", err)
+	}
+	// Accessories report pairing errors in the TLV8 body, with status 200 or
+	// 470; anything else is not a pairing response.
+	if resp.Header.Get("Content-Type") != ContentTypeTLV8 {
+		return nil, fmt.Errorf("unexpected response: %s", resp.Status)
+	}
+	return data, nil
+}
@@ -0,0 +1,170 @@
+// Package homespan renders the pairing data of a label in the forms used by
+// HomeSpan (ESP32 HomeKit library) projects: serial CLI commands, a sketch
+// snippet and a per-device header.
+package homespan
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lordbasex/HomeKitGenQRCode/pkg/homekit"
+)
+
+// categories maps HomeKit category IDs to HomeSpan's Category constants.
+var categories = map[int]string{
+	1:  "Other",
+	2:  "Bridges",
+	3:  "Fans",
+	4:  "GarageDoorOpeners",
+	5:  "Lighting",
+	6:  "Locks",
+	7:  "Outlets",
+	8:  "Switches",
+	9:  "Thermostats",
+	10: "Sensors",
+	11: "SecuritySystems",
+	12: "Doors",
+	13: "Windows",
+	14: "WindowCoverings",
+	15: "ProgrammableSwitches",
+	17: "IPCameras",
+	18: "VideoDoorbells",
+	19: "AirPurifiers",
+	20: "Heaters",
+	21: "AirConditioners",
+	22: "Humidifiers",
+	23: "Dehumidifiers",
+	28: "Sprinklers",
+	29: "Faucets",
+	30: "ShowerSystems",
+	31: "Television",
+}
+
+// Category returns the C++ expression for a category: Category::Lighting, or
+// a cast for categories HomeSpan has no constant for.
+func Category(id int) string {
+	if name, ok := categories[id]; ok {
+		return "Category::" + name
+	}
+	return fmt.Sprintf("static_cast<Category>(%d)", id)
+}
+
+// disallowedCodes are the pairing codes HomeSpan refuses in setPairingCode
+// and the S command.
+var disallowedCodes = []string{
+	"00000000", "11111111", "22222222", "33333333", "44444444",
+	"55555555", "66666666", "77777777", "88888888", "99999999",
+	"12345678", "87654321",
+}
+
+// PairingCode returns the setup code in the form HomeSpan accepts: 8 digits
+// without dashes, not one of the codes HomeSpan refuses.
+func PairingCode(setupCode string) (string, error) {
+	code := homekit.PlainSetupCode(strings.TrimSpace(setupCode))
+	if len(code) != 8 || strings.Trim(code, "0123456789") != "" {
+		return "", fmt.Errorf("HomeSpan pairing code %q must be exactly 8 digits", code)
+	}
+	for _, bad := range disallowedCodes {
+		if code == bad {
+			return "", fmt.Errorf("HomeSpan refuses pairing code %s", code)
+		}
+	}
+	return code, nil
+}
+
+// QRID returns the setup ID in the form HomeSpan accepts: exactly 4 characters
+// from 0-9 and A-Z.
+func QRID(setupID string) (string, error) {
+	id := strings.ToUpper(strings.TrimSpace(setupID))
+	if len(id) != 4 || strings.Trim(id, "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return "", fmt.Errorf("HomeSpan QR ID %q must be exactly 4 characters (0-9, A-Z)", setupID)
+	}
+	return id, nil
+}
+
+// Device holds the label values written to the HomeSpan files.
+type Device struct {
+	Name      string // Name passed to homeSpan.begin()
+	Category  int
+	SetupCode string // Pairing code (XXX-XX-XXX or 8 digits)
+	SetupID   string // QR ID
+	URI       string // Setup payload printed in the QR code
+	Serial    string
+	MAC       string
+}
+
+// values validates the pairing code and QR ID of d.
+func (d Device) values() (code, id string, err error) {
+	if code, err = PairingCode(d.SetupCode); err != nil {
+		return "", "", err
+	}
+	if id, err = QRID(d.SetupID); err != nil {
+		return "", "", err
+	}
+	return code, id, nil
+}
+
+// Script returns the serial CLI commands that store the pairing code and QR
+// ID in the device, one per line, ready to paste into the serial monitor.
+func (d Device) Script() (string, error) {
+	code, id, err := d.values()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("S %s\nQ %s\n", code, id), nil
+}
+
+// Sketch returns a C++ snippet for setup() that sets the pairing code and QR
+// ID before homeSpan.begin().
+func (d Device) Sketch() (string, error) {
+	code, id, err := d.values()
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "// HomeSpan pairing data for %s\n", d.comment())
+	fmt.Fprintf(&sb, "// Setup code %s, setup payload %s\n", homekit.FormatSetupCode(code), d.URI)
+	fmt.Fprintf(&sb, "homeSpan.setPairingCode(%q);\n", code)
+	fmt.Fprintf(&sb, "homeSpan.setQRID(%q);\n", id)
+	fmt.Fprintf(&sb, "homeSpan.begin(%s, %q);\n", Category(d.Category), d.Name)
+	return sb.String(), nil
+}
+
+// Header returns a per-device header defining the pairing data, for sketches
+// built once per device.
+func (d Device) Header() (string, error) {
+	code, id, err := d.values()
+	if err != nil {
+		return "", err
+	}
+	defines := []struct{ name, value string }{
+		{"HOMESPAN_PAIRING_CODE", fmt.Sprintf("%q", code)},
+		{"HOMESPAN_QR_ID", fmt.Sprintf("%q", id)},
+		{"HOMESPAN_CATEGORY", Category(d.Category)},
+		{"HOMESPAN_NAME", fmt.Sprintf("%q", d.Name)},
+		{"HOMESPAN_SETUP_PAYLOAD", fmt.Sprintf("%q", d.URI)},
+		{"HOMESPAN_SERIAL", fmt.Sprintf("%q", d.Serial)},
+		{"HOMESPAN_MAC", fmt.Sprintf("%q", homekit.FormatMAC(d.MAC))},
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "/* HomeSpan pairing data for %s */\n", d.comment())
+	sb.WriteString("#ifndef HOMESPAN_PAIRING_H\n#define HOMESPAN_PAIRING_H\n\n")
+	for _, def := range defines {
+		fmt.Fprintf(&sb, "#define %-24s %s\n", def.name, def.value)
+	}
+	sb.WriteString("\n#endif /* HOMESPAN_PAIRING_H */\n")
+	return sb.String(), nil
+}
+
+// comment identifies the device in generated comments.
+func (d Device) comment() string {
+	s := d.Name
+	if d.Serial != "" {
+		s += ", serial " + d.Serial
+	}
+	if d.MAC != "" {
+		s += ", MAC " + homekit.FormatMAC(d.MAC)
+	}
+	return strings.ReplaceAll(s, "*/", "* /")
+}
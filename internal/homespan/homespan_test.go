@@ -0,0 +1,114 @@
+package homespan
+
+import "testing"
+
+var testDevice = Device{
+	Name:      "Lamp",
+	Category:  5,
+	SetupCode: "613-80-755",
+	SetupID:   "1qj8",
+	URI:       "X-HM://0081YCYEP1QJ8",
+	Serial:    "SN001",
+	MAC:       "AABBCCDDEEFF",
+}
+
+func TestScript(t *testing.T) {
+	got, err := testDevice.Script()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "S 61380755\nQ 1QJ8\n"; got != want {
+		t.Errorf("Script() = %q, want %q", got, want)
+	}
+}
+
+func TestSketch(t *testing.T) {
+	got, err := testDevice.Sketch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `// HomeSpan pairing data for Lamp, serial SN001, MAC AA:BB:CC:DD:EE:FF
+// Setup code 613-80-755, setup payload X-HM://0081YCYEP1QJ8
+homeSpan.setPairingCode("61380755");
+homeSpan.setQRID("1QJ8");
+homeSpan.begin(Category::Lighting, "Lamp");
+`
+	if got != want {
+		t.Errorf("Sketch() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestHeader(t *testing.T) {
+	got, err := testDevice.Header()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `/* HomeSpan pairing data for Lamp, serial SN001, MAC AA:BB:CC:DD:EE:FF */
+#ifndef HOMESPAN_PAIRING_H
+#define HOMESPAN_PAIRING_H
+
+#define HOMESPAN_PAIRING_CODE    "61380755"
+#define HOMESPAN_QR_ID           "1QJ8"
+#define HOMESPAN_CATEGORY        Category::Lighting
+#define HOMESPAN_NAME            "Lamp"
+#define HOMESPAN_SETUP_PAYLOAD   "X-HM://0081YCYEP1QJ8"
+#define HOMESPAN_SERIAL          "SN001"
+#define HOMESPAN_MAC             "AA:BB:CC:DD:EE:FF"
+
+#endif /* HOMESPAN_PAIRING_H */
+`
+	if got != want {
+		t.Errorf("Header() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestPairingCode(t *testing.T) {
+	tests := []struct {
+		in, want string // want is empty when HomeSpan refuses the code
+	}{
+		{"613-80-755", "61380755"},
+		{" 61380755 ", "61380755"},
+		{"234-56-789", "23456789"},
+		{"123-45-678", ""},
+		{"876-54-321", ""},
+		{"000-00-000", ""},
+		{"613-80-75", ""},
+		{"613-8a-755", ""},
+	}
+	for _, tt := range tests {
+		got, err := PairingCode(tt.in)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("PairingCode(%q) = %s, want error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("PairingCode(%q) = %s, %v, want %s", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestQRID(t *testing.T) {
+	for _, id := range []string{"", "1QJ", "1QJ8A", "1Q-8"} {
+		if got, err := QRID(id); err == nil {
+			t.Errorf("QRID(%q) = %s, want error", id, got)
+		}
+	}
+}
+
+func TestCategory(t *testing.T) {
+	tests := []struct {
+		id   int
+		want string
+	}{
+		{2, "Category::Bridges"},
+		{31, "Category::Television"},
+		{16, "static_cast<Category>(16)"},
+	}
+	for _, tt := range tests {
+		if got := Category(tt.id); got != tt.want {
+			t.Errorf("Category(%d) = %s, want %s", tt.id, got, tt.want)
+		}
+	}
+}
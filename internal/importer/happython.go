@@ -0,0 +1,291 @@
+package importer
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/lordbasex/HomeKitGenQRCode/pkg/homekit"
+)
+
+// hapPythonState holds the fields of a HAP-python accessory state file used
+// for the label. HAP-python and Home Assistant have stored the values as
+// strings, Python bytes literals, hex strings or byte arrays over time, so
+// they are decoded by decodeStateValue.
+type hapPythonState struct {
+	MAC      json.RawMessage `json:"mac"`
+	PinCode  json.RawMessage `json:"pincode"`
+	SetupID  json.RawMessage `json:"setup_id"`
+	Category json.RawMessage `json:"category"`
+}
+
+// Home Assistant config entries (.storage/core.config_entries)
+type haConfigEntries struct {
+	Data struct {
+		Entries []haConfigEntry `json:"entries"`
+	} `json:"data"`
+}
+
+// haConfigEntry is a config entry of the HomeKit Bridge integration.
+type haConfigEntry struct {
+	EntryID string `json:"entry_id"`
+	Domain  string `json:"domain"`
+	Title   string `json:"title"`
+	Data    struct {
+		Name string `json:"name"`
+		Port int    `json:"port"`
+	} `json:"data"`
+	Options struct {
+		Mode   string `json:"mode"`
+		Filter struct {
+			IncludeEntities []string `json:"include_entities"`
+		} `json:"filter"`
+		EntityConfig map[string]struct {
+			Type string `json:"type"`
+		} `json:"entity_config"`
+	} `json:"options"`
+}
+
+// haDomainCategories maps the Home Assistant domain of the entity exposed in
+// accessory mode to the category HomeKit Bridge advertises for it.
+var haDomainCategories = map[string]int{
+	"alarm_control_panel": 11,
+	"automation":          8,
+	"binary_sensor":       10,
+	"button":              8,
+	"camera":              17,
+	"climate":             9,
+	"cover":               14,
+	"fan":                 3,
+	"humidifier":          22,
+	"input_boolean":       8,
+	"input_button":        8,
+	"light":               5,
+	"lock":                6,
+	"media_player":        31,
+	"remote":              31,
+	"scene":               8,
+	"script":              8,
+	"sensor":              10,
+	"switch":              8,
+	"water_heater":        9,
+}
+
+// haSwitchTypeCategories maps the entity_config type of a switch to its category.
+var haSwitchTypeCategories = map[string]int{
+	"outlet":    7,
+	"switch":    8,
+	"faucet":    29,
+	"shower":    30,
+	"sprinkler": 28,
+	"valve":     29,
+}
+
+// ReadHAPPython reads HAP-python accessory state files. A path may be a state
+// file or a directory: a Home Assistant configuration directory (its
+// .storage/homekit.*.state files) or a directory of *.state files.
+//
+// The category is inferred from the Home Assistant config entry the state file
+// belongs to (bridge mode, or the entity exposed in accessory mode) when
+// core.config_entries is found next to it; otherwise category is used, or the
+// bridge is reported without one.
+func ReadHAPPython(paths []string, category int) ([]Bridge, error) {
+	var bridges []Bridge
+	for _, path := range paths {
+		files, err := hapPythonStateFiles(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			bridges = append(bridges, readHAPPythonState(file, category))
+		}
+	}
+	return bridges, nil
+}
+
+// hapPythonStateFiles lists the state files at path.
+func hapPythonStateFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	for _, pattern := range []string{
+		filepath.Join(path, ".storage", "homekit.*.state"),
+		filepath.Join(path, "*.state"),
+	} {
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if len(files) > 0 {
+			return files, nil
+		}
+	}
+	return nil, fmt.Errorf("no HAP-python state files found in %s", path)
+}
+
+// readHAPPythonState builds a Bridge from one state file. Missing or
+// undecodable values are reported in Warnings; Validate tells whether a label
+// can still be rendered.
+func readHAPPythonState(file string, category int) Bridge {
+	entry := Bridge{
+		Name:      strings.TrimSuffix(filepath.Base(file), ".state"),
+		Source:    "hap-python",
+		StateFile: file,
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		entry.Warnings = append(entry.Warnings, err.Error())
+		return entry
+	}
+	var state hapPythonState
+	if err := json.Unmarshal(data, &state); err != nil {
+		entry.Warnings = append(entry.Warnings, fmt.Sprintf("error parsing %s: %v", file, err))
+		return entry
+	}
+
+	fields := []struct {
+		name  string
+		raw   json.RawMessage
+		valid func(string) bool
+		value *string
+	}{
+		{"mac", state.MAC, func(v string) bool { return homekit.ValidateMAC(v) == nil }, &entry.MAC},
+		{"pincode", state.PinCode, func(v string) bool { return len(homekit.PlainSetupCode(v)) == 8 }, &entry.SetupCode},
+		{"setup_id", state.SetupID, func(v string) bool { return homekit.ValidateSetupID(v) == nil }, &entry.SetupID},
+	}
+	for _, f := range fields {
+		value, err := decodeStateValue(f.raw, f.valid)
+		if err != nil {
+			entry.Warnings = append(entry.Warnings, fmt.Sprintf("%s: %v", f.name, err))
+			continue
+		}
+		*f.value = value
+	}
+	if mac, err := homekit.ParseMAC(entry.MAC); err == nil {
+		entry.MAC = mac.Format(homekit.MACStyleBare)
+	}
+	entry.setSetupCode(entry.SetupCode)
+	entry.SetupID = strings.ToUpper(entry.SetupID)
+
+	if value, err := decodeStateValue(state.Category, func(v string) bool { _, err := strconv.Atoi(v); return err == nil }); err == nil {
+		entry.Category, _ = strconv.Atoi(value)
+	}
+	if entry.Category == 0 {
+		if ha, ok := haConfigEntryFor(file); ok {
+			entry.Name = ha.Data.Name
+			if entry.Name == "" {
+				entry.Name = ha.Title
+			}
+			entry.Source = "Home Assistant"
+			entry.Port = ha.Data.Port
+			entry.Category = ha.category()
+		}
+	}
+	if entry.Category == 0 {
+		entry.Category = category
+	}
+	if entry.Category == 0 {
+		entry.Warnings = append(entry.Warnings, "the category could not be inferred; set it with --category")
+	}
+	return entry
+}
+
+// decodeStateValue decodes a state file value stored as a string, a Python
+// bytes literal (b'...'), a hex string, a byte array or a number. Of the
+// possible readings of a string, the first one accepted by valid is returned.
+func decodeStateValue(raw json.RawMessage, valid func(string) bool) (string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", errors.New("not found")
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		candidates := []string{s}
+		if len(s) >= 3 && s[0] == 'b' && (s[1] == '\'' || s[1] == '"') && s[len(s)-1] == s[1] {
+			candidates = append(candidates, s[2:len(s)-1])
+		}
+		if b, err := hex.DecodeString(s); err == nil {
+			candidates = append(candidates, string(b))
+		}
+		for _, c := range candidates {
+			if valid(c) {
+				return c, nil
+			}
+		}
+		return s, nil
+	}
+
+	var ints []int
+	if err := json.Unmarshal(raw, &ints); err == nil {
+		b := make([]byte, 0, len(ints))
+		for _, v := range ints {
+			if v < 0 || v > 255 {
+				return "", fmt.Errorf("byte value %d out of range", v)
+			}
+			b = append(b, byte(v))
+		}
+		return string(b), nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(raw, &n); err == nil {
+		return n.String(), nil
+	}
+	return "", fmt.Errorf("unsupported value %s", raw)
+}
+
+// haConfigEntryFor returns the HomeKit Bridge config entry of a Home
+// Assistant state file (.storage/homekit.<entry_id>.state), read from
+// core.config_entries in the same directory.
+func haConfigEntryFor(file string) (haConfigEntry, bool) {
+	base := filepath.Base(file)
+	if !strings.HasPrefix(base, "homekit.") {
+		return haConfigEntry{}, false
+	}
+	id := strings.TrimSuffix(strings.TrimPrefix(base, "homekit."), ".state")
+
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(file), "core.config_entries"))
+	if err != nil {
+		return haConfigEntry{}, false
+	}
+	var entries haConfigEntries
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return haConfigEntry{}, false
+	}
+	for _, e := range entries.Data.Entries {
+		if e.Domain == "homekit" && e.EntryID == id {
+			return e, true
+		}
+	}
+	return haConfigEntry{}, false
+}
+
+// category returns the category HomeKit Bridge advertises for the entry: a
+// bridge in bridge mode, or the category of the exposed entity in accessory
+// mode. It returns 0 when it cannot be inferred.
+func (e haConfigEntry) category() int {
+	if e.Options.Mode != "accessory" {
+		return categoryBridge
+	}
+	if len(e.Options.Filter.IncludeEntities) != 1 {
+		return 0
+	}
+	entity := e.Options.Filter.IncludeEntities[0]
+	domain, _, _ := strings.Cut(entity, ".")
+	if domain == "switch" {
+		if c, ok := haSwitchTypeCategories[e.Options.EntityConfig[entity].Type]; ok {
+			return c
+		}
+	}
+	return haDomainCategories[domain]
+}
@@ -0,0 +1,40 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/lordbasex/HomeKitGenQRCode/pkg/homekit"
+)
+
+func TestReadHAPPythonPIN(t *testing.T) {
+	tests := []struct {
+		pincode string // JSON value as HAP-python writes it
+		code    string
+	}{
+		{`"031-45-154"`, "031-45-154"},
+		{`"03145154"`, "031-45-154"},
+		{`"b'031-45-154'"`, "031-45-154"},
+		{`"3033312d34352d313534"`, "031-45-154"},
+		{`[48, 51, 49, 45, 52, 53, 45, 49, 53, 52]`, "031-45-154"},
+	}
+	for _, tt := range tests {
+		file := filepath.Join(t.TempDir(), "accessory.state")
+		state := `{"mac": "0E:F4:1A:2B:3C:4D", "pincode": ` + tt.pincode + `, "setup_id": "abcd", "category": 2}`
+		if err := os.WriteFile(file, []byte(state), 0644); err != nil {
+			t.Fatal(err)
+		}
+		b := readHAPPythonState(file, 0)
+		want := Bridge{
+			Name:      "accessory",
+			Source:    "hap-python",
+			SetupInfo: homekit.SetupInfo{Category: 2, SetupCode: tt.code, SetupID: "ABCD", MAC: "0EF41A2B3C4D"},
+			StateFile: file,
+		}
+		if !reflect.DeepEqual(b, want) {
+			t.Errorf("pincode %s: readHAPPythonState = %+v, want %+v", tt.pincode, b, want)
+		}
+	}
+}
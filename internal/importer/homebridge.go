@@ -0,0 +1,143 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/lordbasex/HomeKitGenQRCode/pkg/homekit"
+)
+
+// categoryBridge is the HomeKit category of a Homebridge bridge.
+const categoryBridge = 2
+
+// Homebridge configuration (config.json)
+type homebridgeConfig struct {
+	Bridge      homebridgeBridge `json:"bridge"`
+	Platforms   []homebridgeItem `json:"platforms"`
+	Accessories []homebridgeItem `json:"accessories"`
+}
+
+// homebridgeBridge is the main bridge block or a _bridge child bridge block.
+type homebridgeBridge struct {
+	Name     string `json:"name"`
+	Username string `json:"username"`
+	Port     int    `json:"port"`
+	Pin      string `json:"pin"`
+}
+
+// homebridgeItem is a platform or accessory entry, optionally run as a child bridge.
+type homebridgeItem struct {
+	Platform  string            `json:"platform"`
+	Accessory string            `json:"accessory"`
+	Name      string            `json:"name"`
+	Bridge    *homebridgeBridge `json:"_bridge"`
+}
+
+// hapNodeAccessoryInfo is the HAP-NodeJS persist/AccessoryInfo.<username>.json file.
+type hapNodeAccessoryInfo struct {
+	DisplayName string `json:"displayName"`
+	Category    int    `json:"category"`
+	PinCode     string `json:"pincode"`
+	SetupID     string `json:"setupID"`
+}
+
+// ReadHomebridge reads the main bridge and the child bridges of the Homebridge
+// installation whose storage directory (usually ~/.homebridge) is dir. The
+// username and PIN come from config.json; the setup ID and category from the
+// HAP-NodeJS persist files. Child bridges share the main bridge's PIN unless
+// their _bridge block sets one.
+func ReadHomebridge(dir string) ([]Bridge, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		return nil, fmt.Errorf("error reading Homebridge config: %w", err)
+	}
+	var config homebridgeConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", filepath.Join(dir, "config.json"), err)
+	}
+	if config.Bridge.Username == "" {
+		return nil, fmt.Errorf("%s has no bridge username", filepath.Join(dir, "config.json"))
+	}
+
+	persist := filepath.Join(dir, "persist")
+	main := config.Bridge
+	bridges := []Bridge{homebridgeEntry(main, "bridge", main, persist)}
+	for _, items := range [][]homebridgeItem{config.Platforms, config.Accessories} {
+		for _, item := range items {
+			if item.Bridge == nil {
+				continue
+			}
+			source := item.Platform
+			if source == "" {
+				source = item.Accessory
+			}
+			child := *item.Bridge
+			if child.Name == "" {
+				child.Name = item.Name
+			}
+			if child.Name == "" {
+				child.Name = source
+			}
+			if child.Pin == "" {
+				child.Pin = main.Pin
+			}
+			bridges = append(bridges, homebridgeEntry(child, source, main, persist))
+		}
+	}
+	return bridges, nil
+}
+
+// homebridgeEntry builds a Bridge from a config block and its persist file.
+// Missing or inconsistent data is reported in Warnings; Validate tells
+// whether a label can still be rendered.
+func homebridgeEntry(b homebridgeBridge, source string, main homebridgeBridge, persist string) Bridge {
+	entry := Bridge{
+		Name:      b.Name,
+		Source:    source,
+		Port:      b.Port,
+		SetupInfo: homekit.SetupInfo{Category: categoryBridge, MAC: b.Username},
+	}
+	if entry.Name == "" {
+		entry.Name = main.Name
+	}
+	entry.setSetupCode(b.Pin)
+
+	mac, err := homekit.ParseMAC(b.Username)
+	if err != nil {
+		entry.Warnings = append(entry.Warnings, fmt.Sprintf("invalid username %q: %v", b.Username, err))
+		return entry
+	}
+	entry.MAC = mac.Format(homekit.MACStyleBare)
+	entry.StateFile = filepath.Join(persist, "AccessoryInfo."+entry.MAC+".json")
+
+	var info hapNodeAccessoryInfo
+	data, err := os.ReadFile(entry.StateFile)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		entry.Warnings = append(entry.Warnings, fmt.Sprintf("%s not found: start the bridge once so it creates its setup ID", entry.StateFile))
+		entry.StateFile = ""
+		return entry
+	case err != nil:
+		entry.Warnings = append(entry.Warnings, err.Error())
+		return entry
+	}
+	if err := json.Unmarshal(data, &info); err != nil {
+		entry.Warnings = append(entry.Warnings, fmt.Sprintf("error parsing %s: %v", entry.StateFile, err))
+		return entry
+	}
+
+	entry.SetupID = info.SetupID
+	if info.Category != 0 {
+		entry.Category = info.Category
+	}
+	// HAP-NodeJS overwrites the persisted PIN with the configured one when the
+	// bridge is published, so a difference means it has not been restarted.
+	if info.PinCode != "" && homekit.PlainSetupCode(info.PinCode) != homekit.PlainSetupCode(b.Pin) {
+		entry.Warnings = append(entry.Warnings, fmt.Sprintf("PIN %s in config.json differs from %s in %s; restart the bridge before pairing with the printed code", b.Pin, info.PinCode, filepath.Base(entry.StateFile)))
+	}
+	return entry
+}
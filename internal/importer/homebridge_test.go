@@ -0,0 +1,57 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/lordbasex/HomeKitGenQRCode/pkg/homekit"
+)
+
+// writeHomebridge writes a Homebridge storage directory with a main bridge
+// using pin and its HAP-NodeJS persist file.
+func writeHomebridge(t *testing.T, pin string) string {
+	t.Helper()
+	dir := t.TempDir()
+	config := `{"bridge": {"name": "Homebridge", "username": "0E:F4:1A:2B:3C:4D", "port": 51826, "pin": "` + pin + `"}}`
+	info := `{"displayName": "Homebridge", "category": 2, "pincode": "` + pin + `", "setupID": "ABCD"}`
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "persist"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "persist", "AccessoryInfo.0EF41A2B3C4D.json"), []byte(info), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestReadHomebridge(t *testing.T) {
+	tests := []struct{ pin, code string }{
+		{"031-45-154", "031-45-154"},
+		{"03145154", "031-45-154"},
+	}
+	for _, tt := range tests {
+		dir := writeHomebridge(t, tt.pin)
+		bridges, err := ReadHomebridge(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(bridges) != 1 {
+			t.Fatalf("PIN %s: read %d bridges, want 1", tt.pin, len(bridges))
+		}
+		b := bridges[0]
+		want := Bridge{
+			Name:      "Homebridge",
+			Source:    "bridge",
+			Port:      51826,
+			SetupInfo: homekit.SetupInfo{Category: categoryBridge, SetupCode: tt.code, SetupID: "ABCD", MAC: "0EF41A2B3C4D"},
+			StateFile: filepath.Join(dir, "persist", "AccessoryInfo.0EF41A2B3C4D.json"),
+		}
+		if !reflect.DeepEqual(b, want) {
+			t.Errorf("PIN %s: ReadHomebridge = %+v, want %+v", tt.pin, b, want)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+// Package importer reads the pairing data that other HomeKit bridge software
+// keeps on disk, so that labels can be printed for existing installations.
+package importer
+
+import (
+	"errors"
+
+	"github.com/lordbasex/HomeKitGenQRCode/pkg/homekit"
+)
+
+// Bridge is one HomeKit bridge or accessory found in an installation, with
+// the values printed on its label.
+type Bridge struct {
+	Name   string // Display name
+	Source string // Where the bridge is defined, e.g. "bridge" or a plugin name
+	Port   int    // HAP TCP port (0 if not configured)
+	homekit.SetupInfo
+	// StateFile is the file the setup ID and category were read from.
+	StateFile string
+	// Warnings lists inconsistencies found while importing.
+	Warnings []string
+}
+
+// setSetupCode stores code, in XXX-XX-XXX form when it is well formed. Any
+// code the HAP specification allows pairs, so easy-to-guess codes a bridge
+// already uses are only reported in Warnings.
+func (b *Bridge) setSetupCode(code string) {
+	b.SetupCode = code
+	if normalized, err := homekit.NormalizeSetupCode(code); err == nil {
+		b.SetupCode = normalized
+	}
+	if warning := homekit.SetupCodeWarning(code); warning != "" {
+		b.Warnings = append(b.Warnings, warning)
+	}
+}
+
+// Validate reports whether the bridge has everything a label needs. The PIN
+// is checked against the HAP specification only, so easy-to-guess PINs a
+// bridge already uses still get a label; they are reported in Warnings.
+func (b Bridge) Validate() error {
+	if b.SetupID == "" {
+		return errors.New("no setup ID found")
+	}
+	return b.SetupInfo.Validate()
+}
@@ -0,0 +1,50 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lordbasex/HomeKitGenQRCode/pkg/homekit"
+)
+
+func TestSetSetupCode(t *testing.T) {
+	tests := []struct {
+		code  string
+		want  string
+		valid bool
+		warn  bool
+	}{
+		{"031-45-154", "031-45-154", true, false},
+		{"03145154", "031-45-154", true, false},
+		{"234-56-789", "234-56-789", true, true}, // allowed by the spec, easy to guess
+		{"121-21-212", "121-21-212", true, true},
+		{"765-43-210", "765-43-210", true, true},
+		{"123-45-678", "123-45-678", false, false}, // disallowed by the spec
+		{"000-00-000", "000-00-000", false, false},
+		{"1234", "1234", false, false}, // malformed codes are kept as read
+	}
+	for _, tt := range tests {
+		b := Bridge{SetupInfo: homekit.SetupInfo{Category: categoryBridge, SetupID: "ABCD", MAC: "0EF41A2B3C4D"}}
+		b.setSetupCode(tt.code)
+		if b.SetupCode != tt.want {
+			t.Errorf("setSetupCode(%q): SetupCode = %s, want %s", tt.code, b.SetupCode, tt.want)
+		}
+		if err := b.Validate(); (err == nil) != tt.valid {
+			t.Errorf("setSetupCode(%q): Validate() = %v, want valid %v", tt.code, err, tt.valid)
+		}
+		warned := false
+		for _, w := range b.Warnings {
+			warned = warned || strings.Contains(w, "easy to guess")
+		}
+		if warned != tt.warn {
+			t.Errorf("setSetupCode(%q): warnings %q, want an easy-to-guess warning = %v", tt.code, b.Warnings, tt.warn)
+		}
+	}
+}
+
+func TestValidateMissingSetupID(t *testing.T) {
+	b := Bridge{SetupInfo: homekit.SetupInfo{Category: categoryBridge, SetupCode: "031-45-154", MAC: "0EF41A2B3C4D"}}
+	if err := b.Validate(); err == nil {
+		t.Error("Validate accepted a bridge without a setup ID")
+	}
+}
@@ -0,0 +1,52 @@
+package nfc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FlipperFile returns the tag in the Flipper Zero .nfc format (version 4), so
+// that it can be emulated or written to a blank tag from the Flipper.
+func (t *Tag) FlipperFile() string {
+	spec := tagSpecs[t.Type]
+
+	var sb strings.Builder
+	sb.WriteString("Filetype: Flipper NFC device\n")
+	sb.WriteString("Version: 4\n")
+	sb.WriteString("# Device type can be ISO14443-3A, ISO14443-3B, ISO14443-4A, NTAG/Ultralight, Mifare Classic, Mifare DESFire, SLIX, ST25TB\n")
+	sb.WriteString("Device type: NTAG/Ultralight\n")
+	sb.WriteString("# UID is common for all formats\n")
+	fmt.Fprintf(&sb, "UID: %s\n", hexBytes(t.UID[:]))
+	sb.WriteString("# ISO14443-3A specific data\n")
+	sb.WriteString("ATQA: 00 44\n")
+	sb.WriteString("SAK: 00\n")
+	sb.WriteString("# NTAG/Ultralight specific data\n")
+	sb.WriteString("Data format version: 2\n")
+	fmt.Fprintf(&sb, "NTAG/Ultralight type: %s\n", spec.name)
+	fmt.Fprintf(&sb, "Signature: %s\n", hexBytes(make([]byte, 32)))
+	fmt.Fprintf(&sb, "Mifare version: %s\n", hexBytes(spec.version[:]))
+	for i := 0; i < 3; i++ {
+		tearing := "00"
+		if i == 2 {
+			tearing = "BD"
+		}
+		fmt.Fprintf(&sb, "Counter %d: 0\n", i)
+		fmt.Fprintf(&sb, "Tearing %d: %s\n", i, tearing)
+	}
+	fmt.Fprintf(&sb, "Pages total: %d\n", spec.pages)
+	fmt.Fprintf(&sb, "Pages read: %d\n", spec.pages)
+	for i := 0; i < spec.pages; i++ {
+		fmt.Fprintf(&sb, "Page %d: %s\n", i, hexBytes(t.Page(i)))
+	}
+	sb.WriteString("Failed authentication attempts: 0\n")
+	return sb.String()
+}
+
+// hexBytes formats b as space-separated uppercase hex bytes.
+func hexBytes(b []byte) string {
+	parts := make([]string, len(b))
+	for i, v := range b {
+		parts[i] = fmt.Sprintf("%02X", v)
+	}
+	return strings.Join(parts, " ")
+}
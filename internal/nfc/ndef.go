@@ -0,0 +1,86 @@
+// Package nfc builds NFC tag contents for HomeKit setup URIs: NDEF messages,
+// NTAG213/215/216 memory dumps and Flipper Zero .nfc files.
+package nfc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// uriPrefixes are the URI identifier codes of the NFC Forum URI record type
+// definition, indexed by code. Code 0 means the URI is stored in full.
+var uriPrefixes = []string{
+	"",
+	"http://www.",
+	"https://www.",
+	"http://",
+	"https://",
+	"tel:",
+	"mailto:",
+	"ftp://anonymous:anonymous@",
+	"ftp://ftp.",
+	"ftps://",
+	"sftp://",
+	"smb://",
+	"nfs://",
+	"ftp://",
+	"dav://",
+	"news:",
+	"telnet://",
+	"imap:",
+	"rtsp://",
+	"urn:",
+	"pop:",
+	"sip:",
+	"sips:",
+	"tftp:",
+	"btspp://",
+	"btl2cap://",
+	"btgoep://",
+	"tcpobex://",
+	"irdaobex://",
+	"file://",
+	"urn:epc:id:",
+	"urn:epc:tag:",
+	"urn:epc:pat:",
+	"urn:epc:raw:",
+	"urn:epc:",
+	"urn:nfc:",
+}
+
+// NDEF record header bits.
+const (
+	flagMB  = 0x80 // Message begin
+	flagME  = 0x40 // Message end
+	flagSR  = 0x10 // Short record: one-byte payload length
+	tnfWKT  = 0x01 // NFC Forum well-known type
+	typeURI = 'U'  // Well-known URI record type
+)
+
+// URIRecord returns a single-record NDEF message holding uri as a well-known
+// URI record. The longest matching standard prefix is replaced by its
+// identifier code; X-HM:// setup URIs have none and are stored in full.
+func URIRecord(uri string) ([]byte, error) {
+	code := 0
+	for i, prefix := range uriPrefixes {
+		if prefix != "" && strings.HasPrefix(uri, prefix) && len(prefix) > len(uriPrefixes[code]) {
+			code = i
+		}
+	}
+
+	payload := append([]byte{byte(code)}, uri[len(uriPrefixes[code]):]...)
+	header := byte(flagMB | flagME | tnfWKT)
+	var msg []byte
+	switch {
+	case len(payload) <= 0xFF:
+		msg = []byte{header | flagSR, 1, byte(len(payload))}
+	case len(payload) <= 0xFFFF:
+		// Long records carry a four-byte payload length
+		n := len(payload)
+		msg = []byte{header, 1, byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)}
+	default:
+		return nil, fmt.Errorf("URI of %d bytes is too long for an NFC tag", len(uri))
+	}
+	msg = append(msg, typeURI)
+	return append(msg, payload...), nil
+}
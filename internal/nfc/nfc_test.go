@@ -0,0 +1,188 @@
+package nfc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+const testURI = "X-HM://0053158R7ABCD"
+
+var testUID = [7]byte{0x04, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66}
+
+// unhex decodes a hex string, ignoring spaces.
+func unhex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestURIRecord(t *testing.T) {
+	tests := []struct {
+		uri  string
+		want string
+	}{
+		// MB|ME|SR, TNF well-known; type length 1; payload length; "U"; prefix code 0
+		{testURI, "D1 01 15 55 00" + hex.EncodeToString([]byte(testURI))},
+		{"https://www.example.com", "D1 01 0C 55 02" + hex.EncodeToString([]byte("example.com"))},
+		{"https://example.com", "D1 01 0C 55 04" + hex.EncodeToString([]byte("example.com"))},
+		{"urn:nfc:x", "D1 01 02 55 23" + hex.EncodeToString([]byte("x"))},
+	}
+	for _, tt := range tests {
+		got, err := URIRecord(tt.uri)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := unhex(t, tt.want); !bytes.Equal(got, want) {
+			t.Errorf("URIRecord(%q) = % X, want % X", tt.uri, got, want)
+		}
+	}
+
+	// Payloads over 255 bytes (here 1 + 307) use a long record with a
+	// four-byte length
+	long := "X-HM://" + strings.Repeat("A", 300)
+	got, err := URIRecord(long)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := unhex(t, "C1 01 00 00 01 34 55 00"); !bytes.Equal(got[:8], want) {
+		t.Errorf("URIRecord(long) header = % X, want % X", got[:8], want)
+	}
+	if len(got) != 8+len(long) {
+		t.Errorf("URIRecord(long) is %d bytes, want %d", len(got), 8+len(long))
+	}
+}
+
+func TestNewTagLayout(t *testing.T) {
+	msg, err := URIRecord(testURI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag, err := NewTag(NTAG213, testUID, msg, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tag.Memory) != 45*4 {
+		t.Fatalf("NTAG213 memory is %d bytes, want %d", len(tag.Memory), 45*4)
+	}
+
+	// NDEF TLV (type 03, length 25), the message, then the terminator TLV
+	user := "03 19" + " D1 01 15 55 00" + hex.EncodeToString([]byte(testURI)) + " FE"
+	pages := map[int]string{
+		0:  "04 11 22 BF", // UID0-2, BCC0 = 88h ^ UID0 ^ UID1 ^ UID2
+		1:  "33 44 55 66", // UID3-6
+		2:  "44 48 00 00", // BCC1 = UID3 ^ ... ^ UID6, internal byte, static lock bytes
+		3:  "E1 10 12 00", // CC: NDEF magic, version 1.0, 144 bytes, read/write
+		11: "00 00 00 00", // Free user memory is zero
+		39: "00 00 00 00",
+		40: "00 00 00 BD", // Dynamic lock bytes, RFUI
+		41: "04 00 00 FF", // CFG0: MIRROR, RFUI, MIRROR_PAGE, AUTH0
+		42: "00 05 00 00", // CFG1: ACCESS, RFUI
+		43: "FF FF FF FF", // PWD
+		44: "00 00 00 00", // PACK, RFUI
+	}
+	for n, want := range pages {
+		if got := tag.Page(n); !bytes.Equal(got, unhex(t, want)) {
+			t.Errorf("page %d = % X, want %s", n, got, want)
+		}
+	}
+	if want := unhex(t, user); !bytes.Equal(tag.Memory[16:16+len(want)], want) {
+		t.Errorf("user memory = % X, want % X", tag.Memory[16:16+len(want)], want)
+	}
+}
+
+func TestNewTagTypes(t *testing.T) {
+	msg, _ := URIRecord(testURI)
+	tests := []struct {
+		tag     TagType
+		pages   int
+		cc      string
+		dynLock int
+	}{
+		{NTAG213, 45, "E1 10 12 00", 40},
+		{NTAG215, 135, "E1 10 3E 00", 130},
+		{NTAG216, 231, "E1 10 6D 00", 226},
+	}
+	for _, tt := range tests {
+		tag, err := NewTag(tt.tag, testUID, msg, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.tag.Pages() != tt.pages || len(tag.Memory) != tt.pages*4 {
+			t.Errorf("%s: %d pages, %d bytes, want %d pages", tt.tag, tt.tag.Pages(), len(tag.Memory), tt.pages)
+		}
+		if got := tag.Page(3); !bytes.Equal(got, unhex(t, tt.cc)) {
+			t.Errorf("%s: CC = % X, want %s", tt.tag, got, tt.cc)
+		}
+		if got := tag.Page(tt.dynLock); !bytes.Equal(got, unhex(t, "00 00 00 BD")) {
+			t.Errorf("%s: dynamic lock page %d = % X, want 00 00 00 BD", tt.tag, tt.dynLock, got)
+		}
+		if got := tag.Page(tt.dynLock + 1); !bytes.Equal(got, unhex(t, "04 00 00 FF")) {
+			t.Errorf("%s: CFG0 = % X, want 04 00 00 FF", tt.tag, got)
+		}
+		if 4*tt.pages-36 != tt.tag.UserBytes() {
+			t.Errorf("%s: UserBytes() = %d, want %d", tt.tag, tt.tag.UserBytes(), 4*tt.pages-36)
+		}
+	}
+}
+
+func TestNewTagReadOnly(t *testing.T) {
+	msg, _ := URIRecord(testURI)
+	tag, err := NewTag(NTAG213, testUID, msg, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tag.Page(2); !bytes.Equal(got, unhex(t, "44 48 FF FF")) {
+		t.Errorf("page 2 = % X, want 44 48 FF FF (static lock bytes set)", got)
+	}
+	if got := tag.Page(3); !bytes.Equal(got, unhex(t, "E1 10 12 0F")) {
+		t.Errorf("CC = % X, want E1 10 12 0F (write access denied)", got)
+	}
+
+	// Messages past page 15 cannot be locked by the static lock bytes
+	long, _ := URIRecord("X-HM://" + strings.Repeat("A", 60))
+	if _, err := NewTag(NTAG213, testUID, long, true); err == nil {
+		t.Error("NewTag locked a message past the static lock area")
+	}
+	if _, err := NewTag(NTAG213, testUID, long, false); err != nil {
+		t.Errorf("NewTag without lock: %v", err)
+	}
+	tooLong, _ := URIRecord("X-HM://" + strings.Repeat("A", 150))
+	if _, err := NewTag(NTAG213, testUID, tooLong, false); err == nil {
+		t.Error("NewTag accepted a message larger than the NTAG213 user memory")
+	}
+}
+
+func TestFlipperFile(t *testing.T) {
+	msg, _ := URIRecord(testURI)
+	tag, err := NewTag(NTAG213, testUID, msg, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := tag.FlipperFile()
+	for _, line := range []string{
+		"Filetype: Flipper NFC device\nVersion: 4\n",
+		"Device type: NTAG/Ultralight\n",
+		"UID: 04 11 22 33 44 55 66\n",
+		"ATQA: 00 44\nSAK: 00\n",
+		"NTAG/Ultralight type: NTAG213\n",
+		"Mifare version: 00 04 04 02 01 00 0F 03\n",
+		"Pages total: 45\nPages read: 45\n",
+		"Page 0: 04 11 22 BF\n",
+		"Page 3: E1 10 12 00\n",
+		"Page 4: 03 19 D1 01\n",
+		"Page 40: 00 00 00 BD\n",
+		"Page 44: 00 00 00 00\nFailed authentication attempts: 0\n",
+	} {
+		if !strings.Contains(file, line) {
+			t.Errorf("Flipper file does not contain %q", line)
+		}
+	}
+	if n := strings.Count(file, "\nPage "); n != 45 {
+		t.Errorf("Flipper file has %d pages, want 45", n)
+	}
+}
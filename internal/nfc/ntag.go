@@ -0,0 +1,156 @@
+package nfc
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// TagType is an NXP NTAG21x tag type.
+type TagType string
+
+// Supported tag types.
+const (
+	NTAG213 TagType = "ntag213" // 144 bytes of user memory
+	NTAG215 TagType = "ntag215" // 504 bytes of user memory
+	NTAG216 TagType = "ntag216" // 888 bytes of user memory
+)
+
+// TagTypes lists the supported tag types in documentation order.
+var TagTypes = []TagType{NTAG213, NTAG215, NTAG216}
+
+// tagSpec describes the memory layout of a tag type.
+type tagSpec struct {
+	name    string  // Name as printed by readers, e.g. NTAG213
+	pages   int     // Total number of 4-byte pages
+	ccSize  byte    // Data area size in the capability container, in units of 8 bytes
+	version [8]byte // GET_VERSION response
+}
+
+var tagSpecs = map[TagType]tagSpec{
+	NTAG213: {"NTAG213", 45, 0x12, [8]byte{0x00, 0x04, 0x04, 0x02, 0x01, 0x00, 0x0F, 0x03}},
+	NTAG215: {"NTAG215", 135, 0x3E, [8]byte{0x00, 0x04, 0x04, 0x02, 0x01, 0x00, 0x11, 0x03}},
+	NTAG216: {"NTAG216", 231, 0x6D, [8]byte{0x00, 0x04, 0x04, 0x02, 0x01, 0x00, 0x13, 0x03}},
+}
+
+// ParseTagType converts a tag type name (case-insensitive) into a TagType.
+// An empty name selects NTAG213, which is large enough for a setup URI.
+func ParseTagType(name string) (TagType, error) {
+	t := TagType(strings.ToLower(strings.TrimSpace(name)))
+	if t == "" {
+		return NTAG213, nil
+	}
+	if _, ok := tagSpecs[t]; !ok {
+		return "", fmt.Errorf("unknown NFC tag type %q. Expected ntag213, ntag215 or ntag216", name)
+	}
+	return t, nil
+}
+
+// Name returns the tag type as printed by readers, e.g. NTAG213.
+func (t TagType) Name() string {
+	return tagSpecs[t].name
+}
+
+// Pages returns the total number of 4-byte pages of the tag.
+func (t TagType) Pages() int {
+	return tagSpecs[t].pages
+}
+
+// UserBytes returns the size of the user memory, from page 4 up to the
+// dynamic lock bytes.
+func (t TagType) UserBytes() int {
+	return (t.Pages() - 9) * 4
+}
+
+// Memory layout shared by the NTAG21x types. The last five pages hold the
+// dynamic lock bytes and the configuration pages.
+const (
+	pageLock      = 2  // Bytes 2-3 are the static lock bytes
+	pageCC        = 3  // Capability container
+	pageUser      = 4  // First page of user memory
+	staticLockEnd = 16 // Static lock bytes cover pages 3-15
+	internalByte  = 0x48
+	cascadeTag    = 0x88
+	nxpVendorID   = 0x04
+)
+
+// Tag is the memory image of an NTAG21x tag.
+type Tag struct {
+	Type TagType
+	// UID is the 7-byte serial number; the first byte is the NXP vendor ID.
+	UID [7]byte
+	// Memory holds all pages, 4 bytes each.
+	Memory []byte
+}
+
+// RandomUID returns a random 7-byte NXP UID.
+func RandomUID() [7]byte {
+	var uid [7]byte
+	uid[0] = nxpVendorID
+	for i := 1; i < len(uid); i++ {
+		uid[i] = byte(rand.Intn(256))
+	}
+	return uid
+}
+
+// NewTag returns the memory image of a factory-configured tag holding the
+// NDEF message msg in a TLV after the capability container. A readOnly tag
+// has its static lock bytes set and write access denied in the capability
+// container; the message must then fit in the statically locked pages.
+func NewTag(t TagType, uid [7]byte, msg []byte, readOnly bool) (*Tag, error) {
+	spec, ok := tagSpecs[t]
+	if !ok {
+		return nil, fmt.Errorf("unknown NFC tag type %q", t)
+	}
+
+	var tlv []byte
+	if len(msg) < 0xFF {
+		tlv = []byte{0x03, byte(len(msg))}
+	} else {
+		tlv = []byte{0x03, 0xFF, byte(len(msg) >> 8), byte(len(msg))}
+	}
+	tlv = append(tlv, msg...)
+	tlv = append(tlv, 0xFE) // Terminator TLV
+	if len(tlv) > t.UserBytes() {
+		return nil, fmt.Errorf("NDEF message of %d bytes does not fit in the %d bytes of an %s", len(msg), t.UserBytes(), spec.name)
+	}
+	if readOnly && len(tlv) > (staticLockEnd-pageUser)*4 {
+		return nil, fmt.Errorf("NDEF message of %d bytes extends past page %d and cannot be locked by the static lock bytes", len(msg), staticLockEnd-1)
+	}
+
+	mem := make([]byte, spec.pages*4)
+	// Pages 0-2: UID with its two check bytes, internal byte and static lock bytes
+	copy(mem[0:3], uid[0:3])
+	mem[3] = cascadeTag ^ uid[0] ^ uid[1] ^ uid[2]
+	copy(mem[4:8], uid[3:7])
+	mem[8] = uid[3] ^ uid[4] ^ uid[5] ^ uid[6]
+	mem[9] = internalByte
+
+	// Page 3: capability container (NDEF magic number, version 1.0, size, access)
+	copy(mem[pageCC*4:], []byte{0xE1, 0x10, spec.ccSize, 0x00})
+	if readOnly {
+		mem[pageLock*4+2], mem[pageLock*4+3] = 0xFF, 0xFF
+		mem[pageCC*4+3] = 0x0F
+	}
+	copy(mem[pageUser*4:], tlv)
+
+	// Dynamic lock bytes, all unlocked; the fourth byte is RFUI and reads BDh
+	mem[(spec.pages-5)*4+3] = 0xBD
+
+	// Configuration pages after the dynamic lock bytes, with factory defaults:
+	// no mirror, no password protection (AUTH0 beyond the last page)
+	cfg := (spec.pages - 4) * 4
+	copy(mem[cfg:], []byte{
+		0x04, 0x00, 0x00, 0xFF, // CFG0: MIRROR, RFUI, MIRROR_PAGE, AUTH0
+		0x00, 0x05, 0x00, 0x00, // CFG1: ACCESS, RFUI
+		0xFF, 0xFF, 0xFF, 0xFF, // PWD
+		0x00, 0x00, 0x00, 0x00, // PACK, RFUI
+	})
+
+	return &Tag{Type: t, UID: uid, Memory: mem}, nil
+}
+
+// Page returns the 4 bytes of page n.
+func (t *Tag) Page(n int) []byte {
+	return t.Memory[n*4 : n*4+4]
+}
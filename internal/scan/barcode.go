@@ -0,0 +1,447 @@
+package scan
+
+import (
+	"image"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Symbology is a linear barcode type.
+type Symbology string
+
+// Supported linear barcode symbologies
+const (
+	Code39  Symbology = "Code 39"
+	Code128 Symbology = "Code 128"
+)
+
+// Barcode is a linear barcode read from an image.
+type Barcode struct {
+	Symbology Symbology
+	Text      string
+	// Left and Right are the horizontal extent in pixels, Top and Bottom the
+	// first and last rows the barcode was read on.
+	Left, Right, Top, Bottom int
+}
+
+// minBarcodeRows is the number of rows a barcode must be read on to be
+// reported. Code 39 has no check character, so a single row is not trusted.
+const minBarcodeRows = 2
+
+// minQuietZone is the minimum light space before and after a barcode, in
+// narrow bar widths.
+const minQuietZone = 5
+
+// ReadBarcodes reads the horizontal Code 39 and Code 128 barcodes in img,
+// scanning every row left to right. Barcodes are returned top to bottom.
+// Like DecodeQRCodes, it reads a global and a local binarization of the
+// image, so that barcodes in unevenly lit photos are found too.
+func ReadBarcodes(img image.Image) []Barcode {
+	g := toGray(img)
+	barcodes := readBarcodes(g.globalThreshold())
+	for _, b := range readBarcodes(g.localThreshold()) {
+		if !slices.ContainsFunc(barcodes, func(f Barcode) bool {
+			return f.Symbology == b.Symbology && f.Text == b.Text && f.Top <= b.Bottom && b.Top <= f.Bottom
+		}) {
+			barcodes = append(barcodes, b)
+		}
+	}
+	sort.SliceStable(barcodes, func(i, j int) bool { return barcodes[i].Top < barcodes[j].Top })
+	return barcodes
+}
+
+// readBarcodes reads the barcodes in a binarized image, merging the reads of
+// adjacent rows.
+func readBarcodes(m *bitMatrix) []Barcode {
+	var found []Barcode
+	var rows []int
+	runs := make([]int, 0, 256)
+	for y := 0; y < m.height; y++ {
+		runs = m.rowRuns(y, runs[:0])
+		for _, b := range decodeRow(runs) {
+			merged := false
+			for i := range found {
+				f := &found[i]
+				if f.Symbology == b.Symbology && f.Text == b.Text && f.Bottom >= y-2 &&
+					abs(f.Left-b.Left) <= 4 && abs(f.Right-b.Right) <= 4 {
+					f.Bottom = y
+					rows[i]++
+					merged = true
+					break
+				}
+			}
+			if !merged {
+				b.Top, b.Bottom = y, y
+				found = append(found, b)
+				rows = append(rows, 1)
+			}
+		}
+	}
+
+	var barcodes []Barcode
+	for i, b := range found {
+		if rows[i] >= minBarcodeRows {
+			barcodes = append(barcodes, b)
+		}
+	}
+	return barcodes
+}
+
+// rowRuns appends the run lengths of row y to runs. Runs alternate light and
+// dark starting with light, so even indexes are spaces and odd indexes bars;
+// the first run is empty if the row starts dark.
+func (m *bitMatrix) rowRuns(y int, runs []int) []int {
+	row := m.bits[y*m.width : (y+1)*m.width]
+	dark := false
+	n := 0
+	for _, v := range row {
+		if v != dark {
+			runs = append(runs, n)
+			dark, n = !dark, 0
+		}
+		n++
+	}
+	return append(runs, n)
+}
+
+// decodeRow finds the barcodes in one row of run lengths.
+func decodeRow(runs []int) []Barcode {
+	var barcodes []Barcode
+	x := 0
+	for i := 0; i < len(runs); i++ {
+		if i%2 == 1 {
+			for _, decode := range []func([]int, int) (Barcode, int, bool){decodeCode39, decodeCode128} {
+				if b, end, ok := decode(runs, i); ok {
+					width := 0
+					for _, r := range runs[i:end] {
+						width += r
+					}
+					b.Left, b.Right = x, x+width-1
+					barcodes = append(barcodes, b)
+					break
+				}
+			}
+		}
+		x += runs[i]
+	}
+	return barcodes
+}
+
+// quietZone reports whether the space run at index i is wide enough to border
+// a barcode. Spaces at the row edges always qualify.
+func quietZone(runs []int, i int, narrow float64) bool {
+	if i == 0 || i == len(runs)-1 {
+		return true
+	}
+	return float64(runs[i]) >= minQuietZone*narrow
+}
+
+// code39Alphabet lists the Code 39 characters; code39Patterns holds their
+// nine-element bar/space patterns with a 1 bit for each wide element,
+// first element in the most significant bit.
+const code39Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ-. $/+%*"
+
+var code39Patterns = [...]int{
+	0x034, 0x121, 0x061, 0x160, 0x031, 0x130, 0x070, 0x025, 0x124, 0x064,
+	0x109, 0x049, 0x148, 0x019, 0x118, 0x058, 0x00D, 0x10C, 0x04C, 0x01C,
+	0x103, 0x043, 0x142, 0x013, 0x112, 0x052, 0x007, 0x106, 0x046, 0x016,
+	0x181, 0x0C1, 0x1C0, 0x091, 0x190, 0x0D0, 0x085, 0x184, 0x0C4, 0x0A8,
+	0x0A2, 0x08A, 0x02A, 0x094,
+}
+
+// code39Char decodes nine element widths. It returns the character and the
+// mean narrow element width.
+func code39Char(widths []int) (byte, float64, bool) {
+	sorted := append([]int(nil), widths...)
+	sort.Ints(sorted)
+	narrowMax, wideMin := sorted[5], sorted[6]
+	if float64(wideMin) >= 1.5*float64(narrowMax) && sorted[8] <= 5*sorted[0] {
+		threshold := float64(narrowMax+wideMin) / 2
+		if ch, narrow, ok := code39Lookup(widths, func(i int) float64 { return threshold }); ok {
+			return ch, narrow, true
+		}
+	}
+	return code39CharSpread(widths)
+}
+
+// code39CharSpread decodes nine element widths with separate thresholds for
+// bars and spaces, which tolerates printing that widens the bars and narrows
+// the spaces. Each character has either two wide bars and one wide space, or
+// three wide spaces.
+func code39CharSpread(widths []int) (byte, float64, bool) {
+	var bars, spaces []int
+	for i, w := range widths {
+		if i%2 == 0 {
+			bars = append(bars, w)
+		} else {
+			spaces = append(spaces, w)
+		}
+	}
+	slices.Sort(bars)
+	slices.Sort(spaces)
+
+	separates := func(narrow, wide int) bool { return float64(wide) >= 1.5*float64(narrow) }
+	var barThreshold, spaceThreshold float64
+	switch {
+	case separates(bars[2], bars[3]) && separates(spaces[2], spaces[3]):
+		barThreshold = float64(bars[2]+bars[3]) / 2
+		spaceThreshold = float64(spaces[2]+spaces[3]) / 2
+	case !separates(bars[0], bars[4]) && separates(spaces[0], spaces[1]):
+		barThreshold = math.Inf(1)
+		spaceThreshold = float64(spaces[0]+spaces[1]) / 2
+	default:
+		return 0, 0, false
+	}
+	return code39Lookup(widths, func(i int) float64 {
+		if i%2 == 0 {
+			return barThreshold
+		}
+		return spaceThreshold
+	})
+}
+
+// code39Lookup classifies each element as wide if it exceeds threshold(i) and
+// looks up the resulting pattern.
+func code39Lookup(widths []int, threshold func(i int) float64) (byte, float64, bool) {
+	pattern, narrowSum := 0, 0
+	for i, w := range widths {
+		pattern <<= 1
+		if float64(w) > threshold(i) {
+			pattern |= 1
+		} else {
+			narrowSum += w
+		}
+	}
+	for i, p := range code39Patterns {
+		if p == pattern {
+			return code39Alphabet[i], float64(narrowSum) / 6, true
+		}
+	}
+	return 0, 0, false
+}
+
+// decodeCode39 decodes a Code 39 barcode whose start character begins at bar
+// run start. The start and stop characters are not part of the text.
+func decodeCode39(runs []int, start int) (Barcode, int, bool) {
+	if start+9 > len(runs) {
+		return Barcode{}, 0, false
+	}
+	// Cheap check before decoding: the quiet zone is wider than any narrow element
+	if !quietZone(runs, start-1, float64(slices.Min(runs[start:start+9]))) {
+		return Barcode{}, 0, false
+	}
+	ch, narrow, ok := code39Char(runs[start : start+9])
+	if !ok || ch != '*' || !quietZone(runs, start-1, narrow) {
+		return Barcode{}, 0, false
+	}
+
+	var sb strings.Builder
+	for i := start + 10; i+9 <= len(runs); i += 10 {
+		// Characters are separated by a narrow-ish gap
+		if float64(runs[i-1]) > 4*narrow {
+			return Barcode{}, 0, false
+		}
+		ch, _, ok := code39Char(runs[i : i+9])
+		if !ok {
+			return Barcode{}, 0, false
+		}
+		if ch == '*' {
+			if sb.Len() == 0 || (i+9 < len(runs) && !quietZone(runs, i+9, narrow)) {
+				return Barcode{}, 0, false
+			}
+			return Barcode{Symbology: Code39, Text: sb.String()}, i + 9, true
+		}
+		sb.WriteByte(ch)
+	}
+	return Barcode{}, 0, false
+}
+
+// code128Patterns holds the bar/space module widths of Code 128 symbols 0-105;
+// code128Stop is the stop pattern including its final bar.
+var code128Patterns = [...][6]int{
+	{2, 1, 2, 2, 2, 2}, {2, 2, 2, 1, 2, 2}, {2, 2, 2, 2, 2, 1}, {1, 2, 1, 2, 2, 3}, {1, 2, 1, 3, 2, 2},
+	{1, 3, 1, 2, 2, 2}, {1, 2, 2, 2, 1, 3}, {1, 2, 2, 3, 1, 2}, {1, 3, 2, 2, 1, 2}, {2, 2, 1, 2, 1, 3},
+	{2, 2, 1, 3, 1, 2}, {2, 3, 1, 2, 1, 2}, {1, 1, 2, 2, 3, 2}, {1, 2, 2, 1, 3, 2}, {1, 2, 2, 2, 3, 1},
+	{1, 1, 3, 2, 2, 2}, {1, 2, 3, 1, 2, 2}, {1, 2, 3, 2, 2, 1}, {2, 2, 3, 2, 1, 1}, {2, 2, 1, 1, 3, 2},
+	{2, 2, 1, 2, 3, 1}, {2, 1, 3, 2, 1, 2}, {2, 2, 3, 1, 1, 2}, {3, 1, 2, 1, 3, 1}, {3, 1, 1, 2, 2, 2},
+	{3, 2, 1, 1, 2, 2}, {3, 2, 1, 2, 2, 1}, {3, 1, 2, 2, 1, 2}, {3, 2, 2, 1, 1, 2}, {3, 2, 2, 2, 1, 1},
+	{2, 1, 2, 1, 2, 3}, {2, 1, 2, 3, 2, 1}, {2, 3, 2, 1, 2, 1}, {1, 1, 1, 3, 2, 3}, {1, 3, 1, 1, 2, 3},
+	{1, 3, 1, 3, 2, 1}, {1, 1, 2, 3, 1, 3}, {1, 3, 2, 1, 1, 3}, {1, 3, 2, 3, 1, 1}, {2, 1, 1, 3, 1, 3},
+	{2, 3, 1, 1, 1, 3}, {2, 3, 1, 3, 1, 1}, {1, 1, 2, 1, 3, 3}, {1, 1, 2, 3, 3, 1}, {1, 3, 2, 1, 3, 1},
+	{1, 1, 3, 1, 2, 3}, {1, 1, 3, 3, 2, 1}, {1, 3, 3, 1, 2, 1}, {3, 1, 3, 1, 2, 1}, {2, 1, 1, 3, 3, 1},
+	{2, 3, 1, 1, 3, 1}, {2, 1, 3, 1, 1, 3}, {2, 1, 3, 3, 1, 1}, {2, 1, 3, 1, 3, 1}, {3, 1, 1, 1, 2, 3},
+	{3, 1, 1, 3, 2, 1}, {3, 3, 1, 1, 2, 1}, {3, 1, 2, 1, 1, 3}, {3, 1, 2, 3, 1, 1}, {3, 3, 2, 1, 1, 1},
+	{3, 1, 4, 1, 1, 1}, {2, 2, 1, 4, 1, 1}, {4, 3, 1, 1, 1, 1}, {1, 1, 1, 2, 2, 4}, {1, 1, 1, 4, 2, 2},
+	{1, 2, 1, 1, 2, 4}, {1, 2, 1, 4, 2, 1}, {1, 4, 1, 1, 2, 2}, {1, 4, 1, 2, 2, 1}, {1, 1, 2, 2, 1, 4},
+	{1, 1, 2, 4, 1, 2}, {1, 2, 2, 1, 1, 4}, {1, 2, 2, 4, 1, 1}, {1, 4, 2, 1, 1, 2}, {1, 4, 2, 2, 1, 1},
+	{2, 4, 1, 2, 1, 1}, {2, 2, 1, 1, 1, 4}, {4, 1, 3, 1, 1, 1}, {2, 4, 1, 1, 1, 2}, {1, 3, 4, 1, 1, 1},
+	{1, 1, 1, 2, 4, 2}, {1, 2, 1, 1, 4, 2}, {1, 2, 1, 2, 4, 1}, {1, 1, 4, 2, 1, 2}, {1, 2, 4, 1, 1, 2},
+	{1, 2, 4, 2, 1, 1}, {4, 1, 1, 2, 1, 2}, {4, 2, 1, 1, 1, 2}, {4, 2, 1, 2, 1, 1}, {2, 1, 2, 1, 4, 1},
+	{2, 1, 4, 1, 2, 1}, {4, 1, 2, 1, 2, 1}, {1, 1, 1, 1, 4, 3}, {1, 1, 1, 3, 4, 1}, {1, 3, 1, 1, 4, 1},
+	{1, 1, 4, 1, 1, 3}, {1, 1, 4, 3, 1, 1}, {4, 1, 1, 1, 1, 3}, {4, 1, 1, 3, 1, 1}, {1, 1, 3, 1, 4, 1},
+	{1, 1, 4, 1, 3, 1}, {3, 1, 1, 1, 4, 1}, {4, 1, 1, 1, 3, 1}, {2, 1, 1, 4, 1, 2}, {2, 1, 1, 2, 1, 4},
+	{2, 1, 1, 2, 3, 2},
+}
+
+var code128Stop = []int{2, 3, 3, 1, 1, 1, 2}
+
+// Code 128 special symbols
+const (
+	code128Shift  = 98
+	code128CodeC  = 99
+	code128CodeB  = 100
+	code128CodeA  = 101
+	code128FNC1   = 102
+	code128StartA = 103
+)
+
+// Maximum average and per-element deviation from a Code 128 pattern, in modules
+const (
+	maxCode128Variance        = 0.25
+	maxCode128ElementVariance = 0.7
+)
+
+// patternVariance returns the mean deviation of widths from pattern per
+// module, or +Inf if any element deviates too much.
+func patternVariance(widths, pattern []int) float64 {
+	total, modules := 0, 0
+	for i := range widths {
+		total += widths[i]
+		modules += pattern[i]
+	}
+	unit := float64(total) / float64(modules)
+	var variance float64
+	for i, w := range widths {
+		d := math.Abs(float64(w) - float64(pattern[i])*unit)
+		if d > maxCode128ElementVariance*unit {
+			return math.Inf(1)
+		}
+		variance += d
+	}
+	return variance / float64(total)
+}
+
+// code128Symbol matches six element widths to the closest symbol value.
+func code128Symbol(widths []int) (int, bool) {
+	best, bestVariance := -1, maxCode128Variance
+	for value, pattern := range code128Patterns {
+		if v := patternVariance(widths, pattern[:]); v < bestVariance {
+			best, bestVariance = value, v
+		}
+	}
+	return best, best >= 0
+}
+
+// decodeCode128 decodes a Code 128 barcode whose start symbol begins at bar
+// run start. The checksum is verified and is not part of the text; FNC1 is
+// returned as the GS1 group separator (except in first position).
+func decodeCode128(runs []int, start int) (Barcode, int, bool) {
+	if start+6 > len(runs) {
+		return Barcode{}, 0, false
+	}
+	total := 0
+	for _, w := range runs[start : start+6] {
+		total += w
+	}
+	if !quietZone(runs, start-1, float64(total)/11) {
+		return Barcode{}, 0, false
+	}
+	value, ok := code128Symbol(runs[start : start+6])
+	if !ok || value < code128StartA {
+		return Barcode{}, 0, false
+	}
+
+	values := []int{value}
+	i := start + 6
+	for {
+		if i+7 <= len(runs) && patternVariance(runs[i:i+7], code128Stop) < maxCode128Variance {
+			break
+		}
+		if i+6 > len(runs) || len(values) > 80 {
+			return Barcode{}, 0, false
+		}
+		v, ok := code128Symbol(runs[i : i+6])
+		if !ok || v >= code128StartA {
+			return Barcode{}, 0, false
+		}
+		values = append(values, v)
+		i += 6
+	}
+	end := i + 7
+	if end < len(runs) && !quietZone(runs, end, float64(total)/11) {
+		return Barcode{}, 0, false
+	}
+
+	// Start symbol, at least one data symbol, and the check symbol
+	if len(values) < 3 {
+		return Barcode{}, 0, false
+	}
+	check := values[0]
+	for k := 1; k < len(values)-1; k++ {
+		check += k * values[k]
+	}
+	if check%103 != values[len(values)-1] {
+		return Barcode{}, 0, false
+	}
+
+	text, ok := code128Text(values[:len(values)-1])
+	if !ok {
+		return Barcode{}, 0, false
+	}
+	return Barcode{Symbology: Code128, Text: text}, end, true
+}
+
+// code128Text decodes symbol values, starting with the start symbol, using
+// code sets A, B and C.
+func code128Text(values []int) (string, bool) {
+	set := values[0] - code128StartA // 0 = A, 1 = B, 2 = C
+	shifted := false
+	var sb strings.Builder
+	for k, v := range values[1:] {
+		current := set
+		if shifted {
+			current = 1 - set
+			shifted = false
+		}
+
+		if v == code128FNC1 {
+			if k > 0 {
+				sb.WriteByte(0x1D)
+			}
+			continue
+		}
+		switch current {
+		case 2:
+			switch {
+			case v < 100:
+				sb.WriteByte(byte('0' + v/10))
+				sb.WriteByte(byte('0' + v%10))
+			case v == code128CodeB:
+				set = 1
+			case v == code128CodeA:
+				set = 0
+			}
+		case 0, 1:
+			switch {
+			case current == 0 && v < 64:
+				sb.WriteByte(byte(v + 32))
+			case current == 0 && v < 96:
+				sb.WriteByte(byte(v - 64))
+			case current == 1 && v < 96:
+				sb.WriteByte(byte(v + 32))
+			case v == code128Shift:
+				shifted = true
+			case v == code128CodeC:
+				set = 2
+			case current == 0 && v == code128CodeB, current == 1 && v == code128CodeA:
+				set = 1 - current
+			}
+			// FNC2, FNC3 and FNC4 carry no text
+		default:
+			return "", false
+		}
+	}
+	return sb.String(), true
+}
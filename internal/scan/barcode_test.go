@@ -0,0 +1,137 @@
+package scan
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// barcodeImage draws elements, alternating bar and space module widths
+// starting with a bar, unit pixels per module with a 10-module quiet zone on
+// both sides.
+func barcodeImage(elements []int, unit int) *image.Gray {
+	width := 20 * unit
+	for _, e := range elements {
+		width += e * unit
+	}
+	img := image.NewGray(image.Rect(0, 0, width, 20))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	x := 10 * unit
+	for i, e := range elements {
+		if i%2 == 0 {
+			for y := 0; y < 20; y++ {
+				for dx := 0; dx < e*unit; dx++ {
+					img.SetGray(x+dx, y, color.Gray{Y: 0})
+				}
+			}
+		}
+		x += e * unit
+	}
+	return img
+}
+
+// code39Elements converts Code 39 characters given as n/w strings of their
+// nine elements to module widths with a 3:1 ratio and one-module gaps.
+func code39Elements(chars ...string) []int {
+	var elements []int
+	for i, c := range chars {
+		if i > 0 {
+			elements = append(elements, 1) // Inter-character gap
+		}
+		for _, e := range c {
+			if e == 'w' {
+				elements = append(elements, 3)
+			} else {
+				elements = append(elements, 1)
+			}
+		}
+	}
+	return elements
+}
+
+func TestReadCode39(t *testing.T) {
+	// Character patterns from the Code 39 specification
+	const (
+		star = "nwnnwnwnn"
+		a    = "wnnnnwnnw"
+		one  = "wnnwnnnnw"
+		zero = "nnnwwnwnn"
+	)
+	for _, unit := range []int{2, 3, 5} {
+		img := barcodeImage(code39Elements(star, a, one, zero, star), unit)
+		barcodes := ReadBarcodes(img)
+		if len(barcodes) != 1 {
+			t.Fatalf("unit %d: read %d barcodes, want 1", unit, len(barcodes))
+		}
+		if b := barcodes[0]; b.Symbology != Code39 || b.Text != "A10" {
+			t.Errorf("unit %d: read %s %q, want Code 39 \"A10\"", unit, b.Symbology, b.Text)
+		}
+	}
+}
+
+func TestCode128Patterns(t *testing.T) {
+	// Spot checks against the Code 128 specification
+	tests := []struct {
+		value   int
+		pattern [6]int
+	}{
+		{0, [6]int{2, 1, 2, 2, 2, 2}},
+		{code128StartA, [6]int{2, 1, 1, 4, 1, 2}},
+		{code128StartA + 1, [6]int{2, 1, 1, 2, 1, 4}}, // Start B
+		{code128StartA + 2, [6]int{2, 1, 1, 2, 3, 2}}, // Start C
+		{code128FNC1, [6]int{4, 1, 1, 1, 3, 1}},
+	}
+	for _, tt := range tests {
+		if code128Patterns[tt.value] != tt.pattern {
+			t.Errorf("symbol %d pattern %v, want %v", tt.value, code128Patterns[tt.value], tt.pattern)
+		}
+	}
+}
+
+// code128Elements returns the module widths of the symbol values followed by
+// their check symbol and the stop pattern.
+func code128Elements(values ...int) []int {
+	check := values[0]
+	for i, v := range values[1:] {
+		check += (i + 1) * v
+	}
+	var elements []int
+	for _, v := range append(values, check%103) {
+		elements = append(elements, code128Patterns[v][:]...)
+	}
+	return append(elements, code128Stop...)
+}
+
+func TestReadCode128(t *testing.T) {
+	const startB, startC = code128StartA + 1, code128StartA + 2
+	tests := []struct {
+		name   string
+		values []int
+		want   string
+	}{
+		{"set B", []int{startB, 'H' - 32, 'K' - 32, '-' - 32, '7' - 32}, "HK-7"},
+		{"set C", []int{startC, 12, 34, 56}, "123456"},
+		{"set C to B", []int{startC, 12, 34, code128CodeB, 'X' - 32}, "1234X"},
+	}
+	for _, tt := range tests {
+		barcodes := ReadBarcodes(barcodeImage(code128Elements(tt.values...), 3))
+		if len(barcodes) != 1 {
+			t.Errorf("%s: read %d barcodes, want 1", tt.name, len(barcodes))
+			continue
+		}
+		if b := barcodes[0]; b.Symbology != Code128 || b.Text != tt.want {
+			t.Errorf("%s: read %s %q, want Code 128 %q", tt.name, b.Symbology, b.Text, tt.want)
+		}
+	}
+}
+
+func TestReadCode128BadChecksum(t *testing.T) {
+	elements := code128Elements(code128StartA+1, 'H'-32, 'K'-32)
+	// Replace the check symbol with another one
+	copy(elements[18:24], code128Patterns[0][:])
+	if barcodes := ReadBarcodes(barcodeImage(elements, 3)); len(barcodes) != 0 {
+		t.Errorf("read %q with a wrong check symbol", barcodes[0].Text)
+	}
+}
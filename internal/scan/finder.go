@@ -0,0 +1,433 @@
+package scan
+
+import (
+	"math"
+	"sort"
+)
+
+// finderPattern is a candidate center of one of the three 7×7 finder patterns.
+type finderPattern struct {
+	x, y       float64
+	moduleSize float64
+	count      int // Number of scan lines that confirmed it
+}
+
+// aboutEquals reports whether a pattern found at (x, y) is the same one.
+func (p finderPattern) aboutEquals(moduleSize, x, y float64) bool {
+	if math.Abs(x-p.x) > moduleSize || math.Abs(y-p.y) > moduleSize {
+		return false
+	}
+	diff := math.Abs(moduleSize - p.moduleSize)
+	return diff <= 1 || diff <= p.moduleSize
+}
+
+// combine averages another sighting into the pattern.
+func (p finderPattern) combine(moduleSize, x, y float64) finderPattern {
+	n := float64(p.count)
+	return finderPattern{
+		x:          (n*p.x + x) / (n + 1),
+		y:          (n*p.y + y) / (n + 1),
+		moduleSize: (n*p.moduleSize + moduleSize) / (n + 1),
+		count:      p.count + 1,
+	}
+}
+
+func distance(ax, ay, bx, by float64) float64 {
+	return math.Hypot(ax-bx, ay-by)
+}
+
+// foundPatternCross reports whether five run lengths have the 1:1:3:1:1
+// dark-light-dark-light-dark ratio of a finder pattern.
+func foundPatternCross(c [5]int) bool {
+	total := 0
+	for _, n := range c {
+		if n == 0 {
+			return false
+		}
+		total += n
+	}
+	if total < 7 {
+		return false
+	}
+	module := float64(total) / 7
+	variance := module / 2
+	return math.Abs(module-float64(c[0])) < variance &&
+		math.Abs(module-float64(c[1])) < variance &&
+		math.Abs(3*module-float64(c[2])) < 3*variance &&
+		math.Abs(module-float64(c[3])) < variance &&
+		math.Abs(module-float64(c[4])) < variance
+}
+
+// centerFromEnd returns the center of the pattern whose runs end at end.
+func centerFromEnd(c [5]int, end int) float64 {
+	return float64(end-c[4]-c[3]) - float64(c[2])/2
+}
+
+// finderScanner finds finder patterns in a binarized image.
+type finderScanner struct {
+	m          *bitMatrix
+	candidates []finderPattern
+}
+
+// findFinderPatterns scans the rows of m for 1:1:3:1:1 runs, confirms them
+// vertically and horizontally and returns the merged candidates.
+func findFinderPatterns(m *bitMatrix) []finderPattern {
+	f := &finderScanner{m: m}
+	skip := max(m.height/400, 1)
+
+	for y := skip / 2; y < m.height; y += skip {
+		var c [5]int
+		state := 0
+		for x := 0; x < m.width; x++ {
+			if m.get(x, y) {
+				if state&1 == 1 {
+					state++
+				}
+				c[state]++
+				continue
+			}
+			if state&1 == 1 {
+				c[state]++
+				continue
+			}
+			if state != 4 {
+				state++
+				c[state]++
+				continue
+			}
+			if foundPatternCross(c) && f.handlePossibleCenter(c, x, y) {
+				c, state = [5]int{}, 0
+				continue
+			}
+			c = [5]int{c[2], c[3], c[4], 1, 0}
+			state = 3
+		}
+		if foundPatternCross(c) {
+			f.handlePossibleCenter(c, m.width, y)
+		}
+	}
+	return f.candidates
+}
+
+// handlePossibleCenter cross-checks a horizontal match ending at endX on row y
+// and records it if it is confirmed.
+func (f *finderScanner) handlePossibleCenter(c [5]int, endX, y int) bool {
+	total := c[0] + c[1] + c[2] + c[3] + c[4]
+	centerX := centerFromEnd(c, endX)
+	centerY := f.crossCheck(int(centerX), y, 0, 1, c[2], total)
+	if math.IsNaN(centerY) {
+		return false
+	}
+	centerX = f.crossCheck(int(centerX), int(centerY), 1, 0, c[2], total)
+	if math.IsNaN(centerX) {
+		return false
+	}
+
+	moduleSize := float64(total) / 7
+	for i, p := range f.candidates {
+		if p.aboutEquals(moduleSize, centerX, centerY) {
+			f.candidates[i] = p.combine(moduleSize, centerX, centerY)
+			return true
+		}
+	}
+	f.candidates = append(f.candidates, finderPattern{x: centerX, y: centerY, moduleSize: moduleSize, count: 1})
+	return true
+}
+
+// crossCheck counts the runs through (x, y) along direction (dx, dy), which is
+// (0, 1) for vertical and (1, 0) for horizontal, and returns the coordinate of
+// the pattern center along that direction, or NaN if there is no finder
+// pattern of a similar size.
+func (f *finderScanner) crossCheck(x, y, dx, dy, maxCount, originalTotal int) float64 {
+	m := f.m
+	if !m.get(x, y) {
+		return math.NaN()
+	}
+	limit := m.width
+	pos := x
+	if dy != 0 {
+		limit, pos = m.height, y
+	}
+	at := func(i int) bool {
+		if dy != 0 {
+			return m.get(x, i)
+		}
+		return m.get(i, y)
+	}
+
+	var c [5]int
+	i := pos
+	for i >= 0 && at(i) {
+		c[2]++
+		i--
+	}
+	for i >= 0 && !at(i) && c[1] <= maxCount {
+		c[1]++
+		i--
+	}
+	if i < 0 || c[1] > maxCount {
+		return math.NaN()
+	}
+	for i >= 0 && at(i) && c[0] <= maxCount {
+		c[0]++
+		i--
+	}
+	if c[0] > maxCount {
+		return math.NaN()
+	}
+
+	i = pos + 1
+	for i < limit && at(i) {
+		c[2]++
+		i++
+	}
+	for i < limit && !at(i) && c[3] <= maxCount {
+		c[3]++
+		i++
+	}
+	if i == limit || c[3] > maxCount {
+		return math.NaN()
+	}
+	for i < limit && at(i) && c[4] <= maxCount {
+		c[4]++
+		i++
+	}
+	if c[4] > maxCount {
+		return math.NaN()
+	}
+
+	total := c[0] + c[1] + c[2] + c[3] + c[4]
+	if 5*abs(total-originalTotal) >= 2*originalTotal || !foundPatternCross(c) {
+		return math.NaN()
+	}
+	return centerFromEnd(c, i)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// finderTriple is three finder patterns ordered as in an upright symbol.
+type finderTriple struct {
+	bottomLeft, topLeft, topRight finderPattern
+	score                         float64 // Lower is more plausible
+}
+
+// maxFinderCandidates bounds the candidates combined into triples.
+const maxFinderCandidates = 16
+
+// finderTriples combines candidates into plausible symbol corners: similar
+// module sizes and a roughly right isosceles triangle. The most plausible
+// triples come first.
+func finderTriples(candidates []finderPattern) []finderTriple {
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].count > candidates[j].count })
+
+	// Patterns seen on a single scan line are usually noise
+	confirmed := 0
+	for _, p := range candidates {
+		if p.count >= 2 {
+			confirmed++
+		}
+	}
+	if confirmed >= 3 {
+		candidates = candidates[:confirmed]
+	}
+	if len(candidates) > maxFinderCandidates {
+		candidates = candidates[:maxFinderCandidates]
+	}
+
+	var triples []finderTriple
+	for i := 0; i < len(candidates); i++ {
+		for j := i + 1; j < len(candidates); j++ {
+			for k := j + 1; k < len(candidates); k++ {
+				t, ok := orderPatterns(candidates[i], candidates[j], candidates[k])
+				if ok {
+					triples = append(triples, t)
+				}
+			}
+		}
+	}
+	sort.SliceStable(triples, func(i, j int) bool { return triples[i].score < triples[j].score })
+	return triples
+}
+
+// orderPatterns identifies the top-left pattern (opposite the longest side)
+// and orients the other two, and scores how much they look like a symbol.
+func orderPatterns(a, b, c finderPattern) (finderTriple, bool) {
+	ab := distance(a.x, a.y, b.x, b.y)
+	bc := distance(b.x, b.y, c.x, c.y)
+	ac := distance(a.x, a.y, c.x, c.y)
+
+	var t finderTriple
+	switch {
+	case bc >= ab && bc >= ac:
+		t = finderTriple{bottomLeft: b, topLeft: a, topRight: c}
+	case ac >= bc && ac >= ab:
+		t = finderTriple{bottomLeft: a, topLeft: b, topRight: c}
+	default:
+		t = finderTriple{bottomLeft: a, topLeft: c, topRight: b}
+	}
+	// In image coordinates, top-right lies clockwise from bottom-left
+	bl, tl, tr := t.bottomLeft, t.topLeft, t.topRight
+	if (tr.x-tl.x)*(bl.y-tl.y)-(tr.y-tl.y)*(bl.x-tl.x) < 0 {
+		t.bottomLeft, t.topRight = tr, bl
+		bl, tr = t.bottomLeft, t.topRight
+	}
+
+	sizes := []float64{bl.moduleSize, tl.moduleSize, tr.moduleSize}
+	lo, hi := math.Min(sizes[0], math.Min(sizes[1], sizes[2])), math.Max(sizes[0], math.Max(sizes[1], sizes[2]))
+	if hi > 2*lo {
+		return t, false
+	}
+
+	top := distance(tl.x, tl.y, tr.x, tr.y)
+	left := distance(tl.x, tl.y, bl.x, bl.y)
+	diagonal := distance(bl.x, bl.y, tr.x, tr.y)
+	module := (lo + hi) / 2
+	if top < 10*module || left < 10*module || top > 2*left || left > 2*top {
+		return t, false
+	}
+	right := math.Abs(diagonal*diagonal-top*top-left*left) / (diagonal * diagonal)
+	if right > 0.5 {
+		return t, false
+	}
+
+	t.score = right + math.Abs(top-left)/math.Max(top, left) + (hi-lo)/hi
+	return t, true
+}
+
+// findAlignment searches for the alignment pattern (a dark module inside a
+// light ring) near (estX, estY), within allowance modules.
+func findAlignment(m *bitMatrix, estX, estY, moduleSize, allowance float64) (float64, float64, bool) {
+	reach := allowance * moduleSize
+	x0, x1 := max(int(estX-reach), 0), min(int(estX+reach), m.width-1)
+	y0, y1 := max(int(estY-reach), 0), min(int(estY+reach), m.height-1)
+	if x1-x0 < int(3*moduleSize) || y1-y0 < int(3*moduleSize) {
+		return 0, 0, false
+	}
+
+	near := func(n int) bool { return math.Abs(float64(n)-moduleSize) < moduleSize/2+1 }
+	var candidates []finderPattern
+	var runs []int
+	for y := y0; y <= y1; y++ {
+		// Run lengths along the row, alternating light and dark
+		runs = runs[:0]
+		start := x0
+		for x := x0 + 1; x <= x1+1; x++ {
+			if x > x1 || m.get(x, y) != m.get(x-1, y) {
+				runs = append(runs, x-start)
+				start = x
+			}
+		}
+		pos := x0
+		for i := 0; i+2 < len(runs); i++ {
+			dark := m.get(pos, y)
+			if !dark && near(runs[i]) && near(runs[i+1]) && near(runs[i+2]) {
+				cx := pos + runs[i] + runs[i+1]/2
+				if cy, ok := alignmentVertical(m, cx, y, near); ok {
+					fx := float64(pos+runs[i]) + float64(runs[i+1])/2
+					merged := false
+					for k, p := range candidates {
+						if p.aboutEquals(moduleSize, fx, cy) {
+							candidates[k] = p.combine(moduleSize, fx, cy)
+							merged = true
+							break
+						}
+					}
+					if !merged {
+						candidates = append(candidates, finderPattern{x: fx, y: cy, moduleSize: moduleSize, count: 1})
+					}
+				}
+			}
+			pos += runs[i]
+		}
+	}
+
+	best, bestDist := -1, math.Inf(1)
+	for i, p := range candidates {
+		// Prefer patterns confirmed on several rows, then the nearest
+		d := distance(p.x, p.y, estX, estY) / float64(p.count)
+		if d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	if best < 0 {
+		return 0, 0, false
+	}
+	return candidates[best].x, candidates[best].y, true
+}
+
+// alignmentVertical checks the light-dark-light runs through (x, y) vertically
+// and returns the center of the dark module.
+func alignmentVertical(m *bitMatrix, x, y int, near func(int) bool) (float64, bool) {
+	if !m.get(x, y) {
+		return 0, false
+	}
+	top := y
+	for top > 0 && m.get(x, top-1) {
+		top--
+	}
+	bottom := y
+	for bottom < m.height-1 && m.get(x, bottom+1) {
+		bottom++
+	}
+	if !near(bottom - top + 1) {
+		return 0, false
+	}
+
+	above := 0
+	for i := top - 1; i >= 0 && !m.get(x, i); i-- {
+		above++
+	}
+	below := 0
+	for i := bottom + 1; i < m.height && !m.get(x, i); i++ {
+		below++
+	}
+	if !near(above) || !near(below) {
+		return 0, false
+	}
+	return float64(top+bottom+1) / 2, true
+}
+
+// moduleSizeAlong estimates the module size from the finder pattern at a in
+// the direction of b, measuring its dark-light-dark runs through the center
+// both ways (7 modules in total). Unlike the row-based estimate, this is
+// independent of the symbol's rotation.
+func moduleSizeAlong(m *bitMatrix, a, b finderPattern) float64 {
+	dx, dy := b.x-a.x, b.y-a.y
+	n := math.Hypot(dx, dy)
+	if n == 0 {
+		return a.moduleSize
+	}
+	dx, dy = dx/n, dy/n
+	size := edgeDistance(m, a.x, a.y, dx, dy) + edgeDistance(m, a.x, a.y, -dx, -dy)
+	if size == 0 {
+		return a.moduleSize
+	}
+	return size / 7
+}
+
+// edgeDistance walks from the center of a finder pattern along (dx, dy) past
+// the dark center, light ring and dark ring, and returns the distance to the
+// outer edge, or 0 if the pattern does not look like a finder pattern that way.
+func edgeDistance(m *bitMatrix, x, y, dx, dy float64) float64 {
+	limit := 8 * math.Max(float64(m.width), float64(m.height))
+	transitions := 0
+	dark := true
+	for t := 0.0; t < limit; t++ {
+		px, py := int(math.Floor(x+t*dx)), int(math.Floor(y+t*dy))
+		if px < 0 || py < 0 || px >= m.width || py >= m.height {
+			return 0
+		}
+		if m.get(px, py) != dark {
+			dark = !dark
+			if transitions++; transitions == 3 {
+				return t - 0.5 // The edge lies between this sample and the previous one
+			}
+		}
+	}
+	return 0
+}
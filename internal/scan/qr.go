@@ -0,0 +1,247 @@
+package scan
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"slices"
+	"sort"
+)
+
+// ErrNotFound is returned when an image contains no recognizable QR code.
+var ErrNotFound = errors.New("no QR code found")
+
+// QRCode is a QR code read from an image.
+type QRCode struct {
+	Text    string
+	Version int
+	Level   Level
+	Mask    int
+	// ModuleSize is the average module size in pixels.
+	ModuleSize float64
+	// Corners are the symbol corners in image coordinates, excluding the
+	// quiet zone: top-left, top-right, bottom-right and bottom-left.
+	Corners [4]image.Point
+	// Corrected is the number of codewords repaired by error correction.
+	Corrected int
+
+	transform homography // Module coordinates to image coordinates
+}
+
+// Capacity returns the number of codewords error correction can repair in the
+// symbol, summed over its blocks.
+func (c QRCode) Capacity() int {
+	i := slices.Index(levels, c.Level)
+	if c.Version < 1 || c.Version > 40 || i < 0 {
+		return 0
+	}
+	layout := ecBlocks[c.Version][i]
+	return layout.blocks * (layout.ecPerBlock / 2)
+}
+
+// maxTriples bounds the finder pattern combinations tried per image.
+const maxTriples = 64
+
+// DecodeQR reads a QR code from img. If img contains several, the most
+// plausible one is returned.
+func DecodeQR(img image.Image) (QRCode, error) {
+	codes, err := DecodeQRCodes(img)
+	if err != nil {
+		return QRCode{}, err
+	}
+	return codes[0], nil
+}
+
+// DecodeQRCodes reads all QR codes in img, in order of detection confidence.
+// A global threshold is tried first, which is exact for rendered images;
+// local thresholds are used as a fallback for uneven lighting.
+func DecodeQRCodes(img image.Image) ([]QRCode, error) {
+	g := toGray(img)
+	codes, err := decodeMatrix(g.globalThreshold())
+	if err == nil {
+		return codes, nil
+	}
+	if codes, localErr := decodeMatrix(g.localThreshold()); localErr == nil {
+		return codes, nil
+	}
+	return nil, err
+}
+
+// decodeMatrix finds and decodes the QR codes in a binarized image. Each
+// finder pattern is used by at most one code.
+func decodeMatrix(m *bitMatrix) ([]QRCode, error) {
+	triples := finderTriples(findFinderPatterns(m))
+	if len(triples) == 0 {
+		return nil, ErrNotFound
+	}
+
+	var codes []QRCode
+	var firstErr error
+	used := map[finderPattern]bool{}
+	for i, t := range triples {
+		if i == maxTriples {
+			break
+		}
+		if used[t.bottomLeft] || used[t.topLeft] || used[t.topRight] {
+			continue
+		}
+		code, err := decodeTriple(m, t)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("QR code found but not decodable: %w", err)
+			}
+			continue
+		}
+		used[t.bottomLeft], used[t.topLeft], used[t.topRight] = true, true, true
+		codes = append(codes, code)
+	}
+	if len(codes) == 0 {
+		return nil, firstErr
+	}
+	return codes, nil
+}
+
+// decodeTriple decodes the symbol located by three finder patterns, trying the
+// dimensions nearest to the one estimated from their distance.
+func decodeTriple(m *bitMatrix, t finderTriple) (QRCode, error) {
+	bl, tl, tr := t.bottomLeft, t.topLeft, t.topRight
+	top := distance(tl.x, tl.y, tr.x, tr.y) / ((moduleSizeAlong(m, tl, tr) + moduleSizeAlong(m, tr, tl)) / 2)
+	left := distance(tl.x, tl.y, bl.x, bl.y) / ((moduleSizeAlong(m, tl, bl) + moduleSizeAlong(m, bl, tl)) / 2)
+	estimate := (top+left)/2 + 7
+
+	var dims []int
+	for version := 1; version <= 40; version++ {
+		dims = append(dims, dimensionForVersion(version))
+	}
+	sort.Slice(dims, func(i, j int) bool {
+		return math.Abs(float64(dims[i])-estimate) < math.Abs(float64(dims[j])-estimate)
+	})
+
+	var firstErr error
+	for _, dim := range dims[:4] {
+		code, err := decodeDimension(m, t, dim)
+		if err == nil {
+			return code, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return QRCode{}, firstErr
+}
+
+// decodeDimension samples and decodes the symbol as a dim×dim grid. The
+// bottom-right alignment pattern, if found, corrects for perspective.
+func decodeDimension(m *bitMatrix, t finderTriple, dim int) (QRCode, error) {
+	bl, tl, tr := t.bottomLeft, t.topLeft, t.topRight
+	moduleSize := (bl.moduleSize + tl.moduleSize + tr.moduleSize) / 3
+	d := float64(dim)
+
+	src := [4]point{{3.5, 3.5}, {d - 3.5, 3.5}, {d - 3.5, d - 3.5}, {3.5, d - 3.5}}
+	dst := [4]point{{tl.x, tl.y}, {tr.x, tr.y}, {tr.x - tl.x + bl.x, tr.y - tl.y + bl.y}, {bl.x, bl.y}}
+	var transforms []homography
+	if dim > 21 {
+		f := 1 - 3/(d-7)
+		estX, estY := tl.x+f*(dst[2].x-tl.x), tl.y+f*(dst[2].y-tl.y)
+		for _, allowance := range []float64{4, 8, 16} {
+			if x, y, ok := findAlignment(m, estX, estY, moduleSize, allowance); ok {
+				alignSrc, alignDst := src, dst
+				alignSrc[2], alignDst[2] = point{d - 6.5, d - 6.5}, point{x, y}
+				if h, err := newHomography(alignSrc, alignDst); err == nil {
+					transforms = append(transforms, h)
+				}
+				break
+			}
+		}
+	}
+	h, err := newHomography(src, dst)
+	if err != nil {
+		return QRCode{}, err
+	}
+	transforms = append(transforms, h)
+
+	// Without an alignment pattern the bottom-right corner is extrapolated as
+	// if the symbol were a parallelogram, which is off in oblique photos; try
+	// positions around it, nearest first. The format information next to the
+	// other three finder patterns must read, or the triple is not a symbol.
+	if len(transforms) == 1 && formatReadable(h.sample(m, dim)) {
+		ux, uy := (tr.x-tl.x)/(d-7), (tr.y-tl.y)/(d-7)
+		vx, vy := (bl.x-tl.x)/(d-7), (bl.y-tl.y)/(d-7)
+		for _, off := range cornerOffsets {
+			moved := dst
+			moved[2].x += off.x*ux + off.y*vx
+			moved[2].y += off.x*uy + off.y*vy
+			if h, err := newHomography(src, moved); err == nil {
+				transforms = append(transforms, h)
+			}
+		}
+	}
+
+	var firstErr error
+	for _, h := range transforms {
+		code, err := decodeSymbol(h.sample(m, dim))
+		if err == nil {
+			code.ModuleSize = moduleSize
+			code.transform = h
+			for i, c := range [4]point{{0, 0}, {d, 0}, {d, d}, {0, d}} {
+				p := h.apply(c.x, c.y)
+				code.Corners[i] = image.Pt(int(math.Round(p.x)), int(math.Round(p.y)))
+			}
+			return code, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return QRCode{}, firstErr
+}
+
+// formatReadable reports whether the format information of sym can be read.
+func formatReadable(sym symbol) bool {
+	_, _, err := sym.readFormat()
+	return err == nil
+}
+
+// cornerOffsets are the displacements of the bottom-right finder center, in
+// modules, tried when a symbol has no usable alignment pattern. They cover
+// ±2 modules in half-module steps, nearest first.
+var cornerOffsets = func() []point {
+	var offsets []point
+	for x := -2.0; x <= 2; x += 0.5 {
+		for y := -2.0; y <= 2; y += 0.5 {
+			if x != 0 || y != 0 {
+				offsets = append(offsets, point{x, y})
+			}
+		}
+	}
+	sort.SliceStable(offsets, func(i, j int) bool {
+		return math.Hypot(offsets[i].x, offsets[i].y) < math.Hypot(offsets[j].x, offsets[j].y)
+	})
+	return offsets
+}()
+
+// decodeSymbol decodes a sampled module grid.
+func decodeSymbol(sym symbol) (QRCode, error) {
+	dim := sym.dimension()
+	version := (dim - 17) / 4
+	if version >= 7 {
+		if v := sym.readVersion(); v != 0 && v != version {
+			return QRCode{}, fmt.Errorf("version information says %d, symbol size says %d", v, version)
+		}
+	}
+
+	level, mask, err := sym.readFormat()
+	if err != nil {
+		return QRCode{}, err
+	}
+	data, corrected, err := correctBlocks(sym.readCodewords(version, mask), version, level)
+	if err != nil {
+		return QRCode{}, err
+	}
+	text, err := decodeSegments(data, version)
+	if err != nil {
+		return QRCode{}, err
+	}
+	return QRCode{Text: text, Version: version, Level: level, Mask: mask, Corrected: corrected}, nil
+}
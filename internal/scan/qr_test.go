@@ -0,0 +1,120 @@
+package scan
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// qrImage renders content as a QR code at the given version (0 for the
+// smallest) and level, scale pixels per module, with a 4-module quiet zone.
+func qrImage(t *testing.T, content string, version int, level qrcode.RecoveryLevel, scale int) *image.Gray {
+	t.Helper()
+	var qr *qrcode.QRCode
+	var err error
+	if version > 0 {
+		qr, err = qrcode.NewWithForcedVersion(content, version, level)
+	} else {
+		qr, err = qrcode.New(content, level)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	qr.DisableBorder = true
+	modules := qr.Bitmap()
+	size := (len(modules) + 8) * scale
+	img := image.NewGray(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			my, mx := y/scale-4, x/scale-4
+			dark := my >= 0 && mx >= 0 && my < len(modules) && mx < len(modules) && modules[my][mx]
+			img.SetGray(x, y, color.Gray{Y: map[bool]uint8{true: 0, false: 255}[dark]})
+		}
+	}
+	return img
+}
+
+// rotate90 returns img rotated a quarter turn clockwise.
+func rotate90(img *image.Gray) *image.Gray {
+	b := img.Bounds()
+	out := image.NewGray(image.Rect(0, 0, b.Dy(), b.Dx()))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			out.SetGray(b.Dy()-1-y, x, img.GrayAt(x, y))
+		}
+	}
+	return out
+}
+
+func TestDecodeQR(t *testing.T) {
+	const uri = "X-HM://0023ISYWYABCD"
+	tests := []struct {
+		version int
+		level   qrcode.RecoveryLevel
+		want    Level
+	}{
+		{0, qrcode.Low, LevelL},
+		{0, qrcode.Medium, LevelM},
+		{0, qrcode.High, LevelQ},
+		{0, qrcode.Highest, LevelH},
+		{7, qrcode.Medium, LevelM}, // First version with version information
+		{10, qrcode.Highest, LevelH},
+	}
+	for _, tt := range tests {
+		img := qrImage(t, uri, tt.version, tt.level, 4)
+		for turn := 0; turn < 4; turn++ {
+			code, err := DecodeQR(img)
+			if err != nil {
+				t.Errorf("version %d-%s, %d turns: %v", tt.version, tt.want, turn, err)
+			} else {
+				if code.Text != uri {
+					t.Errorf("version %d-%s, %d turns: text %q, want %q", tt.version, tt.want, turn, code.Text, uri)
+				}
+				if code.Level != tt.want {
+					t.Errorf("version %d-%s, %d turns: level %s", tt.version, tt.want, turn, code.Level)
+				}
+				if tt.version > 0 && code.Version != tt.version {
+					t.Errorf("version %d-%s, %d turns: version %d", tt.version, tt.want, turn, code.Version)
+				}
+				if code.Corrected != 0 {
+					t.Errorf("version %d-%s, %d turns: corrected %d codewords of a clean symbol", tt.version, tt.want, turn, code.Corrected)
+				}
+			}
+			img = rotate90(img)
+		}
+	}
+}
+
+func TestDecodeQRDamaged(t *testing.T) {
+	const uri = "X-HM://0023ISYWYABCD"
+	img := qrImage(t, uri, 2, qrcode.Highest, 4)
+
+	// Paint over a few modules in the data area, away from the function patterns
+	for y := 4 * 17; y < 4*19; y++ {
+		for x := 4 * 17; x < 4*21; x++ {
+			img.SetGray(x, y, color.Gray{Y: 0})
+		}
+	}
+	code, err := DecodeQR(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code.Text != uri {
+		t.Errorf("text %q, want %q", code.Text, uri)
+	}
+	if code.Corrected == 0 {
+		t.Error("damaged symbol decoded without corrections")
+	}
+}
+
+func TestDecodeQRNotFound(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 100, 100))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	if _, err := DecodeQR(img); err == nil {
+		t.Error("DecodeQR found a QR code in a blank image")
+	}
+}
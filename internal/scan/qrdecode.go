@@ -0,0 +1,423 @@
+package scan
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strings"
+	"unicode/utf8"
+)
+
+// symbol is a sampled QR code: grid[y][x] is true for dark modules.
+type symbol struct {
+	grid [][]bool
+}
+
+func (s symbol) dimension() int { return len(s.grid) }
+
+func (s symbol) get(x, y int) bool { return s.grid[y][x] }
+
+// readFormat decodes the error correction level and mask from either copy of
+// the format information, allowing up to 3 bit errors.
+func (s symbol) readFormat() (Level, int, error) {
+	dim := s.dimension()
+	var copy1, copy2 int
+	bit := func(v *int, x, y int) {
+		*v <<= 1
+		if s.get(x, y) {
+			*v |= 1
+		}
+	}
+
+	for x := 0; x < 6; x++ {
+		bit(&copy1, x, 8)
+	}
+	bit(&copy1, 7, 8)
+	bit(&copy1, 8, 8)
+	bit(&copy1, 8, 7)
+	for y := 5; y >= 0; y-- {
+		bit(&copy1, 8, y)
+	}
+
+	for y := dim - 1; y >= dim-7; y-- {
+		bit(&copy2, 8, y)
+	}
+	for x := dim - 8; x < dim; x++ {
+		bit(&copy2, x, 8)
+	}
+
+	best, bestDist := -1, 4
+	for data := 0; data < 32; data++ {
+		info := formatInfo(data)
+		for _, c := range []int{copy1, copy2} {
+			if d := bits.OnesCount(uint(info ^ c)); d < bestDist {
+				best, bestDist = data, d
+			}
+		}
+	}
+	if best < 0 {
+		return "", 0, errors.New("unreadable format information")
+	}
+	return formatLevels[best>>3], best & 7, nil
+}
+
+// readVersion decodes the version information of versions 7 and up from
+// either copy, allowing up to 3 bit errors. It returns 0 if both are unreadable.
+func (s symbol) readVersion() int {
+	dim := s.dimension()
+	var copy1, copy2 int
+	for y := 5; y >= 0; y-- {
+		for x := dim - 9; x >= dim-11; x-- {
+			copy1 <<= 1
+			if s.get(x, y) {
+				copy1 |= 1
+			}
+		}
+	}
+	for x := 5; x >= 0; x-- {
+		for y := dim - 9; y >= dim-11; y-- {
+			copy2 <<= 1
+			if s.get(x, y) {
+				copy2 |= 1
+			}
+		}
+	}
+
+	best, bestDist := 0, 4
+	for version := 7; version <= 40; version++ {
+		info := versionInfo(version)
+		for _, c := range []int{copy1, copy2} {
+			if d := bits.OnesCount(uint(info ^ c)); d < bestDist {
+				best, bestDist = version, d
+			}
+		}
+	}
+	return best
+}
+
+// functionPatterns marks the modules of a version that do not carry data:
+// finder patterns with separators and format information, timing patterns,
+// alignment patterns and version information.
+func functionPatterns(version int) [][]bool {
+	dim := dimensionForVersion(version)
+	f := make([][]bool, dim)
+	for y := range f {
+		f[y] = make([]bool, dim)
+	}
+	region := func(x0, y0, w, h int) {
+		for y := y0; y < y0+h; y++ {
+			for x := x0; x < x0+w; x++ {
+				f[y][x] = true
+			}
+		}
+	}
+
+	region(0, 0, 9, 9)
+	region(dim-8, 0, 8, 9)
+	region(0, dim-8, 9, 8)
+
+	centers := alignmentCenters[version]
+	last := len(centers) - 1
+	for i, cy := range centers {
+		for j, cx := range centers {
+			if (i == 0 && (j == 0 || j == last)) || (i == last && j == 0) {
+				continue // Overlaps a finder pattern
+			}
+			region(cx-2, cy-2, 5, 5)
+		}
+	}
+
+	region(6, 9, 1, dim-17)
+	region(9, 6, dim-17, 1)
+
+	if version >= 7 {
+		region(dim-11, 0, 3, 6)
+		region(0, dim-11, 6, 3)
+	}
+	return f
+}
+
+// masked reports whether the data mask inverts the module in row y, column x.
+func masked(mask, y, x int) bool {
+	switch mask {
+	case 0:
+		return (y+x)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (y+x)%3 == 0
+	case 4:
+		return (y/2+x/3)%2 == 0
+	case 5:
+		return (y*x)%2+(y*x)%3 == 0
+	case 6:
+		return ((y*x)%2+(y*x)%3)%2 == 0
+	default:
+		return ((y+x)%2+(y*x)%3)%2 == 0
+	}
+}
+
+// readCodewords reads the unmasked codewords in the standard two-column
+// zigzag order, starting at the bottom right corner.
+func (s symbol) readCodewords(version, mask int) []byte {
+	dim := s.dimension()
+	function := functionPatterns(version)
+	codewords := make([]byte, 0, totalCodewords(version))
+
+	var current byte
+	bitsRead := 0
+	up := true
+	for x := dim - 1; x > 0; x -= 2 {
+		if x == 6 {
+			x-- // Skip the vertical timing pattern
+		}
+		for i := 0; i < dim; i++ {
+			y := i
+			if up {
+				y = dim - 1 - i
+			}
+			for col := 0; col < 2; col++ {
+				if function[y][x-col] {
+					continue
+				}
+				current <<= 1
+				if s.get(x-col, y) != masked(mask, y, x-col) {
+					current |= 1
+				}
+				if bitsRead++; bitsRead == 8 {
+					codewords = append(codewords, current)
+					current, bitsRead = 0, 0
+				}
+			}
+		}
+		up = !up
+	}
+	return codewords
+}
+
+// correctBlocks de-interleaves the codewords into their error correction
+// blocks, corrects each block and returns the data codewords in order.
+func correctBlocks(codewords []byte, version int, level Level) ([]byte, int, error) {
+	var layout ecBlock
+	for i, l := range levels {
+		if l == level {
+			layout = ecBlocks[version][i]
+		}
+	}
+
+	total := totalCodewords(version)
+	if len(codewords) < total {
+		return nil, 0, fmt.Errorf("expected %d codewords, read %d", total, len(codewords))
+	}
+	shortLen := total / layout.blocks
+	longBlocks := total % layout.blocks
+	shortBlocks := layout.blocks - longBlocks
+	shortData := shortLen - layout.ecPerBlock
+
+	blocks := make([][]byte, layout.blocks)
+	for i := range blocks {
+		size := shortLen
+		if i >= shortBlocks {
+			size++
+		}
+		blocks[i] = make([]byte, size)
+	}
+
+	// Data codewords are interleaved first, long blocks having one more;
+	// the error correction codewords follow.
+	offset := 0
+	for i := 0; i < shortData; i++ {
+		for _, b := range blocks {
+			b[i] = codewords[offset]
+			offset++
+		}
+	}
+	for _, b := range blocks[shortBlocks:] {
+		b[shortData] = codewords[offset]
+		offset++
+	}
+	for i := shortData; i < shortLen; i++ {
+		for j, b := range blocks {
+			k := i
+			if j >= shortBlocks {
+				k++
+			}
+			b[k] = codewords[offset]
+			offset++
+		}
+	}
+
+	var data []byte
+	corrected := 0
+	for _, b := range blocks {
+		n, err := rsCorrect(b, layout.ecPerBlock)
+		if err != nil {
+			return nil, 0, err
+		}
+		corrected += n
+		data = append(data, b[:len(b)-layout.ecPerBlock]...)
+	}
+	return data, corrected, nil
+}
+
+// bitReader reads big-endian bit fields from a byte slice.
+type bitReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *bitReader) available() int { return len(r.data)*8 - r.pos }
+
+func (r *bitReader) read(n int) (int, error) {
+	if n > r.available() {
+		return 0, errors.New("data ends unexpectedly")
+	}
+	v := 0
+	for i := 0; i < n; i++ {
+		b := r.data[r.pos/8] >> (7 - r.pos%8) & 1
+		v = v<<1 | int(b)
+		r.pos++
+	}
+	return v, nil
+}
+
+// alphanumeric is the character set of alphanumeric mode.
+const alphanumeric = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+// QR code segment modes
+const (
+	modeTerminator = 0x0
+	modeNumeric    = 0x1
+	modeAlpha      = 0x2
+	modeStructured = 0x3
+	modeByte       = 0x4
+	modeFNC1First  = 0x5
+	modeECI        = 0x7
+	modeKanji      = 0x8
+	modeFNC1Second = 0x9
+)
+
+// countBits returns the width of the character count field of a mode.
+func countBits(mode, version int) int {
+	i := 0
+	if version >= 27 {
+		i = 2
+	} else if version >= 10 {
+		i = 1
+	}
+	switch mode {
+	case modeNumeric:
+		return [3]int{10, 12, 14}[i]
+	case modeAlpha:
+		return [3]int{9, 11, 13}[i]
+	case modeByte:
+		return [3]int{8, 16, 16}[i]
+	default:
+		return [3]int{8, 10, 12}[i]
+	}
+}
+
+// decodeSegments decodes the data codewords into text. Byte mode data that is
+// not valid UTF-8 is read as ISO-8859-1, the QR code default.
+func decodeSegments(data []byte, version int) (string, error) {
+	r := &bitReader{data: data}
+	var sb strings.Builder
+
+	for r.available() >= 4 {
+		mode, _ := r.read(4)
+		switch mode {
+		case modeTerminator:
+			return sb.String(), nil
+		case modeFNC1First, modeFNC1Second:
+			if mode == modeFNC1Second {
+				if _, err := r.read(8); err != nil {
+					return "", err
+				}
+			}
+			continue
+		case modeStructured:
+			if _, err := r.read(16); err != nil {
+				return "", err
+			}
+			continue
+		case modeECI:
+			// The designator is 1, 2 or 3 bytes; text is returned as decoded
+			first, err := r.read(8)
+			if err != nil {
+				return "", err
+			}
+			if first&0x80 != 0 {
+				extra := 8
+				if first&0xC0 == 0xC0 {
+					extra = 16
+				}
+				if _, err := r.read(extra); err != nil {
+					return "", err
+				}
+			}
+			continue
+		}
+
+		count, err := r.read(countBits(mode, version))
+		if err != nil {
+			return "", err
+		}
+		switch mode {
+		case modeNumeric:
+			for count > 0 {
+				digits := min(count, 3)
+				v, err := r.read([4]int{0, 4, 7, 10}[digits])
+				if err != nil {
+					return "", err
+				}
+				s := fmt.Sprintf("%0*d", digits, v)
+				if len(s) != digits {
+					return "", errors.New("invalid numeric data")
+				}
+				sb.WriteString(s)
+				count -= digits
+			}
+		case modeAlpha:
+			for count > 0 {
+				if count == 1 {
+					v, err := r.read(6)
+					if err != nil || v >= 45 {
+						return "", errors.New("invalid alphanumeric data")
+					}
+					sb.WriteByte(alphanumeric[v])
+					break
+				}
+				v, err := r.read(11)
+				if err != nil || v >= 45*45 {
+					return "", errors.New("invalid alphanumeric data")
+				}
+				sb.WriteByte(alphanumeric[v/45])
+				sb.WriteByte(alphanumeric[v%45])
+				count -= 2
+			}
+		case modeByte:
+			buf := make([]byte, count)
+			for i := range buf {
+				v, err := r.read(8)
+				if err != nil {
+					return "", err
+				}
+				buf[i] = byte(v)
+			}
+			if utf8.Valid(buf) {
+				sb.Write(buf)
+			} else {
+				for _, b := range buf {
+					sb.WriteRune(rune(b))
+				}
+			}
+		case modeKanji:
+			return "", errors.New("kanji mode is not supported")
+		default:
+			return "", fmt.Errorf("invalid segment mode %d", mode)
+		}
+	}
+	return sb.String(), nil
+}
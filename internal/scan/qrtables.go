@@ -0,0 +1,149 @@
+package scan
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Level is a QR code error correction level.
+type Level string
+
+// QR code error correction levels, recovering about 7%, 15%, 25% and 30% of
+// the codewords respectively.
+const (
+	LevelL Level = "L"
+	LevelM Level = "M"
+	LevelQ Level = "Q"
+	LevelH Level = "H"
+)
+
+// levels lists the levels in ecBlocks column order.
+var levels = []Level{LevelL, LevelM, LevelQ, LevelH}
+
+// ParseLevel converts a level name (L, M, Q or H, case-insensitive) into a Level.
+func ParseLevel(name string) (Level, error) {
+	l := Level(strings.ToUpper(strings.TrimSpace(name)))
+	if !slices.Contains(levels, l) {
+		return "", fmt.Errorf("unknown error correction level %q. Expected L, M, Q or H", name)
+	}
+	return l, nil
+}
+
+// Next returns the next stronger error correction level, or l itself for
+// LevelH.
+func (l Level) Next() Level {
+	if i := slices.Index(levels, l); i >= 0 && i < len(levels)-1 {
+		return levels[i+1]
+	}
+	return l
+}
+
+// formatLevels maps the two error correction bits of the format information
+// to a level.
+var formatLevels = [4]Level{LevelM, LevelL, LevelH, LevelQ}
+
+// ecBlock is the error correction layout of one version and level: the number
+// of error correction codewords per block and the number of blocks.
+type ecBlock struct {
+	ecPerBlock, blocks int
+}
+
+// ecBlocks holds the error correction layout for versions 1-40, in levels order.
+var ecBlocks = [41][4]ecBlock{
+	{},
+	{{7, 1}, {10, 1}, {13, 1}, {17, 1}},
+	{{10, 1}, {16, 1}, {22, 1}, {28, 1}},
+	{{15, 1}, {26, 1}, {18, 2}, {22, 2}},
+	{{20, 1}, {18, 2}, {26, 2}, {16, 4}},
+	{{26, 1}, {24, 2}, {18, 4}, {22, 4}},
+	{{18, 2}, {16, 4}, {24, 4}, {28, 4}},
+	{{20, 2}, {18, 4}, {18, 6}, {26, 5}},
+	{{24, 2}, {22, 4}, {22, 6}, {26, 6}},
+	{{30, 2}, {22, 5}, {20, 8}, {24, 8}},
+	{{18, 4}, {26, 5}, {24, 8}, {28, 8}},
+	{{20, 4}, {30, 5}, {28, 8}, {24, 11}},
+	{{24, 4}, {22, 8}, {26, 10}, {28, 11}},
+	{{26, 4}, {22, 9}, {24, 12}, {22, 16}},
+	{{30, 4}, {24, 9}, {20, 16}, {24, 16}},
+	{{22, 6}, {24, 10}, {30, 12}, {24, 18}},
+	{{24, 6}, {28, 10}, {24, 17}, {30, 16}},
+	{{28, 6}, {28, 11}, {28, 16}, {28, 19}},
+	{{30, 6}, {26, 13}, {28, 18}, {28, 21}},
+	{{28, 7}, {26, 14}, {26, 21}, {26, 25}},
+	{{28, 8}, {26, 16}, {30, 20}, {28, 25}},
+	{{28, 8}, {26, 17}, {28, 23}, {30, 25}},
+	{{28, 9}, {28, 17}, {30, 23}, {24, 34}},
+	{{30, 9}, {28, 18}, {30, 25}, {30, 30}},
+	{{30, 10}, {28, 20}, {30, 27}, {30, 32}},
+	{{26, 12}, {28, 21}, {30, 29}, {30, 35}},
+	{{28, 12}, {28, 23}, {28, 34}, {30, 37}},
+	{{30, 12}, {28, 25}, {30, 34}, {30, 40}},
+	{{30, 13}, {28, 26}, {30, 35}, {30, 42}},
+	{{30, 14}, {28, 28}, {30, 38}, {30, 45}},
+	{{30, 15}, {28, 29}, {30, 40}, {30, 48}},
+	{{30, 16}, {28, 31}, {30, 43}, {30, 51}},
+	{{30, 17}, {28, 33}, {30, 45}, {30, 54}},
+	{{30, 18}, {28, 35}, {30, 48}, {30, 57}},
+	{{30, 19}, {28, 37}, {30, 51}, {30, 60}},
+	{{30, 19}, {28, 38}, {30, 53}, {30, 63}},
+	{{30, 20}, {28, 40}, {30, 56}, {30, 66}},
+	{{30, 21}, {28, 43}, {30, 59}, {30, 70}},
+	{{30, 22}, {28, 45}, {30, 62}, {30, 74}},
+	{{30, 24}, {28, 47}, {30, 65}, {30, 77}},
+	{{30, 25}, {28, 49}, {30, 68}, {30, 81}},
+}
+
+// alignmentCenters holds the alignment pattern center coordinates for
+// versions 1-40.
+var alignmentCenters = [41][]int{
+	{}, {},
+	{6, 18}, {6, 22}, {6, 26}, {6, 30}, {6, 34},
+	{6, 22, 38}, {6, 24, 42}, {6, 26, 46}, {6, 28, 50}, {6, 30, 54}, {6, 32, 58}, {6, 34, 62},
+	{6, 26, 46, 66}, {6, 26, 48, 70}, {6, 26, 50, 74}, {6, 30, 54, 78}, {6, 30, 56, 82}, {6, 30, 58, 86}, {6, 34, 62, 90},
+	{6, 28, 50, 72, 94}, {6, 26, 50, 74, 98}, {6, 30, 54, 78, 102}, {6, 28, 54, 80, 106}, {6, 32, 58, 84, 110}, {6, 30, 58, 86, 114}, {6, 34, 62, 90, 118},
+	{6, 26, 50, 74, 98, 122}, {6, 30, 54, 78, 102, 126}, {6, 26, 52, 78, 104, 130}, {6, 30, 56, 82, 108, 134}, {6, 34, 60, 86, 112, 138}, {6, 30, 58, 86, 114, 142}, {6, 34, 62, 90, 118, 146},
+	{6, 30, 54, 78, 102, 126, 150}, {6, 24, 50, 76, 102, 128, 154}, {6, 28, 54, 80, 106, 132, 158}, {6, 32, 58, 84, 110, 136, 162}, {6, 26, 54, 82, 110, 138, 166}, {6, 30, 58, 86, 114, 142, 170},
+}
+
+// dimensionForVersion returns the side length in modules of a version.
+func dimensionForVersion(version int) int {
+	return 17 + 4*version
+}
+
+// totalCodewords returns the number of data plus error correction codewords
+// of a version.
+func totalCodewords(version int) int {
+	modules := (16*version+128)*version + 64
+	if version >= 2 {
+		n := version/7 + 2
+		modules -= (25*n-10)*n - 55
+		if version >= 7 {
+			modules -= 36
+		}
+	}
+	return modules / 8
+}
+
+// formatInfo returns the masked 15-bit format information for the 5 data bits
+// (2 error correction level bits and 3 mask bits).
+func formatInfo(data int) int {
+	v := data << 10
+	for i := 14; i >= 10; i-- {
+		if v&(1<<i) != 0 {
+			v ^= 0x537 << (i - 10)
+		}
+	}
+	return (data<<10 | v) ^ 0x5412
+}
+
+// versionInfo returns the 18-bit version information of versions 7-40.
+func versionInfo(version int) int {
+	v := version << 12
+	for i := 17; i >= 12; i-- {
+		if v&(1<<i) != 0 {
+			v ^= 0x1F25 << (i - 12)
+		}
+	}
+	return version<<12 | v
+}
@@ -0,0 +1,151 @@
+package scan
+
+import "errors"
+
+// errTooManyErrors is returned when a block has more errors than its error
+// correction codewords can repair.
+var errTooManyErrors = errors.New("too many errors to correct")
+
+// GF(256) exponent and logarithm tables for the QR code polynomial
+// x^8 + x^4 + x^3 + x^2 + 1.
+var gfExp, gfLog = func() ([512]byte, [256]int) {
+	var exp [512]byte
+	var log [256]int
+	x := 1
+	for i := 0; i < 255; i++ {
+		exp[i] = byte(x)
+		log[x] = i
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11D
+		}
+	}
+	for i := 255; i < 512; i++ {
+		exp[i] = exp[i-255]
+	}
+	return exp, log
+}()
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[gfLog[a]+gfLog[b]]
+}
+
+func gfDiv(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return gfExp[gfLog[a]+255-gfLog[b]]
+}
+
+// gfPow returns α^n.
+func gfPow(n int) byte {
+	n %= 255
+	if n < 0 {
+		n += 255
+	}
+	return gfExp[n]
+}
+
+// gfEval evaluates a polynomial given lowest degree first at x.
+func gfEval(poly []byte, x byte) byte {
+	var y byte
+	for i := len(poly) - 1; i >= 0; i-- {
+		y = gfMul(y, x) ^ poly[i]
+	}
+	return y
+}
+
+// rsCorrect corrects a Reed-Solomon block in place. The block holds the data
+// codewords followed by numEC error correction codewords, highest degree first.
+// It returns the number of corrected codewords.
+func rsCorrect(block []byte, numEC int) (int, error) {
+	n := len(block)
+
+	// Syndromes S_j = r(α^j)
+	syndromes := make([]byte, numEC)
+	clean := true
+	for j := range syndromes {
+		var s byte
+		x := gfPow(j)
+		for _, c := range block {
+			s = gfMul(s, x) ^ c
+		}
+		syndromes[j] = s
+		clean = clean && s == 0
+	}
+	if clean {
+		return 0, nil
+	}
+
+	// Berlekamp-Massey: find the error locator polynomial (lowest degree first)
+	locator := []byte{1}
+	prev := []byte{1}
+	errCount, shift := 0, 1
+	prevDiscrepancy := byte(1)
+	for r := 0; r < numEC; r++ {
+		d := syndromes[r]
+		for i := 1; i <= errCount && i < len(locator); i++ {
+			d ^= gfMul(locator[i], syndromes[r-i])
+		}
+		if d == 0 {
+			shift++
+			continue
+		}
+
+		next := append([]byte(nil), locator...)
+		if need := len(prev) + shift; len(next) < need {
+			next = append(next, make([]byte, need-len(next))...)
+		}
+		coef := gfDiv(d, prevDiscrepancy)
+		for i, c := range prev {
+			next[i+shift] ^= gfMul(coef, c)
+		}
+
+		if 2*errCount <= r {
+			errCount = r + 1 - errCount
+			prev, prevDiscrepancy, shift = locator, d, 1
+		} else {
+			shift++
+		}
+		locator = next
+	}
+	if 2*errCount > numEC {
+		return 0, errTooManyErrors
+	}
+	locator = locator[:errCount+1]
+
+	// Chien search: an error at degree d makes α^-d a root of the locator
+	var positions []int
+	for d := 0; d < n; d++ {
+		if gfEval(locator, gfPow(-d)) == 0 {
+			positions = append(positions, d)
+		}
+	}
+	if len(positions) != errCount {
+		return 0, errTooManyErrors
+	}
+
+	// Forney: error evaluator Ω(x) = S(x)Λ(x) mod x^numEC
+	evaluator := make([]byte, numEC)
+	for i := range evaluator {
+		for j := 0; j <= i && j < len(locator); j++ {
+			evaluator[i] ^= gfMul(locator[j], syndromes[i-j])
+		}
+	}
+	for _, d := range positions {
+		xInv := gfPow(-d)
+		var derivative byte
+		for i := 1; i < len(locator); i += 2 {
+			derivative ^= gfMul(locator[i], gfPow(-d*(i-1)))
+		}
+		if derivative == 0 {
+			return 0, errTooManyErrors
+		}
+		magnitude := gfMul(gfPow(d), gfDiv(gfEval(evaluator, xInv), derivative))
+		block[n-1-d] ^= magnitude
+	}
+	return errCount, nil
+}
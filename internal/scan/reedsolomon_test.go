@@ -0,0 +1,105 @@
+package scan
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+// rsEncode returns the numEC error correction codewords of data, computed as
+// the remainder of data·x^numEC divided by the QR code generator polynomial.
+func rsEncode(data []byte, numEC int) []byte {
+	// Generator polynomial ∏(x - α^i), highest degree first
+	gen := []byte{1}
+	for i := 0; i < numEC; i++ {
+		next := make([]byte, len(gen)+1)
+		for j, c := range gen {
+			next[j] ^= c
+			next[j+1] ^= gfMul(c, gfPow(i))
+		}
+		gen = next
+	}
+	rem := make([]byte, len(data)+numEC)
+	copy(rem, data)
+	for i := range data {
+		if c := rem[i]; c != 0 {
+			for j, g := range gen {
+				rem[i+j] ^= gfMul(g, c)
+			}
+		}
+	}
+	return rem[len(data):]
+}
+
+// ISO/IEC 18004 Annex I: "01234567" as version 1-M
+var (
+	annexData = []byte{0x10, 0x20, 0x0C, 0x56, 0x61, 0x80, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11}
+	annexEC   = []byte{0xA5, 0x24, 0xD4, 0xC1, 0xED, 0x36, 0xC7, 0x87, 0x2C, 0x55}
+)
+
+func TestRSEncodeVector(t *testing.T) {
+	if got := rsEncode(annexData, len(annexEC)); !bytes.Equal(got, annexEC) {
+		t.Errorf("rsEncode = %X, want %X", got, annexEC)
+	}
+}
+
+func TestRSCorrect(t *testing.T) {
+	block := append(append([]byte(nil), annexData...), annexEC...)
+	numEC := len(annexEC)
+	rng := rand.New(rand.NewSource(1))
+
+	for errs := 0; errs <= numEC/2; errs++ {
+		for trial := 0; trial < 20; trial++ {
+			received := append([]byte(nil), block...)
+			for _, i := range rng.Perm(len(block))[:errs] {
+				received[i] ^= byte(1 + rng.Intn(255))
+			}
+			corrected, err := rsCorrect(received, numEC)
+			if err != nil {
+				t.Fatalf("%d errors: %v", errs, err)
+			}
+			if corrected != errs {
+				t.Errorf("%d errors: corrected %d codewords", errs, corrected)
+			}
+			if !bytes.Equal(received, block) {
+				t.Fatalf("%d errors: block not restored: %X", errs, received)
+			}
+		}
+	}
+}
+
+func TestRSCorrectTooManyErrors(t *testing.T) {
+	block := append(append([]byte(nil), annexData...), annexEC...)
+	numEC := len(annexEC)
+	rng := rand.New(rand.NewSource(2))
+
+	detected := 0
+	for trial := 0; trial < 100; trial++ {
+		received := append([]byte(nil), block...)
+		for _, i := range rng.Perm(len(block))[:numEC/2+1] {
+			received[i] ^= byte(1 + rng.Intn(255))
+		}
+		if _, err := rsCorrect(received, numEC); errors.Is(err, errTooManyErrors) {
+			detected++
+		} else if err == nil && bytes.Equal(received, block) {
+			t.Fatal("corrected more errors than the code can repair")
+		}
+	}
+	// Beyond the capacity a block can decode to another codeword, but rarely
+	if detected < 90 {
+		t.Errorf("detected %d of 100 uncorrectable blocks", detected)
+	}
+}
+
+func TestRSEncodeRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	for _, numEC := range []int{7, 18, 30} {
+		data := make([]byte, 40)
+		rng.Read(data)
+		block := append(data, rsEncode(data, numEC)...)
+		if n, err := rsCorrect(append([]byte(nil), block...), numEC); n != 0 || err != nil {
+			t.Errorf("clean block with %d EC codewords: corrected %d, %v", numEC, n, err)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+// Package scan reads QR codes and Code 39 / Code 128 barcodes from images.
+//
+// It is a small pure-Go reader used to verify rendered labels before they are
+// saved and to recover pairing data from photos of printed labels. It expects
+// dark modules and bars on a light background, but tolerates rotation,
+// perspective and uneven lighting. Transparent pixels are treated as white.
+package scan
+
+import (
+	"image"
+	"math"
+)
+
+// grayImage is an 8-bit luminance copy of an image.
+type grayImage struct {
+	width, height int
+	pix           []uint8
+}
+
+// toGray converts img to luminance, compositing transparent pixels over white.
+func toGray(img image.Image) *grayImage {
+	b := img.Bounds()
+	g := &grayImage{width: b.Dx(), height: b.Dy(), pix: make([]uint8, b.Dx()*b.Dy())}
+
+	switch src := img.(type) {
+	case *image.Gray:
+		for y := 0; y < g.height; y++ {
+			i := src.PixOffset(b.Min.X, b.Min.Y+y)
+			copy(g.pix[y*g.width:(y+1)*g.width], src.Pix[i:i+g.width])
+		}
+	case *image.RGBA:
+		for y := 0; y < g.height; y++ {
+			i := src.PixOffset(b.Min.X, b.Min.Y+y)
+			row := g.pix[y*g.width : (y+1)*g.width]
+			for x := range row {
+				p := src.Pix[i : i+4 : i+4]
+				row[x] = luma(uint32(p[0]), uint32(p[1]), uint32(p[2]), uint32(p[3]))
+				i += 4
+			}
+		}
+	case *image.NRGBA:
+		for y := 0; y < g.height; y++ {
+			i := src.PixOffset(b.Min.X, b.Min.Y+y)
+			row := g.pix[y*g.width : (y+1)*g.width]
+			for x := range row {
+				p := src.Pix[i : i+4 : i+4]
+				a := uint32(p[3])
+				row[x] = luma(uint32(p[0])*a/255, uint32(p[1])*a/255, uint32(p[2])*a/255, a)
+				i += 4
+			}
+		}
+	case *image.YCbCr:
+		// JPEG photos: the Y plane is the luminance
+		for y := 0; y < g.height; y++ {
+			i := src.YOffset(b.Min.X, b.Min.Y+y)
+			copy(g.pix[y*g.width:(y+1)*g.width], src.Y[i:i+g.width])
+		}
+	default:
+		for y := 0; y < g.height; y++ {
+			row := g.pix[y*g.width : (y+1)*g.width]
+			for x := range row {
+				r, gr, bl, a := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+				row[x] = luma(r>>8, gr>>8, bl>>8, a>>8)
+			}
+		}
+	}
+	return g
+}
+
+// luma returns the luminance of an alpha-premultiplied 8-bit color over white.
+func luma(r, g, b, a uint32) uint8 {
+	return uint8((299*r+587*g+114*b)/1000 + 255 - a)
+}
+
+// at returns the luminance at (x, y). Pixels outside the image are white.
+func (g *grayImage) at(x, y int) uint8 {
+	if x < 0 || y < 0 || x >= g.width || y >= g.height {
+		return 255
+	}
+	return g.pix[y*g.width+x]
+}
+
+// bilinear returns the luminance at a fractional position, where integer
+// coordinates are pixel centers.
+func (g *grayImage) bilinear(x, y float64) uint8 {
+	x0, y0 := math.Floor(x), math.Floor(y)
+	fx, fy := x-x0, y-y0
+	ix, iy := int(x0), int(y0)
+	top := float64(g.at(ix, iy))*(1-fx) + float64(g.at(ix+1, iy))*fx
+	bottom := float64(g.at(ix, iy+1))*(1-fx) + float64(g.at(ix+1, iy+1))*fx
+	return uint8(top*(1-fy) + bottom*fy + 0.5)
+}
+
+// otsu returns the global threshold separating dark from light pixels,
+// chosen by Otsu's method. Pixels at or below it are dark.
+func (g *grayImage) otsu() uint8 {
+	var hist [256]int
+	for _, v := range g.pix {
+		hist[v]++
+	}
+
+	total := len(g.pix)
+	var sum float64
+	for i, n := range hist {
+		sum += float64(i * n)
+	}
+
+	var sumDark float64
+	var best float64
+	threshold, dark := 127, 0
+	for t := 0; t < 256; t++ {
+		dark += hist[t]
+		if dark == 0 {
+			continue
+		}
+		light := total - dark
+		if light == 0 {
+			break
+		}
+		sumDark += float64(t * hist[t])
+		meanDark := sumDark / float64(dark)
+		meanLight := (sum - sumDark) / float64(light)
+		between := float64(dark) * float64(light) * (meanDark - meanLight) * (meanDark - meanLight)
+		if between > best {
+			best, threshold = between, t
+		}
+	}
+	return uint8(threshold)
+}
+
+// bitMatrix is a binarized image. A set bit is a dark pixel.
+type bitMatrix struct {
+	width, height int
+	bits          []bool
+}
+
+// get reports whether (x, y) is dark. Pixels outside the matrix are light.
+func (m *bitMatrix) get(x, y int) bool {
+	if x < 0 || y < 0 || x >= m.width || y >= m.height {
+		return false
+	}
+	return m.bits[y*m.width+x]
+}
+
+// globalThreshold binarizes the image with a single Otsu threshold, which is
+// exact for rendered images.
+func (g *grayImage) globalThreshold() *bitMatrix {
+	t := g.otsu()
+	m := &bitMatrix{width: g.width, height: g.height, bits: make([]bool, len(g.pix))}
+	for i, v := range g.pix {
+		m.bits[i] = v <= t
+	}
+	return m
+}
+
+// Local thresholding parameters: the image is divided into blocks, and each
+// block is thresholded at the mean of the surrounding 5×5 blocks.
+const (
+	blockSize       = 8
+	minDynamicRange = 24
+)
+
+// localThreshold binarizes the image with per-block thresholds, which copes
+// with uneven lighting and shadows in photos. Blocks without contrast take
+// their threshold from their neighbours.
+func (g *grayImage) localThreshold() *bitMatrix {
+	if g.width < 5*blockSize || g.height < 5*blockSize {
+		return g.globalThreshold()
+	}
+
+	subW := (g.width + blockSize - 1) / blockSize
+	subH := (g.height + blockSize - 1) / blockSize
+	means := make([]int, subW*subH)
+
+	for by := 0; by < subH; by++ {
+		y0 := min(by*blockSize, g.height-blockSize)
+		for bx := 0; bx < subW; bx++ {
+			x0 := min(bx*blockSize, g.width-blockSize)
+			sum, lo, hi := 0, 255, 0
+			for y := y0; y < y0+blockSize; y++ {
+				for _, v := range g.pix[y*g.width+x0 : y*g.width+x0+blockSize] {
+					sum += int(v)
+					lo = min(lo, int(v))
+					hi = max(hi, int(v))
+				}
+			}
+
+			mean := sum / (blockSize * blockSize)
+			if hi-lo <= minDynamicRange {
+				// Flat block: assume it is light unless the neighbours say otherwise
+				mean = lo / 2
+				if by > 0 && bx > 0 {
+					neighbours := (means[(by-1)*subW+bx] + 2*means[by*subW+bx-1] + means[(by-1)*subW+bx-1]) / 4
+					if lo < neighbours {
+						mean = neighbours
+					}
+				}
+			}
+			means[by*subW+bx] = mean
+		}
+	}
+
+	m := &bitMatrix{width: g.width, height: g.height, bits: make([]bool, len(g.pix))}
+	for by := 0; by < subH; by++ {
+		y0 := min(by*blockSize, g.height-blockSize)
+		cy := min(max(by, 2), subH-3)
+		for bx := 0; bx < subW; bx++ {
+			x0 := min(bx*blockSize, g.width-blockSize)
+			cx := min(max(bx, 2), subW-3)
+			sum := 0
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					sum += means[(cy+dy)*subW+cx+dx]
+				}
+			}
+			t := uint8(sum / 25)
+			for y := y0; y < y0+blockSize; y++ {
+				for x := x0; x < x0+blockSize; x++ {
+					m.bits[y*g.width+x] = g.pix[y*g.width+x] <= t
+				}
+			}
+		}
+	}
+	return m
+}
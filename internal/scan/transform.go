@@ -0,0 +1,105 @@
+package scan
+
+import (
+	"errors"
+	"image"
+	"math"
+)
+
+// point is a position in image or module coordinates.
+type point struct {
+	x, y float64
+}
+
+// homography is a perspective transform from module coordinates to image
+// coordinates: x = (h0u + h1v + h2) / (h6u + h7v + 1), likewise y with h3-h5.
+type homography [8]float64
+
+// newHomography returns the perspective transform that maps each src point to
+// the corresponding dst point.
+func newHomography(src, dst [4]point) (homography, error) {
+	// Solve the 8×8 linear system by Gaussian elimination with partial pivoting
+	var a [8][9]float64
+	for i := 0; i < 4; i++ {
+		u, v, x, y := src[i].x, src[i].y, dst[i].x, dst[i].y
+		a[2*i] = [9]float64{u, v, 1, 0, 0, 0, -u * x, -v * x, x}
+		a[2*i+1] = [9]float64{0, 0, 0, u, v, 1, -u * y, -v * y, y}
+	}
+	for col := 0; col < 8; col++ {
+		pivot := col
+		for row := col + 1; row < 8; row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(a[pivot][col]) < 1e-12 {
+			return homography{}, errors.New("degenerate symbol corners")
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		for row := 0; row < 8; row++ {
+			if row == col {
+				continue
+			}
+			f := a[row][col] / a[col][col]
+			for k := col; k < 9; k++ {
+				a[row][k] -= f * a[col][k]
+			}
+		}
+	}
+
+	var h homography
+	for i := range h {
+		h[i] = a[i][8] / a[i][i]
+	}
+	return h, nil
+}
+
+// apply maps module coordinates (u, v) to image coordinates.
+func (h homography) apply(u, v float64) point {
+	d := h[6]*u + h[7]*v + 1
+	return point{(h[0]*u + h[1]*v + h[2]) / d, (h[3]*u + h[4]*v + h[5]) / d}
+}
+
+// sample reads a dim×dim module grid from m, sampling each module at its center.
+func (h homography) sample(m *bitMatrix, dim int) symbol {
+	grid := make([][]bool, dim)
+	for y := range grid {
+		grid[y] = make([]bool, dim)
+		for x := range grid[y] {
+			p := h.apply(float64(x)+0.5, float64(y)+0.5)
+			grid[y][x] = m.get(int(math.Floor(p.x)), int(math.Floor(p.y)))
+		}
+	}
+	return symbol{grid: grid}
+}
+
+// maxRectifyPixels bounds the size of images returned by Rectify.
+const maxRectifyPixels = 40 << 20
+
+// Rectify resamples img so that the QR code appears upright and square, as if
+// photographed head-on, at the module size it has in img. The result extends
+// left, top, right and bottom symbol widths beyond the symbol, so that a label
+// printed around the QR code can be read from a rotated or oblique photo.
+func Rectify(img image.Image, code QRCode, left, top, right, bottom float64) (*image.Gray, error) {
+	if code.transform == (homography{}) {
+		return nil, errors.New("QR code has no position information")
+	}
+	dim := float64(dimensionForVersion(code.Version))
+	scale := math.Max(code.ModuleSize, 1)
+	width, height := (left+1+right)*dim, (top+1+bottom)*dim
+	if pixels := width * height * scale * scale; pixels > maxRectifyPixels {
+		scale *= math.Sqrt(maxRectifyPixels / pixels)
+	}
+
+	g := toGray(img)
+	out := image.NewGray(image.Rect(0, 0, int(width*scale), int(height*scale)))
+	for y := 0; y < out.Rect.Dy(); y++ {
+		row := out.Pix[y*out.Stride : y*out.Stride+out.Rect.Dx()]
+		v := (float64(y)+0.5)/scale - top*dim
+		for x := range row {
+			p := code.transform.apply((float64(x)+0.5)/scale-left*dim, v)
+			row[x] = g.bilinear(p.x-0.5, p.y-0.5)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,326 @@
+// Package server implements the HTTP API used by MES and web tools to generate,
+// validate and decode HomeKit labels without shelling out to the CLI.
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lordbasex/HomeKitGenQRCode/internal/api"
+	"github.com/lordbasex/HomeKitGenQRCode/internal/generator"
+	"github.com/lordbasex/HomeKitGenQRCode/pkg/homekit"
+)
+
+// DefaultMaxBodyBytes is the default request body size limit.
+const DefaultMaxBodyBytes = 64 << 10
+
+// Config holds the server settings.
+type Config struct {
+	Addr            string        // Listen address, e.g. 127.0.0.1:8080
+	Token           string        // Optional bearer token required on /api/ requests
+	MaxBodyBytes    int64         // Request body size limit (DefaultMaxBodyBytes if zero)
+	TLSCertFile     string        // Certificate file; enables TLS together with TLSKeyFile
+	TLSKeyFile      string        // Private key file
+	ShutdownTimeout time.Duration // Time allowed for in-flight requests on shutdown
+	Version         string        // Reported in the OpenAPI document
+	Logger          *log.Logger   // Request and lifecycle log (nil for none)
+}
+
+// Server serves the HTTP API.
+type Server struct {
+	cfg Config
+	mux *http.ServeMux
+}
+
+// New creates a server with the API routes registered.
+func New(cfg Config) *Server {
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+	if cfg.ShutdownTimeout <= 0 {
+		cfg.ShutdownTimeout = 10 * time.Second
+	}
+
+	s := &Server{cfg: cfg, mux: http.NewServeMux()}
+	s.mux.HandleFunc("POST /api/v1/label", s.auth(s.handleLabel))
+	s.mux.HandleFunc("GET /api/v1/random", s.auth(s.handleRandom))
+	s.mux.HandleFunc("POST /api/v1/validate", s.auth(s.handleValidate))
+	s.mux.HandleFunc("GET /api/v1/categories", s.auth(s.handleCategories))
+	s.mux.HandleFunc("POST /api/v1/decode", s.auth(s.handleDecode))
+	s.mux.HandleFunc("GET /api/v1/openapi.json", s.handleOpenAPI)
+	s.mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	return s
+}
+
+// Handle registers an additional handler on the server mux, e.g. static files.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// Handler returns the root HTTP handler.
+func (s *Server) Handler() http.Handler {
+	return s.mux
+}
+
+// ListenAndServe serves until ctx is cancelled, then shuts down gracefully,
+// letting in-flight requests finish within ShutdownTimeout.
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("error listening on %s: %w", s.cfg.Addr, err)
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve is like ListenAndServe but uses an existing listener.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	srv := &http.Server{
+		Handler:           s.logRequests(s.mux),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      60 * time.Second,
+		IdleTimeout:       120 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		if s.cfg.TLSCertFile != "" || s.cfg.TLSKeyFile != "" {
+			errCh <- srv.ServeTLS(ln, s.cfg.TLSCertFile, s.cfg.TLSKeyFile)
+		} else {
+			errCh <- srv.Serve(ln)
+		}
+	}()
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+		s.logf("shutting down (waiting up to %s for in-flight requests)", s.cfg.ShutdownTimeout)
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			return fmt.Errorf("error shutting down server: %w", err)
+		}
+		return nil
+	}
+}
+
+// auth wraps a handler with bearer token authentication when a token is configured.
+func (s *Server) auth(next http.HandlerFunc) http.HandlerFunc {
+	if s.cfg.Token == "" {
+		return next
+	}
+	expected := []byte("Bearer " + s.cfg.Token)
+	return func(w http.ResponseWriter, r *http.Request) {
+		got := []byte(r.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(got, expected) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="homekitgenqrcode"`)
+			writeError(w, http.StatusUnauthorized, errors.New("missing or invalid bearer token"))
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handleLabel renders a label and returns it as PNG, SVG, PDF or JSON.
+func (s *Server) handleLabel(w http.ResponseWriter, r *http.Request) {
+	var req api.GenerateLabelRequest
+	if !s.decodeBody(w, r, &req) {
+		return
+	}
+
+	info, err := homekit.ParseSetupInfo(req.Category, req.Password, req.SetupID, req.MAC)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	asJSON := strings.EqualFold(req.Format, "json")
+	format := generator.FormatPNG
+	if !asJSON {
+		if format, err = generator.ParseImageFormat(req.Format); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := generator.EncodeLabel(&buf, generator.NewLabel(info), generator.LabelOptions{}, format); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if asJSON {
+		writeJSON(w, http.StatusOK, api.GenerateLabelResponse{
+			ImageBase64: "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="homekit-%s%s"`, info.SetupID, format.Extension()))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// handleRandom returns random setup values for a category.
+func (s *Server) handleRandom(w http.ResponseWriter, r *http.Request) {
+	category, err := strconv.Atoi(r.URL.Query().Get("category"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("query parameter 'category' must be an integer"))
+		return
+	}
+	if err := homekit.ValidateCategory(category); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	info := homekit.NewSetupInfo(category)
+	writeJSON(w, http.StatusOK, api.RandomCodeResponse{
+		SetupCode: info.SetupCode,
+		SetupID:   info.SetupID,
+		MAC:       info.MAC,
+		Category:  info.Category,
+	})
+}
+
+// handleValidate validates setup parameters. Invalid input is reported in the
+// response body with status 200, matching the WASM validateInputs function.
+func (s *Server) handleValidate(w http.ResponseWriter, r *http.Request) {
+	var req api.ValidationRequest
+	if !s.decodeBody(w, r, &req) {
+		return
+	}
+
+	resp := api.ValidationResponse{Valid: true, Warning: homekit.SetupCodeWarning(req.Password)}
+	if err := homekit.ValidateSetupInfo(req.Category, req.Password, req.SetupID, req.MAC); err != nil {
+		resp = api.ValidationResponse{Valid: false, Error: err.Error()}
+		var verr *homekit.ValidationError
+		if errors.As(err, &verr) {
+			resp.Field = verr.Field
+			resp.Rule = verr.Rule
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleCategories lists all categories sorted by ID.
+func (s *Server) handleCategories(w http.ResponseWriter, r *http.Request) {
+	categories := []api.Category{}
+	for _, id := range homekit.CategoryIDs() {
+		categories = append(categories, api.Category{ID: id, Name: homekit.CategoryReference[id]})
+	}
+	writeJSON(w, http.StatusOK, categories)
+}
+
+// handleDecode decodes an X-HM:// setup URI.
+func (s *Server) handleDecode(w http.ResponseWriter, r *http.Request) {
+	var req api.DecodeURIRequest
+	if !s.decodeBody(w, r, &req) {
+		return
+	}
+
+	payload, err := homekit.DecodeSetupURI(req.URI)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, api.NewDecodeURIResponse(payload))
+}
+
+// handleOpenAPI serves the OpenAPI document.
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, api.OpenAPI(s.cfg.Version, s.cfg.Token != ""))
+}
+
+// decodeBody decodes a size-limited JSON request body into v.
+// On failure it writes the error response and returns false.
+func (s *Server) decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, s.cfg.MaxBodyBytes)
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(v); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("request body exceeds %d bytes", tooLarge.Limit))
+			return false
+		}
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid JSON: %w", err))
+		return false
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		writeError(w, http.StatusBadRequest, errors.New("invalid JSON: unexpected data after the request object"))
+		return false
+	}
+	return true
+}
+
+// logRequests logs method, path, status and duration of every request.
+func (s *Server) logRequests(next http.Handler) http.Handler {
+	if s.cfg.Logger == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		s.cfg.Logger.Printf("%s %s %d %s", r.Method, r.URL.Path, rec.status, time.Since(start).Round(time.Millisecond))
+	})
+}
+
+// logf writes a lifecycle message if a logger is configured.
+func (s *Server) logf(format string, args ...interface{}) {
+	if s.cfg.Logger != nil {
+		s.cfg.Logger.Printf(format, args...)
+	}
+}
+
+// statusRecorder captures the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// writeJSON writes v as a JSON response.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// writeError writes an ErrorResponse, including field and rule for validation errors.
+func writeError(w http.ResponseWriter, status int, err error) {
+	resp := api.ErrorResponse{Error: err.Error()}
+	var verr *homekit.ValidationError
+	if errors.As(err, &verr) {
+		resp.Field = verr.Field
+		resp.Rule = verr.Rule
+	}
+	writeJSON(w, status, resp)
+}
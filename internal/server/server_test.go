@@ -0,0 +1,171 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lordbasex/HomeKitGenQRCode/internal/api"
+	"github.com/lordbasex/HomeKitGenQRCode/pkg/homekit"
+)
+
+const labelBody = `{"category": 5, "password": "613-80-755", "setupId": "ABCD", "mac": "8E:17:87:A9:C4:32"`
+
+// do sends a request to the server and returns the recorded response.
+func do(s *Server, method, path, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+	return rec
+}
+
+// decodeError decodes an ErrorResponse body.
+func decodeError(t *testing.T, rec *httptest.ResponseRecorder) api.ErrorResponse {
+	t.Helper()
+	var resp api.ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("error response %q is not JSON: %v", rec.Body, err)
+	}
+	return resp
+}
+
+func TestAuth(t *testing.T) {
+	s := New(Config{Token: "secret"})
+	tests := []struct {
+		method, path, token string
+		want                int
+	}{
+		{"GET", "/api/v1/categories", "", http.StatusUnauthorized},
+		{"GET", "/api/v1/categories", "wrong", http.StatusUnauthorized},
+		{"GET", "/api/v1/categories", "secret", http.StatusOK},
+		{"GET", "/api/v1/random?category=5", "", http.StatusUnauthorized},
+		{"GET", "/api/v1/random?category=5", "secret", http.StatusOK},
+		{"GET", "/api/v1/openapi.json", "", http.StatusOK},
+		{"GET", "/healthz", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		rec := do(s, tt.method, tt.path, tt.token, "")
+		if rec.Code != tt.want {
+			t.Errorf("%s %s with token %q = %d, want %d", tt.method, tt.path, tt.token, rec.Code, tt.want)
+		}
+		if rec.Code == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("%s %s: 401 without WWW-Authenticate", tt.method, tt.path)
+		}
+	}
+
+	// Without a configured token the API is open
+	if rec := do(New(Config{}), "GET", "/api/v1/categories", "", ""); rec.Code != http.StatusOK {
+		t.Errorf("GET /api/v1/categories without a token configured = %d, want 200", rec.Code)
+	}
+}
+
+func TestDecodeBody(t *testing.T) {
+	s := New(Config{MaxBodyBytes: 256})
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"valid", labelBody + `}`, http.StatusOK},
+		{"too large", labelBody + `, "format": "` + strings.Repeat("x", 256) + `"}`, http.StatusRequestEntityTooLarge},
+		{"unknown field", labelBody + `, "color": "red"}`, http.StatusBadRequest},
+		{"trailing data", labelBody + `} {}`, http.StatusBadRequest},
+		{"not JSON", `category=5`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		rec := do(s, "POST", "/api/v1/label", "", tt.body)
+		if rec.Code != tt.want {
+			t.Errorf("%s: status %d, want %d (%s)", tt.name, rec.Code, tt.want, rec.Body)
+		}
+		if tt.want != http.StatusOK && decodeError(t, rec).Error == "" {
+			t.Errorf("%s: error response without a message", tt.name)
+		}
+	}
+}
+
+func TestLabelValidationError(t *testing.T) {
+	s := New(Config{})
+	tests := []struct {
+		body, field, rule string
+	}{
+		{`{"category": 5, "password": "123-45-678", "setupId": "ABCD", "mac": "AABBCCDDEEFF"}`, homekit.FieldSetupCode, homekit.RuleSequence},
+		{`{"category": 5, "password": "613-80-755", "setupId": "AB", "mac": "AABBCCDDEEFF"}`, homekit.FieldSetupID, homekit.RuleLength},
+		{`{"category": 999, "password": "613-80-755", "setupId": "ABCD", "mac": "AABBCCDDEEFF"}`, homekit.FieldCategory, homekit.RuleUnknownCategory},
+		{`{"category": 5, "password": "613-80-755", "setupId": "ABCD", "mac": ""}`, homekit.FieldMAC, homekit.RuleRequired},
+	}
+	for _, tt := range tests {
+		rec := do(s, "POST", "/api/v1/label", "", tt.body)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", tt.body, rec.Code)
+			continue
+		}
+		if resp := decodeError(t, rec); resp.Field != tt.field || resp.Rule != tt.rule {
+			t.Errorf("%s: field/rule = %s/%s, want %s/%s", tt.body, resp.Field, resp.Rule, tt.field, tt.rule)
+		}
+	}
+}
+
+func TestLabelFormats(t *testing.T) {
+	s := New(Config{})
+	tests := []struct {
+		format      string
+		contentType string
+		prefix      string
+	}{
+		{"", "image/png", "\x89PNG\r\n\x1a\n"},
+		{"png", "image/png", "\x89PNG\r\n\x1a\n"},
+		{"svg", "image/svg+xml", "<?xml"},
+		{"pdf", "application/pdf", "%PDF-"},
+		{"json", "application/json", "{"},
+	}
+	for _, tt := range tests {
+		rec := do(s, "POST", "/api/v1/label", "", labelBody+`, "format": "`+tt.format+`"}`)
+		if rec.Code != http.StatusOK {
+			t.Errorf("format %q: status %d (%s)", tt.format, rec.Code, rec.Body)
+			continue
+		}
+		if got := rec.Header().Get("Content-Type"); got != tt.contentType {
+			t.Errorf("format %q: Content-Type %s, want %s", tt.format, got, tt.contentType)
+		}
+		if !bytes.HasPrefix(rec.Body.Bytes(), []byte(tt.prefix)) {
+			t.Errorf("format %q: body starts with %q, want %q", tt.format, rec.Body.Bytes()[:min(8, rec.Body.Len())], tt.prefix)
+		}
+		if tt.format == "json" {
+			var resp api.GenerateLabelResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || !strings.HasPrefix(resp.ImageBase64, "data:image/png;base64,") {
+				t.Errorf("format json: imageBase64 %.40q, %v", resp.ImageBase64, err)
+			}
+		}
+	}
+
+	rec := do(s, "POST", "/api/v1/label", "", labelBody+`, "format": "gif"}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("format gif: status %d, want 400", rec.Code)
+	}
+}
+
+func TestDecodeRoundTrip(t *testing.T) {
+	s := New(Config{})
+	uri := homekit.GenHomeKitSetupURI(5, "613-80-755", "ABCD")
+	rec := do(s, "POST", "/api/v1/decode", "", `{"uri": "`+uri+`"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("decode %s: status %d (%s)", uri, rec.Code, rec.Body)
+	}
+	var resp api.DecodeURIResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Category != 5 || resp.SetupCode != "613-80-755" || resp.SetupID != "ABCD" || resp.Flags != homekit.FlagIP {
+		t.Errorf("decode %s = %+v, want category 5, setup code 613-80-755, setup ID ABCD, IP flag", uri, resp)
+	}
+
+	if rec := do(s, "POST", "/api/v1/decode", "", `{"uri": "X-HM://not-a-uri"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("decode of an invalid URI: status %d, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,37 @@
+// Package webui embeds the browser interface (index.html, wasm_exec.js and the
+// compiled WebAssembly module) so the CLI binary can serve it without Node.js.
+//
+// The .wasm module and wasm_exec.js are produced by "make wasm" and are not
+// tracked in git. Binaries built without them still serve index.html, which
+// then falls back to the server-side renderer of the HTTP API.
+package webui
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// WASMFile is the name of the compiled WebAssembly module.
+const WASMFile = "homekitgenqrcode.wasm"
+
+//go:embed static
+var static embed.FS
+
+// FS returns the web interface files rooted at index.html.
+func FS() fs.FS {
+	sub, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err) // The embedded directory always exists
+	}
+	return sub
+}
+
+// HasWASM reports whether the WebAssembly module and its runtime were embedded.
+func HasWASM() bool {
+	for _, name := range []string{WASMFile, "wasm_exec.js"} {
+		if _, err := fs.Stat(FS(), name); err != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,190 @@
+package homekit
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Constants of the HAP Bluetooth LE advertisement
+const (
+	bleAppleCompanyID     = 0x004C // Apple Inc. Bluetooth SIG company identifier
+	bleHAPType            = 0x06   // HomeKit regular advertisement
+	bleHAPSubtype         = 0x01   // Subtype of the regular advertisement (20 ms interval)
+	bleCompatibleVersion  = 0x02   // Compatible version (CV) of the HAP BLE protocol
+	bleHAPPayloadLength   = 17     // SF, device ID, ACID, GSN, CN, CV and setup hash
+	bleMaxAdvertisingData = 31     // Legacy advertising data limit
+	bleADTypeFlags        = 0x01   // AD type: flags
+	bleADTypeLocalName    = 0x09   // AD type: complete local name
+	bleADTypeManufacturer = 0xFF   // AD type: manufacturer specific data
+	bleFlagsGeneral       = 0x06   // LE General Discoverable, BR/EDR not supported
+)
+
+// BLEOptions holds the advertisement values that are not printed on the
+// label. The zero value describes an unpaired accessory at global state number
+// and configuration number 1.
+type BLEOptions struct {
+	// StateNumber is the global state number (GSN); 0 selects 1.
+	StateNumber int
+	// ConfigNumber is the configuration number (CN); 0 selects 1.
+	ConfigNumber int
+	// Paired clears the not-paired status flag.
+	Paired bool
+	// Name is the local name sent in the scan response; empty omits it.
+	Name string
+}
+
+// BLEAdvertisement is the Bluetooth LE advertisement of a HAP accessory: the
+// Apple manufacturer data with type 0x06 followed by the HAP payload.
+type BLEAdvertisement struct {
+	StatusFlags  int
+	DeviceID     MAC
+	Category     int
+	StateNumber  int
+	ConfigNumber int
+	SetupHash    [4]byte
+	Name         string
+}
+
+// BLEField is one field of the advertisement byte layout.
+type BLEField struct {
+	Name   string `json:"name" yaml:"name"`
+	Offset int    `json:"offset" yaml:"offset"`
+	Hex    string `json:"hex" yaml:"hex"`
+	Value  string `json:"value" yaml:"value"`
+}
+
+// NewBLEAdvertisement builds the advertisement of the accessory described by
+// info: the MAC address becomes the device ID, the category the accessory
+// category identifier (ACID), and the setup ID with the device ID the setup
+// hash, the same one advertised as sh over IP.
+func NewBLEAdvertisement(info SetupInfo, opts BLEOptions) (BLEAdvertisement, error) {
+	if err := ValidateCategory(info.Category); err != nil {
+		return BLEAdvertisement{}, err
+	}
+	setupID, err := NormalizeSetupID(info.SetupID)
+	if err != nil {
+		return BLEAdvertisement{}, err
+	}
+	addr, err := ParseMAC(info.MAC)
+	if err != nil {
+		return BLEAdvertisement{}, err
+	}
+	if addr.IsEUI64() {
+		return BLEAdvertisement{}, fmt.Errorf("HAP device IDs are 48 bits; EUI-64 address %s cannot be used", addr.Format(MACStyleColon))
+	}
+	if opts.StateNumber < 0 || opts.StateNumber > 65535 {
+		return BLEAdvertisement{}, fmt.Errorf("global state number %d is out of range (1-65535)", opts.StateNumber)
+	}
+	if opts.ConfigNumber < 0 || opts.ConfigNumber > 255 {
+		return BLEAdvertisement{}, fmt.Errorf("configuration number %d is out of range (1-255)", opts.ConfigNumber)
+	}
+	name := strings.TrimSpace(opts.Name)
+	if len(name) > bleMaxAdvertisingData-2 {
+		return BLEAdvertisement{}, fmt.Errorf("local name %q is longer than %d bytes", name, bleMaxAdvertisingData-2)
+	}
+
+	a := BLEAdvertisement{
+		StatusFlags:  StatusNotPaired,
+		DeviceID:     addr,
+		Category:     info.Category,
+		StateNumber:  max(opts.StateNumber, 1),
+		ConfigNumber: max(opts.ConfigNumber, 1),
+		Name:         name,
+	}
+	if opts.Paired {
+		a.StatusFlags = 0
+	}
+	hash, _ := base64.StdEncoding.DecodeString(SetupHash(setupID, addr.Format(MACStyleColon)))
+	copy(a.SetupHash[:], hash)
+	return a, nil
+}
+
+// Fields returns the advertising data layout field by field.
+func (a BLEAdvertisement) Fields() []BLEField {
+	var fields []BLEField
+	offset := 0
+	add := func(name string, b []byte, value string) {
+		fields = append(fields, BLEField{Name: name, Offset: offset, Hex: strings.ToUpper(hex.EncodeToString(b)), Value: value})
+		offset += len(b)
+	}
+	le16 := func(v int) []byte {
+		return binary.LittleEndian.AppendUint16(nil, uint16(v))
+	}
+
+	add("Flags AD length", []byte{2}, "2")
+	add("Flags AD type", []byte{bleADTypeFlags}, "flags")
+	add("Flags", []byte{bleFlagsGeneral}, "LE General Discoverable, BR/EDR not supported")
+	add("Manufacturer AD length", []byte{bleHAPPayloadLength + 5}, strconv.Itoa(bleHAPPayloadLength+5))
+	add("Manufacturer AD type", []byte{bleADTypeManufacturer}, "manufacturer specific data")
+	add("Company ID", le16(bleAppleCompanyID), "0x004C (Apple)")
+	add("Type", []byte{bleHAPType}, "0x06 (HomeKit)")
+	add("Interval and length", []byte{bleHAPSubtype<<5 | bleHAPPayloadLength}, fmt.Sprintf("subtype %d (20 ms), length %d", bleHAPSubtype, bleHAPPayloadLength))
+	add("Status flags (SF)", []byte{byte(a.StatusFlags)}, a.statusName())
+	add("Device ID", a.DeviceID[:6], a.DeviceID.Format(MACStyleColon))
+	add("Category (ACID)", le16(a.Category), fmt.Sprintf("%d (%s)", a.Category, CategoryName(a.Category)))
+	add("Global state number (GSN)", le16(a.StateNumber), strconv.Itoa(a.StateNumber))
+	add("Configuration number (CN)", []byte{byte(a.ConfigNumber)}, strconv.Itoa(a.ConfigNumber))
+	add("Compatible version (CV)", []byte{bleCompatibleVersion}, strconv.Itoa(bleCompatibleVersion))
+	add("Setup hash (SH)", a.SetupHash[:], base64.StdEncoding.EncodeToString(a.SetupHash[:]))
+	return fields
+}
+
+// Bytes returns the advertising data: the flags AD structure followed by the
+// manufacturer specific data.
+func (a BLEAdvertisement) Bytes() []byte {
+	var b []byte
+	for _, f := range a.Fields() {
+		v, _ := hex.DecodeString(f.Hex)
+		b = append(b, v...)
+	}
+	return b
+}
+
+// ScanResponse returns the scan response data holding the complete local
+// name, or nil when the advertisement has no name.
+func (a BLEAdvertisement) ScanResponse() []byte {
+	if a.Name == "" {
+		return nil
+	}
+	return append([]byte{byte(len(a.Name) + 1), bleADTypeLocalName}, a.Name...)
+}
+
+// CArray returns C arrays holding the advertising data and, when the
+// advertisement has a name, the scan response data.
+func (a BLEAdvertisement) CArray(ident string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "/* HAP BLE advertisement for %s */\n", a.DeviceID.Format(MACStyleColon))
+	writeCBytes(&sb, ident+"_adv", a.Bytes())
+	if rsp := a.ScanResponse(); rsp != nil {
+		writeCBytes(&sb, ident+"_rsp", rsp)
+	}
+	return sb.String()
+}
+
+// statusName describes the status flags.
+func (a BLEAdvertisement) statusName() string {
+	if a.StatusFlags&StatusNotPaired != 0 {
+		return "1 (not paired)"
+	}
+	return "0 (paired)"
+}
+
+// writeCBytes writes a static const uint8_t array, eight bytes per line.
+func writeCBytes(sb *strings.Builder, ident string, data []byte) {
+	fmt.Fprintf(sb, "static const uint8_t %s[%d] = {\n", ident, len(data))
+	for i := 0; i < len(data); i += 8 {
+		sb.WriteString("\t")
+		for j, c := range data[i:min(i+8, len(data))] {
+			if j > 0 {
+				sb.WriteString(" ")
+			}
+			fmt.Fprintf(sb, "0x%02X,", c)
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("};\n")
+}
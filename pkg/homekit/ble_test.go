@@ -0,0 +1,114 @@
+package homekit
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestBLEAdvertisementBytes(t *testing.T) {
+	info := SetupInfo{Category: 5, SetupCode: "613-80-755", SetupID: "ABCD", MAC: "8E:17:87:A9:C4:32"}
+	tests := []struct {
+		name string
+		opts BLEOptions
+		want string
+	}{
+		{
+			name: "defaults",
+			want: "020106" + "16FF4C00" + "0631" + "01" + "8E1787A9C432" + "0500" + "0100" + "01" + "02" + "10D20EE6",
+		},
+		{
+			name: "paired",
+			opts: BLEOptions{StateNumber: 0x1234, ConfigNumber: 7, Paired: true},
+			want: "020106" + "16FF4C00" + "0631" + "00" + "8E1787A9C432" + "0500" + "3412" + "07" + "02" + "10D20EE6",
+		},
+	}
+	for _, tt := range tests {
+		a, err := NewBLEAdvertisement(info, tt.opts)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		want, _ := hex.DecodeString(tt.want)
+		if got := a.Bytes(); !bytes.Equal(got, want) {
+			t.Errorf("%s: Bytes() = %X, want %X", tt.name, got, want)
+		}
+		if len(a.Bytes()) > bleMaxAdvertisingData {
+			t.Errorf("%s: advertisement is %d bytes, more than %d", tt.name, len(a.Bytes()), bleMaxAdvertisingData)
+		}
+	}
+}
+
+func TestBLEAdvertisementFields(t *testing.T) {
+	info := SetupInfo{Category: 9, SetupCode: "613-80-755", SetupID: "7OSX", MAC: "aa-bb-cc-dd-ee-ff"}
+	a, err := NewBLEAdvertisement(info, BLEOptions{StateNumber: 300, ConfigNumber: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]struct {
+		offset int
+		hex    string
+	}{
+		"Status flags (SF)":         {9, "01"},
+		"Device ID":                 {10, "AABBCCDDEEFF"},
+		"Category (ACID)":           {16, "0900"},
+		"Global state number (GSN)": {18, "2C01"},
+		"Configuration number (CN)": {20, "02"},
+		"Compatible version (CV)":   {21, "02"},
+		"Setup hash (SH)":           {22, "5C8A2740"},
+	}
+	offset := 0
+	for _, f := range a.Fields() {
+		if f.Offset != offset {
+			t.Errorf("field %s at offset %d, want %d", f.Name, f.Offset, offset)
+		}
+		offset += len(f.Hex) / 2
+		if w, ok := want[f.Name]; ok {
+			if f.Offset != w.offset || f.Hex != w.hex {
+				t.Errorf("field %s = %s at %d, want %s at %d", f.Name, f.Hex, f.Offset, w.hex, w.offset)
+			}
+			delete(want, f.Name)
+		}
+	}
+	for name := range want {
+		t.Errorf("field %s missing", name)
+	}
+	if offset != len(a.Bytes()) {
+		t.Errorf("fields cover %d bytes, advertisement has %d", offset, len(a.Bytes()))
+	}
+}
+
+func TestBLEScanResponse(t *testing.T) {
+	info := SetupInfo{Category: 5, SetupCode: "613-80-755", SetupID: "ABCD", MAC: "8E:17:87:A9:C4:32"}
+	a, err := NewBLEAdvertisement(info, BLEOptions{Name: "Lamp"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := a.ScanResponse(), []byte{5, 0x09, 'L', 'a', 'm', 'p'}; !bytes.Equal(got, want) {
+		t.Errorf("ScanResponse() = %X, want %X", got, want)
+	}
+	a.Name = ""
+	if a.ScanResponse() != nil {
+		t.Error("ScanResponse() without a name is not nil")
+	}
+}
+
+func TestNewBLEAdvertisementErrors(t *testing.T) {
+	info := SetupInfo{Category: 5, SetupCode: "613-80-755", SetupID: "ABCD", MAC: "8E:17:87:A9:C4:32"}
+	eui64 := info
+	eui64.MAC = "8E:17:87:FF:FE:A9:C4:32"
+	tests := []struct {
+		name string
+		info SetupInfo
+		opts BLEOptions
+	}{
+		{"EUI-64", eui64, BLEOptions{}},
+		{"state number", info, BLEOptions{StateNumber: 65536}},
+		{"config number", info, BLEOptions{ConfigNumber: 256}},
+		{"long name", info, BLEOptions{Name: "A name that does not fit in 31 bytes"}},
+	}
+	for _, tt := range tests {
+		if _, err := NewBLEAdvertisement(tt.info, tt.opts); err == nil {
+			t.Errorf("%s: NewBLEAdvertisement accepted invalid input", tt.name)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package homekit
+
+import "sort"
+
+// CategoryReference maps HomeKit category IDs to their human-readable names.
+// This map contains all supported HomeKit device categories.
+var CategoryReference = map[int]string{
+	1: "Other", 2: "Bridge", 3: "Fan", 4: "Garage Door Opener", 5: "Light",
+	6: "Lock", 7: "Outlet", 8: "Switch", 9: "Thermostat", 10: "Sensor",
+	11: "Security system", 12: "Door", 13: "Window", 14: "Window covering",
+	15: "Programmable switch", 16: "Range extender", 17: "IP camera",
+	18: "Video doorbell", 19: "Air purifier", 20: "Heater", 21: "Air conditioner",
+	22: "Humidifier", 23: "Dehumidifier", 24: "Apple TV", 26: "Speaker",
+	27: "Airport", 28: "Sprinkler", 29: "Faucet", 30: "Shower head",
+	31: "Television", 32: "Target remote",
+}
+
+// CategoryName returns the human-readable name of a category ID, or "Unknown"
+// if the ID is not defined in CategoryReference.
+func CategoryName(category int) string {
+	if name, ok := CategoryReference[category]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// CategoryIDs returns all defined category IDs sorted in ascending order.
+func CategoryIDs() []int {
+	ids := make([]int, 0, len(CategoryReference))
+	for id := range CategoryReference {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
@@ -0,0 +1,186 @@
+package homekit
+
+import (
+	"crypto/hkdf"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// MinMasterKeyLength is the minimum accepted length in bytes of the factory
+// master secret used by DeriveSetupCode.
+const MinMasterKeyLength = 16
+
+// maxDeriveAttempts bounds the re-derivation loop in DeriveSetupCode.
+// A rejected code is astronomically unlikely to repeat this many times.
+const maxDeriveAttempts = 1000
+
+// GenerateHomeKitSetupCode generates a valid HomeKit setup code in format XXX-XX-XXX.
+// Internally uses 8 digits and avoids trivial codes (sequences, repeated digits, simple patterns).
+// Similar to HomeSpan's practical criteria for code generation.
+func GenerateHomeKitSetupCode() string {
+	for {
+		// Generate 8-digit number (10000000-99999999 avoids 00000000)
+		n := rand.Intn(90000000) + 10000000
+		raw := fmt.Sprintf("%08d", n)
+
+		if !isTooSimple(raw) {
+			return FormatSetupCode(raw)
+		}
+	}
+}
+
+// ParseMasterKey decodes the contents of a factory master key file: the key
+// as hexadecimal digits (e.g. the output of "openssl rand -hex 32"). Whitespace
+// anywhere in the file, such as a trailing newline or CRLF, is ignored, so the
+// same key always decodes to the same bytes.
+func ParseMasterKey(data []byte) ([]byte, error) {
+	digits := strings.Join(strings.Fields(string(data)), "")
+	key, err := hex.DecodeString(digits)
+	if err != nil {
+		return nil, fmt.Errorf("master key must be hexadecimal: %w", err)
+	}
+	if len(key) < MinMasterKeyLength {
+		return nil, fmt.Errorf("master key too short (%d bytes). At least %d bytes (%d hexadecimal digits) are required", len(key), MinMasterKeyLength, 2*MinMasterKeyLength)
+	}
+	return key, nil
+}
+
+// DeriveSetupCode derives a setup code (XXX-XX-XXX) and setup ID from a factory
+// master secret and a device identifier such as the serial number or MAC address.
+// Key material is produced with HKDF-SHA256, using the identifier and an attempt
+// counter as the info parameter; the counter is increased until the code passes
+// the isTooSimple rules. The same master key and identifier always yield the same
+// values, so any label can be regenerated by a station holding only the master key.
+//
+// The identifier is trimmed and uppercased. An identifier ParseMAC accepts is
+// used in its bare form (MAC.String), so "aa:bb:cc:dd:ee:ff", "AA-BB-CC-DD-EE-FF"
+// and "AABBCCDDEEFF" derive the same code.
+func DeriveSetupCode(masterKey []byte, identifier string) (setupCode, setupID string, err error) {
+	if len(masterKey) < MinMasterKeyLength {
+		return "", "", fmt.Errorf("master key too short (%d bytes). At least %d bytes are required", len(masterKey), MinMasterKeyLength)
+	}
+
+	identifier = strings.ToUpper(strings.TrimSpace(identifier))
+	if identifier == "" {
+		return "", "", fmt.Errorf("device identifier cannot be empty")
+	}
+	if mac, err := ParseMAC(identifier); err == nil {
+		identifier = mac.String()
+	}
+
+	for attempt := 0; attempt < maxDeriveAttempts; attempt++ {
+		info := fmt.Sprintf("HomeKitGenQRCode setup code|%s|%d", identifier, attempt)
+
+		// 8 bytes for the setup code, 4 bytes for the setup ID
+		okm, err := hkdf.Key(sha256.New, masterKey, nil, info, 12)
+		if err != nil {
+			return "", "", fmt.Errorf("error deriving key material: %w", err)
+		}
+
+		raw := fmt.Sprintf("%08d", binary.BigEndian.Uint64(okm[0:8])%100000000)
+		if isTooSimple(raw) {
+			continue
+		}
+
+		id := make([]byte, 4)
+		for i := range id {
+			id[i] = base36[int(okm[8+i])%len(base36)]
+		}
+
+		return FormatSetupCode(raw), string(id), nil
+	}
+
+	return "", "", fmt.Errorf("could not derive a valid setup code for %q", identifier)
+}
+
+// PlainSetupCode converts a formatted setup code to plain format.
+// Example: "613-80-755" -> "61380755"
+func PlainSetupCode(code string) string {
+	return strings.ReplaceAll(code, "-", "")
+}
+
+// IsValidSetupCode validates HomeKit setup code format.
+// Accepts codes with or without dashes, but must be 8 digits and not disallowed by the HAP specification.
+// Use ValidateSetupCode to find out why a code was rejected.
+func IsValidSetupCode(code string) bool {
+	return ValidateSetupCode(code) == nil
+}
+
+// isTooSimple checks if a setup code is too simple to be generated: either
+// disallowed by the HAP specification (see setupCodeRule) or easy to guess
+// (see weakSetupCode).
+func isTooSimple(raw string) bool {
+	rule, _ := setupCodeRule(raw)
+	return rule != "" || weakSetupCode(raw) != ""
+}
+
+// setupCodeRule checks an 8-digit plain setup code against the codes the HAP
+// specification disallows. It returns the violated rule and a reason, or empty
+// strings if the spec allows the code:
+//   - 00000000, 11111111, 22222222, ... 99999999 (all digits the same)
+//   - 12345678 and 87654321
+func setupCodeRule(raw string) (rule, reason string) {
+	if len(raw) != 8 {
+		return RuleLength, "a setup code has 8 digits"
+	}
+	if strings.Count(raw, raw[:1]) == 8 {
+		return RuleRepeatedDigits, "all digits are the same"
+	}
+	if raw == "12345678" || raw == "87654321" {
+		return RuleSequence, "the HAP specification disallows 12345678 and 87654321"
+	}
+	return "", ""
+}
+
+// weakSetupCode checks an 8-digit plain setup code that the HAP specification
+// allows against the practical anti-simple rules of HomeSpan, which generated
+// codes also follow. It returns the reason the code is easy to guess, or an
+// empty string:
+//   - Any ascending or descending run (01234567, 23456789, 76543210, ...)
+//   - Simple repetitive pattern (ABABABAB numeric: 12121212, 34343434, ...)
+func weakSetupCode(raw string) string {
+	if len(raw) != 8 {
+		return ""
+	}
+
+	asc, desc := true, true
+	for i := 1; i < 8; i++ {
+		if raw[i] != raw[i-1]+1 {
+			asc = false
+		}
+		if raw[i] != raw[i-1]-1 {
+			desc = false
+		}
+	}
+	if asc {
+		return "digits form an ascending sequence"
+	}
+	if desc {
+		return "digits form a descending sequence"
+	}
+
+	if raw[0:2] == raw[2:4] && raw[2:4] == raw[4:6] && raw[4:6] == raw[6:8] {
+		return "a two-digit pattern is repeated"
+	}
+	return ""
+}
+
+// SetupCodeWarning returns why a setup code that passes ValidateSetupCode is
+// still easy to guess (an ascending or descending run, or a repeated two-digit
+// pattern), or an empty string. Such codes are valid per the HAP specification
+// and are accepted, for example for accessories already paired in the field,
+// but are never generated.
+func SetupCodeWarning(code string) string {
+	if ValidateSetupCode(code) != nil {
+		return ""
+	}
+	raw := PlainSetupCode(strings.TrimSpace(code))
+	if reason := weakSetupCode(raw); reason != "" {
+		return fmt.Sprintf("setup code %s is allowed by the HAP specification but easy to guess: %s", FormatSetupCode(raw), reason)
+	}
+	return ""
+}
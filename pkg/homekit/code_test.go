@@ -0,0 +1,104 @@
+package homekit
+
+import (
+	"bytes"
+	"testing"
+)
+
+var testMasterKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestDeriveSetupCodeDeterministic(t *testing.T) {
+	code, id, err := DeriveSetupCode(testMasterKey, "SN0001234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateSetupCode(code); err != nil {
+		t.Errorf("derived setup code %s is invalid: %v", code, err)
+	}
+	if err := ValidateSetupID(id); err != nil {
+		t.Errorf("derived setup ID %s is invalid: %v", id, err)
+	}
+	for i := 0; i < 3; i++ {
+		c, d, _ := DeriveSetupCode(testMasterKey, "SN0001234")
+		if c != code || d != id {
+			t.Fatalf("DeriveSetupCode returned %s/%s, then %s/%s", code, id, c, d)
+		}
+	}
+
+	// Identifier forms that name the same device derive the same code
+	for _, same := range []string{" sn0001234 ", "SN0001234\n"} {
+		if c, d, _ := DeriveSetupCode(testMasterKey, same); c != code || d != id {
+			t.Errorf("DeriveSetupCode(%q) = %s/%s, want %s/%s", same, c, d, code, id)
+		}
+	}
+
+	// Different identifiers and keys derive different codes
+	seen := map[string]string{code: "SN0001234"}
+	for _, other := range []string{"SN0001235", "SN0001236", "AABBCCDDEEFF", "AABBCCDDEEF0"} {
+		c, _, err := DeriveSetupCode(testMasterKey, other)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if prev, ok := seen[c]; ok {
+			t.Errorf("DeriveSetupCode(%q) = %s, same as for %q", other, c, prev)
+		}
+		seen[c] = other
+	}
+	otherKey := bytes.Repeat([]byte{0x5A}, MinMasterKeyLength)
+	if c, _, _ := DeriveSetupCode(otherKey, "SN0001234"); c == code {
+		t.Errorf("a different master key derived the same code %s", c)
+	}
+}
+
+func TestDeriveSetupCodeMACNotations(t *testing.T) {
+	want, wantID, err := DeriveSetupCode(testMasterKey, "AABBCCDDEEFF")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mac := range []string{"aa:bb:cc:dd:ee:ff", "AA-BB-CC-DD-EE-FF", "aabb.ccdd.eeff", " aabbccddeeff "} {
+		if code, id, _ := DeriveSetupCode(testMasterKey, mac); code != want || id != wantID {
+			t.Errorf("DeriveSetupCode(%q) = %s/%s, want %s/%s", mac, code, id, want, wantID)
+		}
+	}
+}
+
+func TestDeriveSetupCodeErrors(t *testing.T) {
+	if _, _, err := DeriveSetupCode(testMasterKey[:MinMasterKeyLength-1], "SN0001234"); err == nil {
+		t.Error("DeriveSetupCode accepted a short master key")
+	}
+	if _, _, err := DeriveSetupCode(testMasterKey, "  "); err == nil {
+		t.Error("DeriveSetupCode accepted an empty identifier")
+	}
+}
+
+func TestParseMasterKey(t *testing.T) {
+	want := []byte{
+		0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77,
+		0x88, 0x99, 0xAA, 0xBB, 0xCC, 0xDD, 0xEE, 0xFF,
+	}
+	for _, data := range []string{
+		"00112233445566778899aabbccddeeff",
+		"00112233445566778899AABBCCDDEEFF\n",
+		"00112233445566778899aabbccddeeff\r\n",
+		"  0011 2233 4455 6677\n8899 aabb ccdd eeff\n",
+	} {
+		key, err := ParseMasterKey([]byte(data))
+		if err != nil {
+			t.Errorf("ParseMasterKey(%q): %v", data, err)
+			continue
+		}
+		if !bytes.Equal(key, want) {
+			t.Errorf("ParseMasterKey(%q) = %X, want %X", data, key, want)
+		}
+	}
+	for _, data := range []string{
+		"",
+		"00112233445566778899aabbccddee",   // 15 bytes
+		"00112233445566778899aabbccddeefg", // not hex
+		"00112233445566778899aabbccddeef",  // odd length
+	} {
+		if _, err := ParseMasterKey([]byte(data)); err == nil {
+			t.Errorf("ParseMasterKey(%q) accepted an invalid key", data)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package homekit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// base36 contains the characters used for base36 encoding (0-9, A-Z)
+const base36 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// setupURIPrefix is the scheme prefix of HomeKit setup URIs.
+const setupURIPrefix = "X-HM://"
+
+// Setup payload flags (4 bits) describing how the accessory can be paired.
+const (
+	FlagNFC = 1 << 0 // Accessory supports NFC pairing
+	FlagIP  = 1 << 1 // Accessory pairs over IP (Wi-Fi/Ethernet)
+	FlagBLE = 1 << 2 // Accessory pairs over Bluetooth LE
+)
+
+// GenHomeKitSetupURI generates a HomeKit setup URI from the provided parameters.
+// The URI format is: X-HM://{encoded_payload}{setupID}
+//
+// Parameters:
+//   - category: HomeKit device category ID
+//   - password: Setup password in format XXX-XX-XXX (will be converted to plain format)
+//   - setupID: Setup ID (4 alphanumeric characters)
+//
+// The payload is encoded as follows:
+//   - version (3 bits): Currently 0
+//   - reserved (4 bits): Currently 0
+//   - category (8 bits): Device category ID
+//   - flags (4 bits): Currently 2 (FlagIP)
+//   - password (27 bits): 8-digit password without dashes
+//
+// The payload is then base36 encoded to create the URI.
+func GenHomeKitSetupURI(category int, password, setupID string) string {
+	return GenHomeKitSetupURIWithFlags(category, FlagIP, password, setupID)
+}
+
+// GenHomeKitSetupURIWithFlags generates a HomeKit setup URI with explicit payload flags
+// (a combination of FlagNFC, FlagIP and FlagBLE). See GenHomeKitSetupURI for the layout.
+func GenHomeKitSetupURIWithFlags(category, flags int, password, setupID string) string {
+	version := 0
+	reserved := 0
+
+	// Build payload by bit-shifting and ORing values
+	payload := 0
+	payload |= (version & 0x7)
+	payload <<= 4
+	payload |= (reserved & 0xF)
+	payload <<= 8
+	payload |= (category & 0xFF)
+	payload <<= 4
+	payload |= (flags & 0xF)
+	payload <<= 27
+
+	// Convert password to plain format and add to payload
+	num, _ := strconv.Atoi(strings.ReplaceAll(password, "-", ""))
+	payload |= (num & 0x7FFFFFF)
+
+	// Encode payload to base36 (9 characters)
+	out := make([]byte, 9)
+	for i := 8; i >= 0; i-- {
+		out[i] = base36[payload%36]
+		payload /= 36
+	}
+
+	return fmt.Sprintf("%s%s%s", setupURIPrefix, string(out), setupID)
+}
+
+// SetupPayload is the decoded content of an X-HM:// setup URI.
+type SetupPayload struct {
+	Version   int    `json:"version"`
+	Category  int    `json:"category"`
+	Flags     int    `json:"flags"`
+	SetupCode string `json:"setupCode"`
+	SetupID   string `json:"setupId,omitempty"`
+}
+
+// HasFlag reports whether all bits of flag are set in the payload flags.
+func (p SetupPayload) HasFlag(flag int) bool {
+	return p.Flags&flag == flag
+}
+
+// FlagNames returns the names of the transport flags set in the payload.
+func (p SetupPayload) FlagNames() []string {
+	var names []string
+	for _, f := range []struct {
+		flag int
+		name string
+	}{{FlagNFC, "NFC"}, {FlagIP, "IP"}, {FlagBLE, "BLE"}} {
+		if p.HasFlag(f.flag) {
+			names = append(names, f.name)
+		}
+	}
+	return names
+}
+
+// DecodeSetupURI parses an X-HM:// setup URI produced by GenHomeKitSetupURI
+// (or printed on any HomeKit label) back into its fields. The setup ID suffix
+// is optional. The scheme is matched case-insensitively.
+func DecodeSetupURI(uri string) (SetupPayload, error) {
+	uri = strings.TrimSpace(uri)
+	if len(uri) < len(setupURIPrefix) || !strings.EqualFold(uri[:len(setupURIPrefix)], setupURIPrefix) {
+		return SetupPayload{}, fmt.Errorf("invalid setup URI %q: must start with %s", uri, setupURIPrefix)
+	}
+
+	body := strings.ToUpper(uri[len(setupURIPrefix):])
+	if len(body) != 9 && len(body) != 13 {
+		return SetupPayload{}, fmt.Errorf("invalid setup URI %q: expected 9 payload characters and an optional 4-character setup ID", uri)
+	}
+
+	payload := uint64(0)
+	for i, ch := range body[:9] {
+		digit := strings.IndexRune(base36, ch)
+		if digit < 0 {
+			return SetupPayload{}, fmt.Errorf("invalid setup URI %q: character '%c' at position %d is not base36", uri, ch, i+1)
+		}
+		payload = payload*36 + uint64(digit)
+	}
+
+	if payload>>46 != 0 {
+		return SetupPayload{}, fmt.Errorf("invalid setup URI %q: payload exceeds 46 bits", uri)
+	}
+	if payload&0x7FFFFFF > 99999999 {
+		return SetupPayload{}, fmt.Errorf("invalid setup URI %q: setup code exceeds 8 digits", uri)
+	}
+
+	result := SetupPayload{
+		Version:   int(payload>>43) & 0x7,
+		Category:  int(payload>>31) & 0xFF,
+		Flags:     int(payload>>27) & 0xF,
+		SetupCode: FormatSetupCode(fmt.Sprintf("%08d", payload&0x7FFFFFF)),
+	}
+
+	if len(body) == 13 {
+		result.SetupID = body[9:]
+		if err := ValidateSetupID(result.SetupID); err != nil {
+			return SetupPayload{}, fmt.Errorf("invalid setup URI %q: %w", uri, err)
+		}
+	}
+
+	return result, nil
+}
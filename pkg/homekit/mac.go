@@ -0,0 +1,163 @@
+package homekit
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// MAC is a hardware address: 6 bytes (EUI-48) or 8 bytes (EUI-64, used by Thread devices).
+type MAC []byte
+
+// MACStyle selects how a MAC address is rendered as text.
+type MACStyle string
+
+// Supported MAC display styles.
+const (
+	MACStyleColon  MACStyle = "colon"  // AA:BB:CC:DD:EE:FF
+	MACStyleHyphen MACStyle = "hyphen" // AA-BB-CC-DD-EE-FF
+	MACStyleDot    MACStyle = "dot"    // AABB.CCDD.EEFF (Cisco notation)
+	MACStyleBare   MACStyle = "bare"   // AABBCCDDEEFF
+)
+
+// MACStyles lists the supported display styles in documentation order.
+var MACStyles = []MACStyle{MACStyleColon, MACStyleHyphen, MACStyleDot, MACStyleBare}
+
+// ParseMACStyle converts a style name into a MACStyle.
+// An empty name selects MACStyleColon.
+func ParseMACStyle(name string) (MACStyle, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return MACStyleColon, nil
+	}
+	for _, style := range MACStyles {
+		if string(style) == name {
+			return style, nil
+		}
+	}
+	return "", fmt.Errorf("unknown MAC style %q. Expected one of: colon, hyphen, dot, bare", name)
+}
+
+// ParseMAC parses an EUI-48 or EUI-64 address in any common notation:
+//   - bare hexadecimal: AABBCCDDEEFF / AABBCCDDEEFF0011
+//   - colon or hyphen separated octets: AA:BB:CC:DD:EE:FF / aa-bb-cc-dd-ee-ff
+//   - Cisco dotted groups of 4 digits: aabb.ccdd.eeff
+//
+// Parsing is case-insensitive. Failures are returned as *ValidationError.
+func ParseMAC(s string) (MAC, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, newValidationError(FieldMAC, RuleRequired, "MAC address cannot be empty. Expected 12 hexadecimal characters (e.g., AABBCCDDEEFF)")
+	}
+
+	var digits string
+	switch {
+	case strings.ContainsAny(s, ":-"):
+		sep := ":"
+		if strings.Contains(s, "-") {
+			sep = "-"
+		}
+		groups := strings.Split(s, sep)
+		if len(groups) != 6 && len(groups) != 8 {
+			return nil, newValidationError(FieldMAC, RuleFormat, "invalid MAC address format %q. Expected 6 (EUI-48) or 8 (EUI-64) octets separated by '%s'", s, sep)
+		}
+		for _, g := range groups {
+			if len(g) != 2 {
+				return nil, newValidationError(FieldMAC, RuleFormat, "invalid MAC address format %q. Each octet must have 2 hexadecimal characters", s)
+			}
+		}
+		digits = strings.Join(groups, "")
+	case strings.Contains(s, "."):
+		groups := strings.Split(s, ".")
+		if len(groups) != 3 && len(groups) != 4 {
+			return nil, newValidationError(FieldMAC, RuleFormat, "invalid MAC address format %q. Expected 3 (EUI-48) or 4 (EUI-64) groups separated by '.'", s)
+		}
+		for _, g := range groups {
+			if len(g) != 4 {
+				return nil, newValidationError(FieldMAC, RuleFormat, "invalid MAC address format %q. Each group must have 4 hexadecimal characters", s)
+			}
+		}
+		digits = strings.Join(groups, "")
+	default:
+		digits = s
+		if len(digits) != 12 && len(digits) != 16 {
+			return nil, newValidationError(FieldMAC, RuleLength, "invalid MAC address length. Expected 12 (EUI-48) or 16 (EUI-64) hexadecimal characters (e.g., AABBCCDDEEFF)")
+		}
+	}
+
+	for i, r := range digits {
+		if !((r >= '0' && r <= '9') || (r >= 'a' && r <= 'f') || (r >= 'A' && r <= 'F')) {
+			return nil, newValidationError(FieldMAC, RuleCharset, "invalid character '%c' at position %d. MAC address must contain only hexadecimal characters (0-9, A-F)", r, i+1)
+		}
+	}
+
+	addr, err := hex.DecodeString(digits)
+	if err != nil {
+		return nil, newValidationError(FieldMAC, RuleCharset, "invalid MAC address %q: %v", s, err)
+	}
+	return MAC(addr), nil
+}
+
+// String returns the address as uppercase hexadecimal without separators.
+func (m MAC) String() string {
+	return strings.ToUpper(hex.EncodeToString(m))
+}
+
+// Format renders the address in the given style. Unknown styles fall back to MACStyleColon.
+func (m MAC) Format(style MACStyle) string {
+	digits := m.String()
+	switch style {
+	case MACStyleBare:
+		return digits
+	case MACStyleDot:
+		return joinGroups(digits, 4, ".")
+	case MACStyleHyphen:
+		return joinGroups(digits, 2, "-")
+	default:
+		return joinGroups(digits, 2, ":")
+	}
+}
+
+// IsEUI64 reports whether the address is a 64-bit EUI-64.
+func (m MAC) IsEUI64() bool {
+	return len(m) == 8
+}
+
+// IsMulticast reports whether the I/G bit (least significant bit of the first octet) is set.
+// Multicast addresses cannot identify a single device.
+func (m MAC) IsMulticast() bool {
+	return len(m) > 0 && m[0]&0x01 != 0
+}
+
+// IsLocallyAdministered reports whether the U/L bit (second least significant bit
+// of the first octet) is set, meaning the address was not assigned from an IEEE OUI.
+func (m MAC) IsLocallyAdministered() bool {
+	return len(m) > 0 && m[0]&0x02 != 0
+}
+
+// Warnings returns human-readable notes about address bits that are unusual on a
+// device label: multicast addresses, and locally administered addresses when
+// checkLocal is true (pass false when local addresses are intended).
+func (m MAC) Warnings(checkLocal bool) []string {
+	var warnings []string
+	if m.IsMulticast() {
+		warnings = append(warnings, fmt.Sprintf("MAC address %s has the multicast (I/G) bit set and cannot identify a single device", m.Format(MACStyleColon)))
+	}
+	if checkLocal && m.IsLocallyAdministered() {
+		warnings = append(warnings, fmt.Sprintf("MAC address %s is locally administered (U/L bit set), not assigned from an IEEE OUI", m.Format(MACStyleColon)))
+	}
+	return warnings
+}
+
+// joinGroups splits s into groups of size n and joins them with sep.
+func joinGroups(s string, n int, sep string) string {
+	var parts []string
+	for i := 0; i < len(s); i += n {
+		end := i + n
+		if end > len(s) {
+			end = len(s)
+		}
+		parts = append(parts, s[i:end])
+	}
+	return strings.Join(parts, sep)
+}
@@ -0,0 +1,120 @@
+package homekit
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseMAC(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string // bare form; empty when rule is set
+		rule string
+	}{
+		{"AA:BB:CC:DD:EE:FF", "AABBCCDDEEFF", ""},
+		{"aa-bb-cc-dd-ee-ff", "AABBCCDDEEFF", ""},
+		{"aabb.ccdd.eeff", "AABBCCDDEEFF", ""},
+		{"AABBCCDDEEFF", "AABBCCDDEEFF", ""},
+		{" 00:1a:2b:3c:4d:5e ", "001A2B3C4D5E", ""},
+		{"00:11:22:33:44:55:66:77", "0011223344556677", ""},
+		{"00-11-22-33-44-55-66-77", "0011223344556677", ""},
+		{"0011.2233.4455.6677", "0011223344556677", ""},
+		{"0011223344556677", "0011223344556677", ""},
+		{"", "", RuleRequired},
+		{"AA:BB-CC:DD:EE:FF", "", RuleFormat},
+		{"AA:BB:CC.DD:EE:FF", "", RuleFormat},
+		{"AABB.CC:DD.EEFF", "", RuleFormat},
+		{"AA:BB:CC:DD:EE", "", RuleFormat},
+		{"AA:BB:CC:DD:EE:FF:00", "", RuleFormat},
+		{"AAB:BC:CD:DE:EF:F0", "", RuleFormat},
+		{"A:BB:CC:DD:EE:FF", "", RuleFormat},
+		{"AABB.CCDD", "", RuleFormat},
+		{"AAB.BCCDD.EEFF", "", RuleFormat},
+		{"AABBCCDDEE", "", RuleLength},
+		{"AABBCCDDEEFF00", "", RuleLength},
+		{"AA:BB:CC:DD:EE:GG", "", RuleCharset},
+		{"AABBCCDDEEFZ", "", RuleCharset},
+	}
+	for _, tt := range tests {
+		mac, err := ParseMAC(tt.in)
+		if tt.rule == "" {
+			if err != nil {
+				t.Errorf("ParseMAC(%q) = %v, want %s", tt.in, err, tt.want)
+			} else if mac.String() != tt.want {
+				t.Errorf("ParseMAC(%q) = %s, want %s", tt.in, mac, tt.want)
+			}
+			continue
+		}
+		var verr *ValidationError
+		if !errors.As(err, &verr) {
+			t.Errorf("ParseMAC(%q) = %v, want rule %s", tt.in, err, tt.rule)
+			continue
+		}
+		if verr.Field != FieldMAC || verr.Rule != tt.rule {
+			t.Errorf("ParseMAC(%q) field/rule = %s/%s, want %s/%s", tt.in, verr.Field, verr.Rule, FieldMAC, tt.rule)
+		}
+	}
+}
+
+func TestMACFormat(t *testing.T) {
+	mac, err := ParseMAC("0011223344556677")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		style MACStyle
+		want  string
+	}{
+		{MACStyleColon, "00:11:22:33:44:55:66:77"},
+		{MACStyleHyphen, "00-11-22-33-44-55-66-77"},
+		{MACStyleDot, "0011.2233.4455.6677"},
+		{MACStyleBare, "0011223344556677"},
+	}
+	for _, tt := range tests {
+		if got := mac.Format(tt.style); got != tt.want {
+			t.Errorf("Format(%s) = %s, want %s", tt.style, got, tt.want)
+		}
+	}
+	if !mac.IsEUI64() {
+		t.Errorf("IsEUI64() = false for %s", mac)
+	}
+}
+
+func TestMACBits(t *testing.T) {
+	tests := []struct {
+		in               string
+		multicast, local bool
+	}{
+		{"00:1A:2B:3C:4D:5E", false, false},
+		{"01:00:5E:00:00:FB", true, false}, // IPv4 multicast (mDNS)
+		{"02:00:00:00:00:01", false, true},
+		{"03:00:00:00:00:01", true, true},
+		{"FE:FF:FF:FF:FF:FF", false, true},
+		{"FF:FF:FF:FF:FF:FF", true, true}, // broadcast
+	}
+	for _, tt := range tests {
+		mac, err := ParseMAC(tt.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := mac.IsMulticast(); got != tt.multicast {
+			t.Errorf("IsMulticast(%s) = %v, want %v", tt.in, got, tt.multicast)
+		}
+		if got := mac.IsLocallyAdministered(); got != tt.local {
+			t.Errorf("IsLocallyAdministered(%s) = %v, want %v", tt.in, got, tt.local)
+		}
+		want := 0
+		if tt.multicast {
+			want++
+		}
+		if tt.local {
+			want++
+		}
+		if got := len(mac.Warnings(true)); got != want {
+			t.Errorf("Warnings(%s, true) has %d entries, want %d", tt.in, got, want)
+		}
+		if tt.local && len(mac.Warnings(false)) != want-1 {
+			t.Errorf("Warnings(%s, false) still reports the locally administered bit", tt.in)
+		}
+	}
+}
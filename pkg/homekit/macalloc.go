@@ -0,0 +1,325 @@
+package homekit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrMACBlockExhausted is returned when every address of the configured range
+// has already been allocated.
+var ErrMACBlockExhausted = errors.New("MAC address block exhausted")
+
+// MACBlock is a range of addresses sharing a fixed prefix, such as an IEEE
+// assignment: MA-L (/24), MA-M (/28) or MA-S (/36).
+type MACBlock struct {
+	Base      MAC // First address of the block (bits after the prefix are zero)
+	PrefixLen int // Number of fixed leading bits
+}
+
+// ParseMACBlock parses a block written as "<address>/<prefix bits>", for example
+// "70:B3:D5:12:30:00/36" for an MA-S block. Bits after the prefix must be zero.
+// A bare OUI such as "70:B3:D5" is accepted as an MA-L (/24) block.
+func ParseMACBlock(s string) (MACBlock, error) {
+	s = strings.TrimSpace(s)
+	addrPart, lenPart, hasLen := strings.Cut(s, "/")
+
+	if !hasLen {
+		// Bare OUI: 3 octets, MA-L block
+		digits := strings.NewReplacer(":", "", "-", "", ".", "").Replace(addrPart)
+		oui, err := ParseMAC(digits + "000000")
+		if len(digits) != 6 || err != nil {
+			return MACBlock{}, fmt.Errorf("invalid MAC block %q: expected <address>/<prefix bits> or an OUI like 70:B3:D5", s)
+		}
+		if oui.IsMulticast() {
+			return MACBlock{}, fmt.Errorf("invalid MAC block %q: multicast prefix cannot be used for device addresses", s)
+		}
+		return MACBlock{Base: oui, PrefixLen: 24}, nil
+	}
+
+	base, err := ParseMAC(addrPart)
+	if err != nil {
+		return MACBlock{}, fmt.Errorf("invalid MAC block %q: %w", s, err)
+	}
+	prefixLen, err := strconv.Atoi(lenPart)
+	if err != nil || prefixLen < 1 || prefixLen >= len(base)*8 {
+		return MACBlock{}, fmt.Errorf("invalid MAC block %q: prefix length must be between 1 and %d bits", s, len(base)*8-1)
+	}
+
+	block := MACBlock{Base: base, PrefixLen: prefixLen}
+	if block.offsetOf(base) != 0 {
+		return MACBlock{}, fmt.Errorf("invalid MAC block %q: bits after the /%d prefix must be zero", s, prefixLen)
+	}
+	if base.IsMulticast() {
+		return MACBlock{}, fmt.Errorf("invalid MAC block %q: multicast prefix cannot be used for device addresses", s)
+	}
+	return block, nil
+}
+
+// String returns the block in the notation accepted by ParseMACBlock.
+func (b MACBlock) String() string {
+	return fmt.Sprintf("%s/%d", b.Base.Format(MACStyleColon), b.PrefixLen)
+}
+
+// Size returns the number of addresses in the block.
+func (b MACBlock) Size() uint64 {
+	return uint64(1) << uint(len(b.Base)*8-b.PrefixLen)
+}
+
+// Address returns the address at the given offset from the start of the block.
+func (b MACBlock) Address(offset uint64) MAC {
+	value := b.value(b.Base) + offset
+	addr := make(MAC, len(b.Base))
+	for i := len(addr) - 1; i >= 0; i-- {
+		addr[i] = byte(value)
+		value >>= 8
+	}
+	return addr
+}
+
+// Contains reports whether addr belongs to the block.
+func (b MACBlock) Contains(addr MAC) bool {
+	if len(addr) != len(b.Base) {
+		return false
+	}
+	hostBits := uint(len(b.Base)*8 - b.PrefixLen)
+	return b.value(addr)>>hostBits == b.value(b.Base)>>hostBits
+}
+
+// value returns the address as an unsigned integer.
+func (b MACBlock) value(addr MAC) uint64 {
+	var v uint64
+	for _, octet := range addr {
+		v = v<<8 | uint64(octet)
+	}
+	return v
+}
+
+// offsetOf returns the offset of addr from the start of the block (host bits only).
+func (b MACBlock) offsetOf(addr MAC) uint64 {
+	return b.value(addr) & (b.Size() - 1)
+}
+
+// MACAllocMode selects how addresses are picked inside a block.
+type MACAllocMode string
+
+// Supported allocation modes.
+const (
+	MACAllocSequential MACAllocMode = "sequential" // Lowest free offset first
+	MACAllocRandom     MACAllocMode = "random"     // Uniformly random free offset
+)
+
+// ParseMACAllocMode converts a mode name into a MACAllocMode.
+// An empty name selects MACAllocSequential.
+func ParseMACAllocMode(name string) (MACAllocMode, error) {
+	switch MACAllocMode(strings.ToLower(strings.TrimSpace(name))) {
+	case "", MACAllocSequential:
+		return MACAllocSequential, nil
+	case MACAllocRandom:
+		return MACAllocRandom, nil
+	}
+	return "", fmt.Errorf("unknown MAC allocation mode %q. Expected sequential or random", name)
+}
+
+// MACAllocator hands out addresses from a MACBlock and records every allocation
+// in a JSON state file, so addresses are never reused across runs.
+type MACAllocator struct {
+	Block     MACBlock
+	First     uint64       // First usable offset in the block (inclusive)
+	Last      uint64       // Last usable offset in the block (inclusive)
+	Mode      MACAllocMode // Sequential or random assignment
+	StatePath string       // JSON file tracking allocated addresses
+}
+
+// NewMACAllocator returns an allocator covering the whole block.
+func NewMACAllocator(block MACBlock, mode MACAllocMode, statePath string) *MACAllocator {
+	return &MACAllocator{Block: block, First: 0, Last: block.Size() - 1, Mode: mode, StatePath: statePath}
+}
+
+// SetRange restricts allocation to offsets "START-END" within the block.
+// Offsets are decimal or 0x-prefixed hexadecimal, for example "0x100-0x1FF".
+func (a *MACAllocator) SetRange(spec string) error {
+	startStr, endStr, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return fmt.Errorf("invalid MAC range %q: expected START-END", spec)
+	}
+	first, err := strconv.ParseUint(strings.TrimSpace(startStr), 0, 64)
+	if err != nil {
+		return fmt.Errorf("invalid MAC range start %q: %w", startStr, err)
+	}
+	last, err := strconv.ParseUint(strings.TrimSpace(endStr), 0, 64)
+	if err != nil {
+		return fmt.Errorf("invalid MAC range end %q: %w", endStr, err)
+	}
+	if first > last || last >= a.Block.Size() {
+		return fmt.Errorf("invalid MAC range %q: offsets must satisfy START <= END < %d", spec, a.Block.Size())
+	}
+	a.First, a.Last = first, last
+	return nil
+}
+
+// macAllocState is the on-disk format of the allocation state file.
+type macAllocState struct {
+	Block     string   `json:"block"`
+	Allocated []string `json:"allocated"`
+}
+
+// Allocate reserves the next free address and records it in the state file.
+// It returns ErrMACBlockExhausted when the configured range is full.
+func (a *MACAllocator) Allocate() (MAC, error) {
+	// The lock file lives next to the state file, so its directory must exist first
+	if err := os.MkdirAll(filepath.Dir(a.StatePath), 0755); err != nil {
+		return nil, fmt.Errorf("error creating MAC allocation state directory: %w", err)
+	}
+	unlock, err := lockFile(a.StatePath + ".lock")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	state, used, err := a.loadState()
+	if err != nil {
+		return nil, err
+	}
+
+	total := a.Last - a.First + 1
+	var inRange uint64
+	for offset := range used {
+		if offset >= a.First && offset <= a.Last {
+			inRange++
+		}
+	}
+	if inRange >= total {
+		return nil, fmt.Errorf("%w: all %d addresses of %s (offsets %d-%d) are allocated", ErrMACBlockExhausted, total, a.Block, a.First, a.Last)
+	}
+
+	offset, found := a.First, false
+	if a.Mode == MACAllocRandom {
+		// Random probing first; fall back to a scan from a random start when the range is dense
+		for i := 0; i < 64 && !found; i++ {
+			offset = a.First + rand.Uint64()%total
+			_, taken := used[offset]
+			found = !taken
+		}
+		if !found {
+			offset = a.First + rand.Uint64()%total
+		}
+	}
+	for !found {
+		if _, taken := used[offset]; !taken {
+			found = true
+		} else if offset == a.Last {
+			offset = a.First
+		} else {
+			offset++
+		}
+	}
+
+	addr := a.Block.Address(offset)
+	state.Allocated = append(state.Allocated, addr.String())
+	sort.Strings(state.Allocated)
+	if err := a.saveState(state); err != nil {
+		return nil, err
+	}
+	return addr, nil
+}
+
+// loadState reads the state file, returning an empty state if it does not exist yet.
+// The returned set holds the block offsets already allocated.
+func (a *MACAllocator) loadState() (macAllocState, map[uint64]struct{}, error) {
+	state := macAllocState{Block: a.Block.String()}
+	used := make(map[uint64]struct{})
+
+	data, err := os.ReadFile(a.StatePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, used, nil
+	}
+	if err != nil {
+		return state, nil, fmt.Errorf("error reading MAC allocation state: %w", err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, nil, fmt.Errorf("error parsing MAC allocation state %s: %w", a.StatePath, err)
+	}
+	if state.Block != a.Block.String() {
+		return state, nil, fmt.Errorf("MAC allocation state %s belongs to block %s, not %s", a.StatePath, state.Block, a.Block)
+	}
+
+	for _, s := range state.Allocated {
+		addr, err := ParseMAC(s)
+		if err != nil || !a.Block.Contains(addr) {
+			return state, nil, fmt.Errorf("MAC allocation state %s contains invalid address %q", a.StatePath, s)
+		}
+		used[a.Block.offsetOf(addr)] = struct{}{}
+	}
+	return state, used, nil
+}
+
+// saveState writes the state file atomically (temporary file and rename).
+func (a *MACAllocator) saveState(state macAllocState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding MAC allocation state: %w", err)
+	}
+
+	dir := filepath.Dir(a.StatePath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("error creating MAC allocation state directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(a.StatePath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("error writing MAC allocation state: %w", err)
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("error writing MAC allocation state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("error writing MAC allocation state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), a.StatePath); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("error writing MAC allocation state: %w", err)
+	}
+	return nil
+}
+
+// lockFile takes an exclusive lock by creating path, retrying for a few seconds
+// while another process holds it. The returned function releases the lock.
+func lockFile(path string) (func(), error) {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("error locking %s: %w", path, err)
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for lock %s (remove it if no other process is running)", path)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// GenerateLocalMAC generates a random locally administered unicast EUI-48 address:
+// the U/L bit is set and the I/G bit is cleared, so it cannot clash with
+// vendor-assigned addresses. Returned as 12 uppercase hexadecimal characters.
+func GenerateLocalMAC() string {
+	addr := make(MAC, 6)
+	for i := range addr {
+		addr[i] = byte(rand.Intn(256))
+	}
+	addr[0] = (addr[0] | 0x02) &^ 0x01
+	return addr.String()
+}
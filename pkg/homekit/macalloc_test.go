@@ -0,0 +1,156 @@
+package homekit
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseMACBlock(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string // empty for invalid blocks
+		size uint64
+	}{
+		{"70:B3:D5:12:30:00/36", "70:B3:D5:12:30:00/36", 4096},
+		{"70B3D5123000/36", "70:B3:D5:12:30:00/36", 4096},
+		{"70:B3:D5", "70:B3:D5:00:00:00/24", 1 << 24},
+		{"70-B3-D5-00-00-00/28", "70:B3:D5:00:00:00/28", 1 << 20},
+		{"70:B3:D5:12:34:00/36", "", 0}, // host bits not zero
+		{"70:B3:D5:12:30:01/36", "", 0}, // host bits not zero
+		{"71:B3:D5:00:00:00/24", "", 0}, // multicast
+		{"71:B3:D5", "", 0},             // multicast OUI
+		{"70:B3:D5:12:30:00/48", "", 0}, // no host bits
+		{"70:B3:D5:12:30:00/0", "", 0},
+		{"70:B3:D5:12:30:00/x", "", 0},
+		{"70:B3", "", 0},
+	}
+	for _, tt := range tests {
+		block, err := ParseMACBlock(tt.in)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("ParseMACBlock(%q) = %s, want an error", tt.in, block)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseMACBlock(%q): %v", tt.in, err)
+			continue
+		}
+		if block.String() != tt.want || block.Size() != tt.size {
+			t.Errorf("ParseMACBlock(%q) = %s with %d addresses, want %s with %d", tt.in, block, block.Size(), tt.want, tt.size)
+		}
+	}
+}
+
+func TestMACAllocatorSetRange(t *testing.T) {
+	block, _ := ParseMACBlock("70:B3:D5:12:30:00/36")
+	tests := []struct {
+		spec        string
+		first, last uint64
+		valid       bool
+	}{
+		{"0x100-0x1FF", 0x100, 0x1FF, true},
+		{"10-20", 10, 20, true},
+		{" 0 - 4095 ", 0, 4095, true},
+		{"0-4096", 0, 0, false}, // past the end of the block
+		{"0x200-0x100", 0, 0, false},
+		{"0x100", 0, 0, false},
+		{"a-b", 0, 0, false},
+	}
+	for _, tt := range tests {
+		a := NewMACAllocator(block, MACAllocSequential, "")
+		err := a.SetRange(tt.spec)
+		if (err == nil) != tt.valid {
+			t.Errorf("SetRange(%q) = %v, want valid %v", tt.spec, err, tt.valid)
+			continue
+		}
+		if tt.valid && (a.First != tt.first || a.Last != tt.last) {
+			t.Errorf("SetRange(%q) = %d-%d, want %d-%d", tt.spec, a.First, a.Last, tt.first, tt.last)
+		}
+	}
+}
+
+func TestMACAllocatorSequential(t *testing.T) {
+	block, _ := ParseMACBlock("70:B3:D5:12:30:00/36")
+	state := filepath.Join(t.TempDir(), "state", "mac-allocations.json")
+	a := NewMACAllocator(block, MACAllocSequential, state)
+	if err := a.SetRange("0x100-0x102"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"70B3D5123100", "70B3D5123101", "70B3D5123102"} {
+		// A fresh allocator per run, as separate invocations of the CLI
+		next := *a
+		addr, err := next.Allocate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if addr.String() != want {
+			t.Errorf("Allocate() = %s, want %s", addr, want)
+		}
+	}
+	if _, err := a.Allocate(); !errors.Is(err, ErrMACBlockExhausted) {
+		t.Errorf("Allocate() on a full range = %v, want ErrMACBlockExhausted", err)
+	}
+	if _, err := os.Stat(state + ".lock"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("lock file left behind: %v", err)
+	}
+
+	// The rest of the block is still available
+	a.Last = 0x103
+	if addr, err := a.Allocate(); err != nil || addr.String() != "70B3D5123103" {
+		t.Errorf("Allocate() after widening the range = %v, %v, want 70B3D5123103", addr, err)
+	}
+}
+
+func TestMACAllocatorBlockMismatch(t *testing.T) {
+	state := filepath.Join(t.TempDir(), "mac-allocations.json")
+	block, _ := ParseMACBlock("70:B3:D5:12:30:00/36")
+	if _, err := NewMACAllocator(block, MACAllocSequential, state).Allocate(); err != nil {
+		t.Fatal(err)
+	}
+	other, _ := ParseMACBlock("70:B3:D5:12:40:00/36")
+	_, err := NewMACAllocator(other, MACAllocSequential, state).Allocate()
+	if err == nil || errors.Is(err, ErrMACBlockExhausted) {
+		t.Errorf("Allocate() with another block's state file = %v, want a block mismatch error", err)
+	}
+}
+
+func TestMACAllocatorRandom(t *testing.T) {
+	block, _ := ParseMACBlock("70:B3:D5:12:30:00/36")
+	a := NewMACAllocator(block, MACAllocRandom, filepath.Join(t.TempDir(), "mac-allocations.json"))
+	if err := a.SetRange("0x10-0x2F"); err != nil {
+		t.Fatal(err)
+	}
+	seen := map[string]bool{}
+	for i := 0; i < 32; i++ {
+		addr, err := a.Allocate()
+		if err != nil {
+			t.Fatalf("allocation %d: %v", i+1, err)
+		}
+		if seen[addr.String()] {
+			t.Fatalf("Allocate() returned %s twice", addr)
+		}
+		if offset := block.offsetOf(addr); offset < 0x10 || offset > 0x2F {
+			t.Errorf("Allocate() = %s, offset 0x%X outside the range", addr, offset)
+		}
+		seen[addr.String()] = true
+	}
+	if _, err := a.Allocate(); !errors.Is(err, ErrMACBlockExhausted) {
+		t.Errorf("Allocate() on a full range = %v, want ErrMACBlockExhausted", err)
+	}
+}
+
+func TestGenerateLocalMAC(t *testing.T) {
+	for i := 0; i < 1000; i++ {
+		addr, err := ParseMAC(GenerateLocalMAC())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !addr.IsLocallyAdministered() || addr.IsMulticast() {
+			t.Fatalf("GenerateLocalMAC() = %s, want a locally administered unicast address", addr)
+		}
+	}
+}
@@ -0,0 +1,369 @@
+package homekit
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// base38 contains the characters used for Matter QR payload encoding
+const base38 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ-."
+
+// matterQRPrefix is the prefix of Matter onboarding QR payloads.
+const matterQRPrefix = "MT:"
+
+// MatterFlow is the commissioning flow of a Matter device.
+type MatterFlow int
+
+// Matter commissioning flows.
+const (
+	MatterFlowStandard   MatterFlow = 0 // Commissionable as soon as it is powered on
+	MatterFlowUserIntent MatterFlow = 1 // Needs a user action (e.g. a button press) first
+	MatterFlowCustom     MatterFlow = 2 // Needs vendor-specific steps
+)
+
+var matterFlowNames = map[MatterFlow]string{
+	MatterFlowStandard:   "standard",
+	MatterFlowUserIntent: "user-intent",
+	MatterFlowCustom:     "custom",
+}
+
+// ParseMatterFlow converts a flow name (standard, user-intent or custom) into
+// a MatterFlow. An empty name selects MatterFlowStandard.
+func ParseMatterFlow(name string) (MatterFlow, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return MatterFlowStandard, nil
+	}
+	for flow, n := range matterFlowNames {
+		if n == name {
+			return flow, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown commissioning flow %q. Expected standard, user-intent or custom", name)
+}
+
+// String returns the flow name.
+func (f MatterFlow) String() string {
+	if name, ok := matterFlowNames[f]; ok {
+		return name
+	}
+	return fmt.Sprintf("flow(%d)", int(f))
+}
+
+// Matter discovery capabilities (8 bits) describing how a device can be
+// found for commissioning.
+const (
+	MatterDiscoverySoftAP    = 1 << 0 // Wi-Fi soft access point
+	MatterDiscoveryBLE       = 1 << 1 // Bluetooth LE
+	MatterDiscoveryOnNetwork = 1 << 2 // Already on the IP network
+)
+
+var matterDiscoveryNames = []struct {
+	flag int
+	name string
+}{{MatterDiscoverySoftAP, "softap"}, {MatterDiscoveryBLE, "ble"}, {MatterDiscoveryOnNetwork, "on-network"}}
+
+// ParseMatterDiscovery converts discovery capability names (softap, ble,
+// on-network) into a bit mask.
+func ParseMatterDiscovery(names []string) (int, error) {
+	mask := 0
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		found := false
+		for _, d := range matterDiscoveryNames {
+			if d.name == name {
+				mask |= d.flag
+				found = true
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("unknown discovery capability %q. Expected softap, ble or on-network", name)
+		}
+	}
+	return mask, nil
+}
+
+// Matter passcode and discriminator limits.
+const (
+	MaxMatterPasscode      = 99999998
+	MaxMatterDiscriminator = 0xFFF
+)
+
+// invalidMatterPasscodes are the trivial passcodes the Matter specification
+// forbids.
+var invalidMatterPasscodes = map[int]bool{
+	0: true, 11111111: true, 22222222: true, 33333333: true, 44444444: true,
+	55555555: true, 66666666: true, 77777777: true, 88888888: true, 99999999: true,
+	12345678: true, 87654321: true,
+}
+
+// ValidateMatterPasscode checks a Matter setup passcode: it must be between 1
+// and 99999998 and not one of the trivial values the specification forbids.
+func ValidateMatterPasscode(passcode int) error {
+	if passcode < 1 || passcode > MaxMatterPasscode {
+		return fmt.Errorf("passcode %d is out of range (1-%d)", passcode, MaxMatterPasscode)
+	}
+	if invalidMatterPasscodes[passcode] {
+		return fmt.Errorf("passcode %08d is not allowed by the Matter specification", passcode)
+	}
+	return nil
+}
+
+// GenerateMatterPasscode generates a random valid Matter setup passcode.
+func GenerateMatterPasscode() int {
+	for {
+		passcode := 1 + rand.Intn(MaxMatterPasscode)
+		if ValidateMatterPasscode(passcode) == nil {
+			return passcode
+		}
+	}
+}
+
+// GenerateMatterDiscriminator generates a random 12-bit discriminator.
+func GenerateMatterDiscriminator() int {
+	return rand.Intn(MaxMatterDiscriminator + 1)
+}
+
+// MatterPayload is the onboarding payload of a Matter device.
+type MatterPayload struct {
+	Version       int        `json:"version"`
+	VendorID      uint16     `json:"vendorId"`
+	ProductID     uint16     `json:"productId"`
+	Flow          MatterFlow `json:"flow"`
+	Discovery     int        `json:"discovery"`
+	Discriminator int        `json:"discriminator"`
+	Passcode      int        `json:"passcode"`
+}
+
+// Validate checks the payload fields against the Matter specification.
+func (p MatterPayload) Validate() error {
+	if p.Version != 0 {
+		return fmt.Errorf("unsupported Matter payload version %d", p.Version)
+	}
+	if _, ok := matterFlowNames[p.Flow]; !ok {
+		return fmt.Errorf("unknown commissioning flow %d", int(p.Flow))
+	}
+	if p.Discovery < 0 || p.Discovery > 0xFF {
+		return fmt.Errorf("discovery capabilities 0x%X do not fit in 8 bits", p.Discovery)
+	}
+	if p.Discriminator < 0 || p.Discriminator > MaxMatterDiscriminator {
+		return fmt.Errorf("discriminator %d is out of range (0-%d)", p.Discriminator, MaxMatterDiscriminator)
+	}
+	return ValidateMatterPasscode(p.Passcode)
+}
+
+// DiscoveryNames returns the names of the discovery capabilities set in the payload.
+func (p MatterPayload) DiscoveryNames() []string {
+	var names []string
+	for _, d := range matterDiscoveryNames {
+		if p.Discovery&d.flag != 0 {
+			names = append(names, d.name)
+		}
+	}
+	return names
+}
+
+// QRCode returns the MT: onboarding payload encoded in the Matter QR code.
+//
+// The fields are packed least significant bit first into 88 bits:
+//   - version (3 bits)
+//   - vendor ID (16 bits)
+//   - product ID (16 bits)
+//   - commissioning flow (2 bits)
+//   - discovery capabilities (8 bits)
+//   - discriminator (12 bits)
+//   - passcode (27 bits)
+//   - padding (4 bits)
+//
+// The 11 bytes are then base38 encoded: 5 characters per 3 bytes and 4
+// characters for the last 2.
+func (p MatterPayload) QRCode() (string, error) {
+	if err := p.Validate(); err != nil {
+		return "", err
+	}
+
+	var data [11]byte
+	offset := 0
+	put := func(value uint64, bits int) {
+		for i := 0; i < bits; i++ {
+			if value>>i&1 == 1 {
+				data[offset/8] |= 1 << (offset % 8)
+			}
+			offset++
+		}
+	}
+	put(uint64(p.Version), 3)
+	put(uint64(p.VendorID), 16)
+	put(uint64(p.ProductID), 16)
+	put(uint64(p.Flow), 2)
+	put(uint64(p.Discovery), 8)
+	put(uint64(p.Discriminator), 12)
+	put(uint64(p.Passcode), 27)
+
+	var sb strings.Builder
+	sb.WriteString(matterQRPrefix)
+	for i := 0; i < len(data); i += 3 {
+		chunk := data[i:min(i+3, len(data))]
+		value, chars := 0, []int{0, 2, 4, 5}[len(chunk)]
+		for j, b := range chunk {
+			value |= int(b) << (8 * j)
+		}
+		for j := 0; j < chars; j++ {
+			sb.WriteByte(base38[value%38])
+			value /= 38
+		}
+	}
+	return sb.String(), nil
+}
+
+// DecodeMatterQRCode parses an MT: onboarding payload. See MatterPayload.QRCode
+// for the layout.
+func DecodeMatterQRCode(payload string) (MatterPayload, error) {
+	if !strings.HasPrefix(payload, matterQRPrefix) {
+		return MatterPayload{}, fmt.Errorf("Matter payload %q does not start with %s", payload, matterQRPrefix)
+	}
+	encoded := payload[len(matterQRPrefix):]
+	if len(encoded) != 19 {
+		return MatterPayload{}, fmt.Errorf("Matter payload %q has %d characters after %s, expected 19", payload, len(encoded), matterQRPrefix)
+	}
+
+	var data []byte
+	for i := 0; i < len(encoded); i += 5 {
+		chunk := encoded[i:min(i+5, len(encoded))]
+		value := 0
+		for j := len(chunk) - 1; j >= 0; j-- {
+			digit := strings.IndexByte(base38, chunk[j])
+			if digit < 0 {
+				return MatterPayload{}, fmt.Errorf("Matter payload %q contains invalid character %q", payload, chunk[j])
+			}
+			value = value*38 + digit
+		}
+		bytes := map[int]int{5: 3, 4: 2, 2: 1}[len(chunk)]
+		for j := 0; j < bytes; j++ {
+			data = append(data, byte(value>>(8*j)))
+		}
+	}
+
+	offset := 0
+	get := func(bits int) int {
+		value := 0
+		for i := 0; i < bits; i++ {
+			if data[offset/8]>>(offset%8)&1 == 1 {
+				value |= 1 << i
+			}
+			offset++
+		}
+		return value
+	}
+	p := MatterPayload{Version: get(3)}
+	p.VendorID = uint16(get(16))
+	p.ProductID = uint16(get(16))
+	p.Flow = MatterFlow(get(2))
+	p.Discovery = get(8)
+	p.Discriminator = get(12)
+	p.Passcode = get(27)
+	return p, nil
+}
+
+// ManualCode returns the manual pairing code: 11 digits, or 21 digits with
+// the vendor and product ID when the commissioning flow is not standard.
+//
+// The digits are the top 2 bits of the short (4-bit) discriminator with a
+// flag for the long form (1 digit), the rest of the short discriminator with
+// the low 14 passcode bits (5 digits), the high 13 passcode bits (4 digits),
+// for the long form the vendor and product ID (5 digits each), and a Verhoeff
+// check digit.
+func (p MatterPayload) ManualCode() (string, error) {
+	if err := p.Validate(); err != nil {
+		return "", err
+	}
+
+	long := p.Flow != MatterFlowStandard
+	shortDiscriminator := p.Discriminator >> 8
+	first := shortDiscriminator >> 2
+	if long {
+		first |= 1 << 2
+	}
+	code := fmt.Sprintf("%d%05d%04d", first, (shortDiscriminator&0x3)<<14|p.Passcode&0x3FFF, p.Passcode>>14)
+	if long {
+		code += fmt.Sprintf("%05d%05d", p.VendorID, p.ProductID)
+	}
+	return code + string(verhoeffCheckDigit(code)), nil
+}
+
+// FormatMatterManualCode groups a manual pairing code for printing:
+// XXXX-XXX-XXXX, or XXXX-XXX-XXXX-XXXXX-XXXXX for the 21-digit form.
+// Other input is returned unchanged.
+func FormatMatterManualCode(code string) string {
+	var groups []int
+	switch len(code) {
+	case 11:
+		groups = []int{4, 3, 4}
+	case 21:
+		groups = []int{4, 3, 4, 5, 5}
+	default:
+		return code
+	}
+	parts := make([]string, 0, len(groups))
+	for _, n := range groups {
+		parts = append(parts, code[:n])
+		code = code[n:]
+	}
+	return strings.Join(parts, "-")
+}
+
+// Verhoeff dihedral group multiplication and permutation tables
+var (
+	verhoeffD = [10][10]byte{
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+		{1, 2, 3, 4, 0, 6, 7, 8, 9, 5},
+		{2, 3, 4, 0, 1, 7, 8, 9, 5, 6},
+		{3, 4, 0, 1, 2, 8, 9, 5, 6, 7},
+		{4, 0, 1, 2, 3, 9, 5, 6, 7, 8},
+		{5, 9, 8, 7, 6, 0, 4, 3, 2, 1},
+		{6, 5, 9, 8, 7, 1, 0, 4, 3, 2},
+		{7, 6, 5, 9, 8, 2, 1, 0, 4, 3},
+		{8, 7, 6, 5, 9, 3, 2, 1, 0, 4},
+		{9, 8, 7, 6, 5, 4, 3, 2, 1, 0},
+	}
+	verhoeffP = [8][10]byte{
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+		{1, 5, 7, 6, 2, 8, 3, 0, 9, 4},
+		{5, 8, 0, 3, 7, 9, 6, 1, 4, 2},
+		{8, 9, 1, 6, 0, 4, 3, 5, 2, 7},
+		{9, 4, 5, 3, 1, 2, 6, 8, 7, 0},
+		{4, 2, 8, 6, 5, 7, 3, 9, 0, 1},
+		{2, 7, 9, 3, 8, 0, 6, 4, 1, 5},
+		{7, 0, 4, 6, 9, 1, 3, 2, 5, 8},
+	}
+	verhoeffInv = [10]byte{0, 4, 3, 2, 1, 5, 6, 7, 8, 9}
+)
+
+// verhoeffCheckDigit returns the Verhoeff check digit of a decimal string.
+func verhoeffCheckDigit(digits string) byte {
+	c := byte(0)
+	for i := 0; i < len(digits); i++ {
+		d := digits[len(digits)-1-i] - '0'
+		c = verhoeffD[c][verhoeffP[(i+1)%8][d]]
+	}
+	return '0' + verhoeffInv[c]
+}
+
+// ValidateMatterManualCode checks the length and Verhoeff check digit of a
+// manual pairing code. Dashes and spaces are ignored.
+func ValidateMatterManualCode(code string) error {
+	digits := strings.NewReplacer("-", "", " ", "").Replace(code)
+	if len(digits) != 11 && len(digits) != 21 {
+		return fmt.Errorf("manual pairing code %q must have 11 or 21 digits", code)
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return fmt.Errorf("manual pairing code %q must contain only digits", code)
+		}
+	}
+	if verhoeffCheckDigit(digits[:len(digits)-1]) != digits[len(digits)-1] {
+		return fmt.Errorf("manual pairing code %q has an invalid check digit", code)
+	}
+	return nil
+}
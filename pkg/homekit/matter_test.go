@@ -0,0 +1,194 @@
+package homekit
+
+import "testing"
+
+// matterTestPayload is the example device of the Matter specification and
+// the connectedhomeip test vectors.
+var matterTestPayload = MatterPayload{
+	VendorID:      0xFFF1,
+	ProductID:     0x8000,
+	Flow:          MatterFlowStandard,
+	Discovery:     MatterDiscoveryBLE,
+	Discriminator: 3840,
+	Passcode:      20202021,
+}
+
+func TestMatterQRCode(t *testing.T) {
+	got, err := matterTestPayload.QRCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "MT:Y.K9042C00KA0648G00"; got != want {
+		t.Errorf("QRCode() = %s, want %s", got, want)
+	}
+}
+
+func TestDecodeMatterQRCode(t *testing.T) {
+	got, err := DecodeMatterQRCode("MT:Y.K9042C00KA0648G00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != matterTestPayload {
+		t.Errorf("DecodeMatterQRCode() = %+v, want %+v", got, matterTestPayload)
+	}
+
+	for _, payload := range []string{
+		"Y.K9042C00KA0648G00",     // no prefix
+		"MT:Y.K9042C00KA0648G0",   // too short
+		"MT:Y.K9042C00KA0648G000", // too long
+		"MT:Y.K9042C00KA0648g00",  // lower case is not base38
+	} {
+		if _, err := DecodeMatterQRCode(payload); err == nil {
+			t.Errorf("DecodeMatterQRCode(%q) accepted an invalid payload", payload)
+		}
+	}
+}
+
+func TestMatterQRCodeRoundTrip(t *testing.T) {
+	payloads := []MatterPayload{
+		matterTestPayload,
+		{VendorID: 0, ProductID: 0, Flow: MatterFlowStandard, Discovery: 0, Discriminator: 0, Passcode: 1},
+		{VendorID: 0xFFFF, ProductID: 0xFFFF, Flow: MatterFlowCustom, Discovery: 0xFF, Discriminator: MaxMatterDiscriminator, Passcode: MaxMatterPasscode},
+		{VendorID: 0x1234, ProductID: 0x5678, Flow: MatterFlowUserIntent, Discovery: MatterDiscoverySoftAP | MatterDiscoveryOnNetwork, Discriminator: 0xABC, Passcode: 34567890},
+	}
+	for _, p := range payloads {
+		code, err := p.QRCode()
+		if err != nil {
+			t.Errorf("QRCode(%+v): %v", p, err)
+			continue
+		}
+		got, err := DecodeMatterQRCode(code)
+		if err != nil {
+			t.Errorf("DecodeMatterQRCode(%q): %v", code, err)
+			continue
+		}
+		if got != p {
+			t.Errorf("DecodeMatterQRCode(%q) = %+v, want %+v", code, got, p)
+		}
+	}
+}
+
+func TestMatterManualCode(t *testing.T) {
+	long := matterTestPayload
+	long.Flow = MatterFlowUserIntent
+	tests := []struct {
+		payload MatterPayload
+		want    string
+	}{
+		{matterTestPayload, "34970112332"},
+		{long, "749701123365521327687"},
+	}
+	for _, tt := range tests {
+		got, err := tt.payload.ManualCode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("ManualCode(%v flow) = %s, want %s", tt.payload.Flow, got, tt.want)
+		}
+		if err := ValidateMatterManualCode(got); err != nil {
+			t.Errorf("ValidateMatterManualCode(%q) = %v, want nil", got, err)
+		}
+	}
+}
+
+func TestFormatMatterManualCode(t *testing.T) {
+	tests := []struct{ code, want string }{
+		{"34970112332", "3497-011-2332"},
+		{"749701123365521327687", "7497-011-2336-55213-27687"},
+		{"1234", "1234"},
+	}
+	for _, tt := range tests {
+		if got := FormatMatterManualCode(tt.code); got != tt.want {
+			t.Errorf("FormatMatterManualCode(%q) = %s, want %s", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestVerhoeffCheckDigit(t *testing.T) {
+	tests := []struct {
+		digits string
+		want   byte
+	}{
+		{"236", '3'},
+		{"12345", '1'},
+		{"142857", '0'},
+		{"3497011233", '2'},
+	}
+	for _, tt := range tests {
+		if got := verhoeffCheckDigit(tt.digits); got != tt.want {
+			t.Errorf("verhoeffCheckDigit(%q) = %c, want %c", tt.digits, got, tt.want)
+		}
+	}
+}
+
+func TestValidateMatterManualCode(t *testing.T) {
+	tests := []struct {
+		code  string
+		valid bool
+	}{
+		{"34970112332", true},
+		{"3497-011-2332", true},
+		{"3497 011 2332", true},
+		{"749701123365521327687", true},
+		{"34970112333", false}, // wrong check digit
+		{"34970112323", false}, // transposed digits
+		{"3497011233", false},  // too short
+		{"3497O112332", false}, // letter O
+	}
+	for _, tt := range tests {
+		if err := ValidateMatterManualCode(tt.code); (err == nil) != tt.valid {
+			t.Errorf("ValidateMatterManualCode(%q) = %v, want valid %v", tt.code, err, tt.valid)
+		}
+	}
+}
+
+func TestValidateMatterPasscode(t *testing.T) {
+	tests := []struct {
+		passcode int
+		valid    bool
+	}{
+		{20202021, true},
+		{1, true},
+		{MaxMatterPasscode, true},
+		{0, false},
+		{-1, false},
+		{99999999, false},
+		{11111111, false},
+		{12345678, false},
+		{87654321, false},
+	}
+	for _, tt := range tests {
+		if err := ValidateMatterPasscode(tt.passcode); (err == nil) != tt.valid {
+			t.Errorf("ValidateMatterPasscode(%d) = %v, want valid %v", tt.passcode, err, tt.valid)
+		}
+	}
+	for i := 0; i < 1000; i++ {
+		if p := GenerateMatterPasscode(); ValidateMatterPasscode(p) != nil {
+			t.Fatalf("GenerateMatterPasscode() = %d, which is not valid", p)
+		}
+	}
+}
+
+func TestMatterPayloadValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*MatterPayload)
+	}{
+		{"version", func(p *MatterPayload) { p.Version = 1 }},
+		{"flow", func(p *MatterPayload) { p.Flow = 3 }},
+		{"discovery", func(p *MatterPayload) { p.Discovery = 0x100 }},
+		{"discriminator", func(p *MatterPayload) { p.Discriminator = MaxMatterDiscriminator + 1 }},
+		{"passcode", func(p *MatterPayload) { p.Passcode = 12345678 }},
+	}
+	for _, tt := range tests {
+		p := matterTestPayload
+		tt.modify(&p)
+		if err := p.Validate(); err == nil {
+			t.Errorf("%s: Validate accepted an invalid payload", tt.name)
+		}
+		if _, err := p.QRCode(); err == nil {
+			t.Errorf("%s: QRCode encoded an invalid payload", tt.name)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+// Package homekit provides the HomeKit pairing data model shared by the CLI,
+// the WASM build and external Go programs: setup codes, setup IDs, MAC
+// addresses and categories, with parsing, validation, generation and
+// setup URI encoding.
+//
+// Label rendering is not part of this package.
+package homekit
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// SetupInfo holds the pairing data printed on a HomeKit label.
+// Values produced by this package are normalized: the setup code is formatted
+// as XXX-XX-XXX, the setup ID is uppercase and the MAC address is uppercase
+// hexadecimal without separators (12 characters for EUI-48, 16 for EUI-64).
+type SetupInfo struct {
+	Category  int    `json:"category"`
+	SetupCode string `json:"setupCode"`
+	SetupID   string `json:"setupId"`
+	MAC       string `json:"mac"`
+}
+
+// NewSetupInfo returns a SetupInfo for the given category with a random
+// setup code, setup ID and MAC address.
+func NewSetupInfo(category int) SetupInfo {
+	return SetupInfo{
+		Category:  category,
+		SetupCode: GenerateHomeKitSetupCode(),
+		SetupID:   GenerateSetupID(),
+		MAC:       GenerateMAC(),
+	}
+}
+
+// ParseSetupInfo normalizes and validates raw user input.
+// It returns the first *ValidationError found.
+func ParseSetupInfo(category int, setupCode, setupID, mac string) (SetupInfo, error) {
+	if err := ValidateCategory(category); err != nil {
+		return SetupInfo{}, err
+	}
+	code, err := NormalizeSetupCode(setupCode)
+	if err != nil {
+		return SetupInfo{}, err
+	}
+	id, err := NormalizeSetupID(setupID)
+	if err != nil {
+		return SetupInfo{}, err
+	}
+	addr, err := NormalizeMAC(mac)
+	if err != nil {
+		return SetupInfo{}, err
+	}
+	return SetupInfo{Category: category, SetupCode: code, SetupID: id, MAC: addr}, nil
+}
+
+// Validate checks every field of the SetupInfo with ValidateSetupInfo.
+func (s SetupInfo) Validate() error {
+	return ValidateSetupInfo(s.Category, s.SetupCode, s.SetupID, s.MAC)
+}
+
+// URI returns the X-HM:// setup URI encoded in the label QR code.
+func (s SetupInfo) URI() string {
+	return GenHomeKitSetupURI(s.Category, s.SetupCode, s.SetupID)
+}
+
+// CategoryName returns the human-readable category name.
+func (s SetupInfo) CategoryName() string {
+	return CategoryName(s.Category)
+}
+
+// NormalizeSetupCode validates a setup code and returns it formatted as XXX-XX-XXX.
+// Accepts the formatted form or 8 plain digits.
+func NormalizeSetupCode(code string) (string, error) {
+	code = strings.TrimSpace(code)
+	if err := ValidateSetupCode(code); err != nil {
+		return "", err
+	}
+	return FormatSetupCode(PlainSetupCode(code)), nil
+}
+
+// FormatSetupCode formats an 8-digit plain setup code as XXX-XX-XXX.
+// Example: "61380755" -> "613-80-755"
+// If the code is not 8 characters long, returns it unchanged.
+func FormatSetupCode(raw string) string {
+	if len(raw) != 8 {
+		return raw
+	}
+	return fmt.Sprintf("%s-%s-%s", raw[0:3], raw[3:5], raw[5:8])
+}
+
+// NormalizeSetupID validates a setup ID and returns it in uppercase.
+func NormalizeSetupID(id string) (string, error) {
+	id = strings.TrimSpace(strings.ToUpper(id))
+	if err := ValidateSetupID(id); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// NormalizeMAC parses a MAC address in any notation accepted by ParseMAC and
+// returns it as uppercase hexadecimal without separators (12 or 16 characters).
+func NormalizeMAC(mac string) (string, error) {
+	addr, err := ParseMAC(mac)
+	if err != nil {
+		return "", err
+	}
+	return addr.String(), nil
+}
+
+// FormatMAC formats a MAC address by adding colons between octets.
+// Example: "AABBCCDDEEFF" -> "AA:BB:CC:DD:EE:FF"
+// If the MAC address cannot be parsed, returns it unchanged.
+func FormatMAC(mac string) string {
+	addr, err := ParseMAC(mac)
+	if err != nil {
+		return mac
+	}
+	return addr.Format(MACStyleColon)
+}
+
+// GenerateSetupID generates a random 4-character setup ID.
+// Characters are selected from 0-9 and A-Z (36 possible characters).
+func GenerateSetupID() string {
+	result := make([]byte, 4)
+	for i := range result {
+		result[i] = base36[rand.Intn(len(base36))]
+	}
+	return string(result)
+}
+
+// GenerateMAC generates a random 12-character hexadecimal MAC address.
+// The address is a locally administered unicast address (see GenerateLocalMAC),
+// so it never has the multicast bit set nor clashes with vendor-assigned
+// addresses.
+func GenerateMAC() string {
+	return GenerateLocalMAC()
+}
@@ -0,0 +1,158 @@
+package homekit
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// HAPProtocolVersion is the HAP protocol version advertised in the pv key.
+const HAPProtocolVersion = "1.1"
+
+// Status flags (sf) of a HAP accessory.
+const (
+	StatusNotPaired     = 1 << 0 // Not paired with a controller
+	StatusNotConfigured = 1 << 1 // Not configured to join a Wi-Fi network
+	StatusProblem       = 1 << 2 // A problem has been detected
+)
+
+// TXTOptions holds the TXT record values that are not printed on the label.
+// The zero value describes an unpaired accessory at configuration number 1,
+// named after its category.
+type TXTOptions struct {
+	// Model is the model name (md); empty selects the category name.
+	Model string
+	// ConfigNumber is the configuration number (c#); 0 selects 1.
+	ConfigNumber int
+	// FeatureFlags (ff): 1 for MFi hardware authentication, 2 for software authentication.
+	FeatureFlags int
+	// Paired clears the not-paired status flag.
+	Paired bool
+}
+
+// TXTRecord is the Bonjour TXT record of a HAP accessory's _hap._tcp service.
+type TXTRecord struct {
+	ConfigNumber    int    `json:"c#"`
+	FeatureFlags    int    `json:"ff"`
+	DeviceID        string `json:"id"`
+	Model           string `json:"md"`
+	ProtocolVersion string `json:"pv"`
+	StateNumber     int    `json:"s#"`
+	StatusFlags     int    `json:"sf"`
+	Category        int    `json:"ci"`
+	SetupHash       string `json:"sh"`
+}
+
+// TXTPair is one key/value entry of a TXT record.
+type TXTPair struct {
+	Key   string
+	Value string
+}
+
+// NewTXTRecord builds the TXT record advertised by the accessory described by
+// info: the category becomes ci, the MAC address the device ID (id), and the
+// setup ID and device ID the setup hash (sh).
+func NewTXTRecord(info SetupInfo, opts TXTOptions) (TXTRecord, error) {
+	if err := ValidateCategory(info.Category); err != nil {
+		return TXTRecord{}, err
+	}
+	setupID, err := NormalizeSetupID(info.SetupID)
+	if err != nil {
+		return TXTRecord{}, err
+	}
+	addr, err := ParseMAC(info.MAC)
+	if err != nil {
+		return TXTRecord{}, err
+	}
+	if addr.IsEUI64() {
+		return TXTRecord{}, fmt.Errorf("HAP device IDs are 48 bits; EUI-64 address %s cannot be used", addr.Format(MACStyleColon))
+	}
+	if opts.ConfigNumber < 0 || opts.ConfigNumber > 65535 {
+		return TXTRecord{}, fmt.Errorf("configuration number %d is out of range (1-65535)", opts.ConfigNumber)
+	}
+	if opts.FeatureFlags < 0 || opts.FeatureFlags > 0xFF {
+		return TXTRecord{}, fmt.Errorf("feature flags 0x%X do not fit in 8 bits", opts.FeatureFlags)
+	}
+
+	r := TXTRecord{
+		ConfigNumber:    max(opts.ConfigNumber, 1),
+		FeatureFlags:    opts.FeatureFlags,
+		DeviceID:        addr.Format(MACStyleColon),
+		Model:           strings.TrimSpace(opts.Model),
+		ProtocolVersion: HAPProtocolVersion,
+		StateNumber:     1,
+		StatusFlags:     StatusNotPaired,
+		Category:        info.Category,
+	}
+	if r.Model == "" {
+		r.Model = CategoryName(info.Category)
+	}
+	if opts.Paired {
+		r.StatusFlags = 0
+	}
+	r.SetupHash = SetupHash(setupID, r.DeviceID)
+	return r, nil
+}
+
+// SetupHash returns the sh value: the base64-encoded first 4 bytes of the
+// SHA-512 hash of the setup ID followed by the device ID (XX:XX:XX:XX:XX:XX).
+// Controllers use it to find the accessory whose setup code was scanned.
+func SetupHash(setupID, deviceID string) string {
+	sum := sha512.Sum512([]byte(setupID + deviceID))
+	return base64.StdEncoding.EncodeToString(sum[:4])
+}
+
+// Pairs returns the TXT record entries in the order of the HAP specification.
+func (r TXTRecord) Pairs() []TXTPair {
+	return []TXTPair{
+		{"c#", strconv.Itoa(r.ConfigNumber)},
+		{"ff", strconv.Itoa(r.FeatureFlags)},
+		{"id", r.DeviceID},
+		{"md", r.Model},
+		{"pv", r.ProtocolVersion},
+		{"s#", strconv.Itoa(r.StateNumber)},
+		{"sf", strconv.Itoa(r.StatusFlags)},
+		{"ci", strconv.Itoa(r.Category)},
+		{"sh", r.SetupHash},
+	}
+}
+
+// DNSSDCommand returns a dns-sd -R command line that registers the service
+// with this TXT record, for testing on macOS or with the mDNSResponder tools.
+func (r TXTRecord) DNSSDCommand(name string, port int) string {
+	args := []string{"dns-sd", "-R", shellQuote(name), "_hap._tcp", ".", strconv.Itoa(port)}
+	for _, p := range r.Pairs() {
+		args = append(args, shellQuote(p.Key+"="+p.Value))
+	}
+	return strings.Join(args, " ")
+}
+
+// CInitializer returns a C array of key/value string pairs holding the TXT
+// record, laid out like ESP-IDF's mdns_txt_item_t.
+func (r TXTRecord) CInitializer(ident string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "/* _hap._tcp TXT record for %s (%s) */\n", cComment(r.Model), r.DeviceID)
+	fmt.Fprintf(&sb, "static const struct { const char *key; const char *value; } %s[] = {\n", ident)
+	for _, p := range r.Pairs() {
+		fmt.Fprintf(&sb, "\t{ %s, %s },\n", strconv.Quote(p.Key), strconv.Quote(p.Value))
+	}
+	sb.WriteString("};\n")
+	return sb.String()
+}
+
+// shellQuote quotes s for a POSIX shell when it contains special characters.
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("=:,./+_-", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// cComment makes s safe to embed in a C block comment.
+func cComment(s string) string {
+	return strings.ReplaceAll(s, "*/", "* /")
+}
@@ -0,0 +1,83 @@
+package homekit
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSetupHash(t *testing.T) {
+	// First four bytes of SHA-512 over the setup ID and device ID, as printed by
+	// printf %s "$id$mac" | openssl dgst -sha512 -binary | head -c4 | base64
+	tests := []struct{ setupID, deviceID, want string }{
+		{"ABCD", "8E:17:87:A9:C4:32", "ENIO5g=="},
+		{"7OSX", "AA:BB:CC:DD:EE:FF", "XIonQA=="},
+		{"1QJ8", "AA:BB:CC:DD:EE:FF", "Jyv0aQ=="},
+		{"7OSX", "12:34:56:78:9A:BC", "Pb1TTQ=="},
+	}
+	for _, tt := range tests {
+		if got := SetupHash(tt.setupID, tt.deviceID); got != tt.want {
+			t.Errorf("SetupHash(%q, %q) = %s, want %s", tt.setupID, tt.deviceID, got, tt.want)
+		}
+	}
+}
+
+func TestNewTXTRecord(t *testing.T) {
+	// Lowercase ID and bare MAC: sh is computed over the normalized values
+	info := SetupInfo{Category: 5, SetupCode: "613-80-755", SetupID: "1qj8", MAC: "aabbccddeeff"}
+	r, err := NewTXTRecord(info, TXTOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []TXTPair{
+		{"c#", "1"},
+		{"ff", "0"},
+		{"id", "AA:BB:CC:DD:EE:FF"},
+		{"md", CategoryName(5)},
+		{"pv", "1.1"},
+		{"s#", "1"},
+		{"sf", "1"},
+		{"ci", "5"},
+		{"sh", "Jyv0aQ=="},
+	}
+	if got := r.Pairs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Pairs() = %v, want %v", got, want)
+	}
+
+	r, err = NewTXTRecord(info, TXTOptions{Model: "Lamp1,1", ConfigNumber: 7, FeatureFlags: 2, Paired: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Model != "Lamp1,1" || r.ConfigNumber != 7 || r.FeatureFlags != 2 || r.StatusFlags != 0 {
+		t.Errorf("NewTXTRecord options not applied: %+v", r)
+	}
+}
+
+func TestNewTXTRecordErrors(t *testing.T) {
+	valid := SetupInfo{Category: 5, SetupCode: "613-80-755", SetupID: "1QJ8", MAC: "AABBCCDDEEFF"}
+	tests := []struct {
+		name string
+		info SetupInfo
+		opts TXTOptions
+	}{
+		{"EUI-64 address", SetupInfo{Category: 5, SetupID: "1QJ8", MAC: "0011223344556677"}, TXTOptions{}},
+		{"bad setup ID", SetupInfo{Category: 5, SetupID: "1Q-8", MAC: "AABBCCDDEEFF"}, TXTOptions{}},
+		{"config number", valid, TXTOptions{ConfigNumber: 65536}},
+		{"feature flags", valid, TXTOptions{FeatureFlags: 0x100}},
+	}
+	for _, tt := range tests {
+		if _, err := NewTXTRecord(tt.info, tt.opts); err == nil {
+			t.Errorf("NewTXTRecord accepted %s", tt.name)
+		}
+	}
+}
+
+func TestDNSSDCommand(t *testing.T) {
+	r, err := NewTXTRecord(SetupInfo{Category: 5, SetupID: "1QJ8", MAC: "AABBCCDDEEFF"}, TXTOptions{Model: "Lamp"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "dns-sd -R 'My Lamp' _hap._tcp . 51826 'c#=1' ff=0 id=AA:BB:CC:DD:EE:FF md=Lamp pv=1.1 's#=1' sf=1 ci=5 sh=Jyv0aQ=="
+	if got := r.DNSSDCommand("My Lamp", 51826); got != want {
+		t.Errorf("DNSSDCommand() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,138 @@
+package homekit
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Field names reported in ValidationError.Field.
+// They match the JSON keys used by the WASM requests.
+const (
+	FieldCategory  = "category"
+	FieldSetupCode = "password"
+	FieldSetupID   = "setupId"
+	FieldMAC       = "mac"
+)
+
+// Rule names reported in ValidationError.Rule.
+const (
+	RuleRequired        = "required"         // Value is empty
+	RuleLength          = "length"           // Value has the wrong number of characters
+	RuleFormat          = "format"           // Separators are missing or misplaced
+	RuleCharset         = "charset"          // Value contains characters outside the allowed set
+	RuleUnknownCategory = "unknown-category" // Category ID is not defined by HomeKit
+	RuleRepeatedDigits  = "repeated-digits"  // All digits the same (00000000 ... 99999999)
+	RuleSequence        = "sequence"         // 12345678 or 87654321
+)
+
+// ValidationError describes why a setup parameter was rejected.
+// Field names the offending input, Rule identifies the violated rule and
+// Message is a human-readable explanation suitable for end users.
+type ValidationError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Error implements the error interface and returns the human-readable message.
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// newValidationError builds a ValidationError with a formatted message.
+func newValidationError(field, rule, format string, args ...interface{}) *ValidationError {
+	return &ValidationError{Field: field, Rule: rule, Message: fmt.Sprintf(format, args...)}
+}
+
+// ValidateSetupInfo validates category, setup code, setup ID and MAC address in that order.
+// It returns the first *ValidationError found, or nil if every value is valid.
+func ValidateSetupInfo(category int, password, setupID, mac string) error {
+	if err := ValidateCategory(category); err != nil {
+		return err
+	}
+	if err := ValidateSetupCode(password); err != nil {
+		return err
+	}
+	if err := ValidateSetupID(setupID); err != nil {
+		return err
+	}
+	return ValidateMAC(mac)
+}
+
+// ValidateCategory checks that the category ID is defined in CategoryReference.
+func ValidateCategory(category int) error {
+	if category < 1 {
+		return newValidationError(FieldCategory, RuleRequired, "category must be a positive number")
+	}
+	if _, exists := CategoryReference[category]; !exists {
+		return newValidationError(FieldCategory, RuleUnknownCategory, "invalid category ID: %d. Use 'list-categories' to see available categories", category)
+	}
+	return nil
+}
+
+// ValidateSetupCode validates a setup code against the HAP specification.
+// Accepts the formatted XXX-XX-XXX form or 8 plain digits. Besides the format,
+// the code must not be one of the codes disallowed by the spec (all digits the
+// same, 12345678, 87654321). Codes the spec allows but that are easy to guess
+// pass; see SetupCodeWarning.
+func ValidateSetupCode(code string) error {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return newValidationError(FieldSetupCode, RuleRequired, "setup code cannot be empty. Expected format: XXX-XX-XXX (e.g., 613-80-755)")
+	}
+
+	if strings.Contains(code, "-") {
+		// Format XXX-XX-XXX has 10 characters: 3 + 1 + 2 + 1 + 3 = 10
+		if len(code) != 10 {
+			return newValidationError(FieldSetupCode, RuleLength, "invalid password length (%d). Expected format: XXX-XX-XXX (e.g., 613-80-755)", len(code))
+		}
+		if code[3] != '-' || code[6] != '-' || strings.Count(code, "-") != 2 {
+			return newValidationError(FieldSetupCode, RuleFormat, "invalid password format. Expected format: XXX-XX-XXX (e.g., 613-80-755)")
+		}
+	} else if len(code) != 8 {
+		return newValidationError(FieldSetupCode, RuleLength, "invalid password length (%d). Expected format: XXX-XX-XXX (e.g., 613-80-755)", len(code))
+	}
+
+	raw := PlainSetupCode(code)
+	if len(raw) != 8 {
+		return newValidationError(FieldSetupCode, RuleLength, "invalid password length (%d digits). Expected format: XXX-XX-XXX (e.g., 613-80-755)", len(raw))
+	}
+	for i, r := range raw {
+		if r < '0' || r > '9' {
+			return newValidationError(FieldSetupCode, RuleCharset, "invalid character '%c' at digit %d. Setup code must contain only digits 0-9", r, i+1)
+		}
+	}
+
+	if rule, reason := setupCodeRule(raw); rule != "" {
+		return newValidationError(FieldSetupCode, rule, "setup code %s is not allowed: %s", code, reason)
+	}
+
+	return nil
+}
+
+// ValidateSetupID validates setup ID format (4 alphanumeric: 0-9, A-Z).
+// Lowercase letters are accepted and treated as uppercase.
+func ValidateSetupID(id string) error {
+	id = strings.TrimSpace(strings.ToUpper(id))
+	if id == "" {
+		return newValidationError(FieldSetupID, RuleRequired, "setup ID cannot be empty. Expected 4 alphanumeric characters (0-9, A-Z)")
+	}
+	if len(id) != 4 {
+		return newValidationError(FieldSetupID, RuleLength, "invalid setup ID length. Expected 4 alphanumeric characters (0-9, A-Z)")
+	}
+
+	for i, r := range id {
+		if !((r >= '0' && r <= '9') || (r >= 'A' && r <= 'Z')) {
+			return newValidationError(FieldSetupID, RuleCharset, "invalid character '%c' at position %d. Setup ID must contain only 0-9 and A-Z", r, i+1)
+		}
+	}
+
+	return nil
+}
+
+// ValidateMAC validates a MAC address in any notation accepted by ParseMAC
+// (EUI-48 or EUI-64, bare, colon, hyphen or Cisco dotted).
+func ValidateMAC(mac string) error {
+	_, err := ParseMAC(mac)
+	return err
+}
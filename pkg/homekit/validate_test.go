@@ -0,0 +1,90 @@
+package homekit
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateSetupCode(t *testing.T) {
+	tests := []struct {
+		code string
+		rule string // empty for valid codes
+	}{
+		{"613-80-755", ""},
+		{"61380755", ""},
+		{" 613-80-755 ", ""},
+		{"234-56-789", ""}, // ascending run: allowed by the spec
+		{"121-21-212", ""}, // repeated pattern: allowed by the spec
+		{"765-43-210", ""}, // descending run: allowed by the spec
+		{"", RuleRequired},
+		{"613-80-75", RuleLength},
+		{"6138075", RuleLength},
+		{"613807555", RuleLength},
+		{"613-80-7-5", RuleFormat},
+		{"613--0-755", RuleFormat},
+		{"61-380-755", RuleFormat},
+		{"613-8a-755", RuleCharset},
+		{"000-00-000", RuleRepeatedDigits},
+		{"111-11-111", RuleRepeatedDigits},
+		{"99999999", RuleRepeatedDigits},
+		{"123-45-678", RuleSequence},
+		{"876-54-321", RuleSequence},
+	}
+	for _, tt := range tests {
+		err := ValidateSetupCode(tt.code)
+		if tt.rule == "" {
+			if err != nil {
+				t.Errorf("ValidateSetupCode(%q) = %v, want nil", tt.code, err)
+			}
+			continue
+		}
+		var verr *ValidationError
+		if !errors.As(err, &verr) {
+			t.Errorf("ValidateSetupCode(%q) = %v, want rule %s", tt.code, err, tt.rule)
+			continue
+		}
+		if verr.Field != FieldSetupCode || verr.Rule != tt.rule {
+			t.Errorf("ValidateSetupCode(%q) field/rule = %s/%s, want %s/%s", tt.code, verr.Field, verr.Rule, FieldSetupCode, tt.rule)
+		}
+	}
+}
+
+func TestParseSetupInfoMalformedCode(t *testing.T) {
+	// Used to panic with index out of range after the dashes were removed
+	for _, code := range []string{"613-80-7-5", "613--0-755", "---------0"} {
+		if _, err := ParseSetupInfo(5, code, "ABCD", "AABBCCDDEEFF"); err == nil {
+			t.Errorf("ParseSetupInfo accepted setup code %q", code)
+		}
+	}
+}
+
+func TestSetupCodeWarning(t *testing.T) {
+	tests := []struct {
+		code string
+		warn bool
+	}{
+		{"613-80-755", false},
+		{"234-56-789", true},
+		{"76543210", true},
+		{"121-21-212", true},
+		{"123-45-678", false}, // invalid, not a warning
+		{"613-80-7-5", false},
+	}
+	for _, tt := range tests {
+		if got := SetupCodeWarning(tt.code) != ""; got != tt.warn {
+			t.Errorf("SetupCodeWarning(%q) returned a warning = %v, want %v", tt.code, got, tt.warn)
+		}
+	}
+}
+
+func TestGeneratedSetupCodesAreNotWeak(t *testing.T) {
+	for i := 0; i < 1000; i++ {
+		code := GenerateHomeKitSetupCode()
+		if err := ValidateSetupCode(code); err != nil {
+			t.Fatalf("generated invalid setup code %s: %v", code, err)
+		}
+		if w := SetupCodeWarning(code); w != "" {
+			t.Fatalf("generated weak setup code: %s", w)
+		}
+	}
+}